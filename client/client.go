@@ -0,0 +1,363 @@
+// Package client is a typed Go client for the payment orchestrator HTTP API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/health"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/model"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/trace"
+)
+
+// Re-exported API types, so integrators outside this module can name them.
+type (
+	PaymentRequest  = model.PaymentRequest
+	PaymentResult   = model.PaymentResult
+	PaymentStatus   = model.PaymentStatus
+	Attempt         = model.Attempt
+	ProcessorHealth = health.ProcessorHealth
+)
+
+// ErrNotFound is returned (wrapped in an *APIError) when the server responds 404.
+var ErrNotFound = errors.New("not found")
+
+// APIError is returned for error responses that carry no payment result,
+// such as validation failures.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("api error %d: %s", e.StatusCode, e.Message)
+}
+
+// Unwrap lets errors.Is(err, ErrNotFound) match 404 responses.
+func (e *APIError) Unwrap() error {
+	if e.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeclinedError is returned when a payment is declined (422). The full result,
+// including every attempt, is exposed for inspection.
+type DeclinedError struct {
+	Result PaymentResult
+}
+
+func (e *DeclinedError) Error() string {
+	return fmt.Sprintf("payment %s %s", e.Result.TransactionID, e.Result.Status)
+}
+
+// UnavailableError is returned when retries were exhausted by transient processor
+// failures (503). The payment may succeed if retried after RetryAfter.
+type UnavailableError struct {
+	Result     PaymentResult
+	RetryAfter time.Duration
+}
+
+func (e *UnavailableError) Error() string {
+	return fmt.Sprintf("payment %s %s: processors unavailable, retry after %s",
+		e.Result.TransactionID, e.Result.Status, e.RetryAfter)
+}
+
+// Client calls the orchestrator API.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient replaces the default http.Client.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// New creates a Client for the service at baseURL (e.g. "http://localhost:8080").
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// ProcessPayment submits a payment. It returns the result on approval (200 or 201)
+// or when the payment is left pending (202), a *DeclinedError on 422, an *UnavailableError on 503, and an *APIError otherwise,
+// including a 503 that carries no payment result, such as during maintenance.
+func (c *Client) ProcessPayment(ctx context.Context, req PaymentRequest) (PaymentResult, error) {
+	return c.submitPayment(ctx, "/payments", req)
+}
+
+// submitPayment posts to a payment-processing endpoint and maps its status codes.
+func (c *Client) submitPayment(ctx context.Context, path string, body interface{}) (PaymentResult, error) {
+	resp, err := c.do(ctx, http.MethodPost, path, body)
+	if err != nil {
+		return PaymentResult{}, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusAccepted, http.StatusUnprocessableEntity, http.StatusServiceUnavailable:
+	default:
+		return PaymentResult{}, decodeAPIError(resp)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return PaymentResult{}, fmt.Errorf("read payment result: %w", err)
+	}
+	// A 503 without a payment result, e.g. during maintenance, is a plain API error
+	if resp.StatusCode == http.StatusServiceUnavailable {
+		var probe struct {
+			Status model.PaymentStatus `json:"status"`
+			Error  string              `json:"error"`
+		}
+		if json.Unmarshal(data, &probe) != nil || probe.Status == "" {
+			message := probe.Error
+			if message == "" {
+				message = strings.TrimSpace(string(data))
+			}
+			return PaymentResult{}, &APIError{StatusCode: resp.StatusCode, Message: message}
+		}
+	}
+
+	var result PaymentResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return PaymentResult{}, fmt.Errorf("decode payment result: %w", err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnprocessableEntity:
+		return result, &DeclinedError{Result: result}
+	case http.StatusServiceUnavailable:
+		return result, &UnavailableError{Result: result, RetryAfter: parseRetryAfter(resp.Header)}
+	default:
+		return result, nil
+	}
+}
+
+// ReplayPayment re-runs a stored payment under a new transaction ID. Errors follow
+// ProcessPayment; a missing transaction yields an error matching ErrNotFound.
+func (c *Client) ReplayPayment(ctx context.Context, txnID string) (PaymentResult, error) {
+	return c.submitPayment(ctx, "/payments/"+url.PathEscape(txnID)+"/replay", nil)
+}
+
+// ResolvePayment applies the final outcome code of a pending payment and returns
+// the updated result. A non-pending payment yields an *APIError with status 409.
+func (c *Client) ResolvePayment(ctx context.Context, txnID string, code model.ResponseCode, message string) (PaymentResult, error) {
+	var result PaymentResult
+	body := map[string]string{"code": string(code), "message": message}
+	err := c.doJSON(ctx, http.MethodPatch, "/payments/"+url.PathEscape(txnID), body, &result)
+	return result, err
+}
+
+// GetPaymentHistory returns the stored result for a transaction. A missing
+// transaction yields an error matching ErrNotFound.
+func (c *Client) GetPaymentHistory(ctx context.Context, txnID string) (PaymentResult, error) {
+	var result PaymentResult
+	err := c.doJSON(ctx, http.MethodGet, "/payments/"+url.PathEscape(txnID), nil, &result)
+	return result, err
+}
+
+// CustomerPayments is one page of a customer's payments, most recent first.
+type CustomerPayments struct {
+	CustomerID string          `json:"customer_id"`
+	Payments   []PaymentResult `json:"payments"`
+	Total      int             `json:"total"`
+	Offset     int             `json:"offset"`
+	Limit      int             `json:"limit"`
+}
+
+// GetCustomerPayments returns a page of a customer's payments. A zero limit uses
+// the server default. A customer without payments yields an empty page.
+func (c *Client) GetCustomerPayments(ctx context.Context, customerID string, offset, limit int) (CustomerPayments, error) {
+	q := url.Values{}
+	if offset > 0 {
+		q.Set("offset", strconv.Itoa(offset))
+	}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+	path := "/customers/" + url.PathEscape(customerID) + "/payments"
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+	var page CustomerPayments
+	err := c.doJSON(ctx, http.MethodGet, path, nil, &page)
+	return page, err
+}
+
+// GetProcessorHealth returns the health of every processor that has recorded outcomes.
+func (c *Client) GetProcessorHealth(ctx context.Context) ([]ProcessorHealth, error) {
+	var resp struct {
+		Processors []ProcessorHealth `json:"processors"`
+	}
+	if err := c.doJSON(ctx, http.MethodGet, "/health/processors", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Processors, nil
+}
+
+// GetSingleProcessorHealth returns the health of one registered processor. An
+// unregistered name yields an error matching ErrNotFound.
+func (c *Client) GetSingleProcessorHealth(ctx context.Context, name string) (ProcessorHealth, error) {
+	var h ProcessorHealth
+	err := c.doJSON(ctx, http.MethodGet, "/health/processors/"+url.PathEscape(name), nil, &h)
+	return h, err
+}
+
+// SimulateDegrade toggles degraded mode on a mock processor.
+func (c *Client) SimulateDegrade(ctx context.Context, processorName string, degraded bool) error {
+	body := map[string]interface{}{"processor_name": processorName, "degraded": degraded}
+	return c.doJSON(ctx, http.MethodPost, "/simulate/degrade", body, nil)
+}
+
+// BatchRequest configures a simulated batch. Empty Method and Currency use the
+// server defaults; Currencies spreads the batch across several currencies.
+type BatchRequest struct {
+	Count      int      `json:"count"`
+	Method     string   `json:"method,omitempty"`
+	Currency   string   `json:"currency,omitempty"`
+	Currencies []string `json:"currencies,omitempty"`
+}
+
+// CurrencyTotals sums one currency's payments within a batch.
+type CurrencyTotals struct {
+	Payments       int     `json:"payments"`
+	Approved       int     `json:"approved"`
+	Amount         float64 `json:"amount"`
+	ApprovedAmount float64 `json:"approved_amount"`
+	Fees           float64 `json:"fees"`
+	NetAmount      float64 `json:"net_amount"`
+}
+
+// BatchSummary is the aggregate result of a simulated batch.
+type BatchSummary struct {
+	Total            int     `json:"total"`
+	Approved         int     `json:"approved"`
+	Declined         int     `json:"declined"`
+	ExhaustedRetries int     `json:"exhausted_retries"`
+	ApprovalRate     float64 `json:"approval_rate"`
+	AvgAttempts      float64 `json:"avg_attempts"`
+	TotalFees        float64 `json:"total_fees"`
+	LatencyMinMs     float64 `json:"latency_min_ms"`
+	LatencyP50Ms     float64 `json:"latency_p50_ms"`
+	LatencyP95Ms     float64 `json:"latency_p95_ms"`
+	LatencyP99Ms     float64 `json:"latency_p99_ms"`
+	LatencyMaxMs     float64 `json:"latency_max_ms"`
+	// ByCurrency holds amount and fee totals per currency.
+	ByCurrency map[string]CurrencyTotals `json:"by_currency"`
+}
+
+// SimulateBatch runs a batch of random payments and returns its summary.
+func (c *Client) SimulateBatch(ctx context.Context, req BatchRequest) (BatchSummary, error) {
+	var summary BatchSummary
+	err := c.doJSON(ctx, http.MethodPost, "/simulate/batch", req, &summary)
+	return summary, err
+}
+
+// ResetSummary reports what POST /simulate/reset cleared.
+type ResetSummary struct {
+	PaymentsCleared      int      `json:"payments_cleared"`
+	HealthWindowsCleared int      `json:"health_windows_cleared"`
+	ProcessorsReset      []string `json:"processors_reset"`
+}
+
+// SimulateReset clears all simulation state on the server.
+func (c *Client) SimulateReset(ctx context.Context) (ResetSummary, error) {
+	var summary ResetSummary
+	err := c.doJSON(ctx, http.MethodPost, "/simulate/reset", nil, &summary)
+	return summary, err
+}
+
+// SimulateMode sets the global simulation mode: "normal", "approve_all" or "decline_all".
+func (c *Client) SimulateMode(ctx context.Context, mode string) error {
+	return c.doJSON(ctx, http.MethodPost, "/simulate/mode", map[string]string{"mode": mode}, nil)
+}
+
+// doJSON performs a request expecting 200, decoding the body into out when non-nil.
+func (c *Client) doJSON(ctx context.Context, method, path string, body, out interface{}) error {
+	resp, err := c.do(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return decodeAPIError(resp)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode %s %s response: %w", method, path, err)
+	}
+	return nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body interface{}) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("encode %s %s request: %w", method, path, err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("build %s %s request: %w", method, path, err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if traceID := trace.TraceIDFromContext(ctx); traceID != "" {
+		req.Header.Set(trace.HeaderName, traceID)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	return resp, nil
+}
+
+// decodeAPIError builds an *APIError from an error body ({"error": "..."}).
+func decodeAPIError(resp *http.Response) error {
+	var body struct {
+		Error string `json:"error"`
+	}
+	data, _ := io.ReadAll(resp.Body)
+	if err := json.Unmarshal(data, &body); err != nil || body.Error == "" {
+		body.Error = strings.TrimSpace(string(data))
+	}
+	return &APIError{StatusCode: resp.StatusCode, Message: body.Error}
+}
+
+func parseRetryAfter(h http.Header) time.Duration {
+	seconds, err := strconv.Atoi(h.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,195 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/handler"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/health"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/model"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/orchestrator"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/processor"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/trace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubProcessor returns a response code chosen per customer ID, defaulting to
+// approval, without simulated latency.
+type stubProcessor struct {
+	name  string
+	codes map[string]model.ResponseCode
+}
+
+func (p stubProcessor) Name() string               { return p.name }
+func (p stubProcessor) SupportedMethods() []string { return []string{"card", "pix", "oxxo", "pse"} }
+func (p stubProcessor) Process(ctx context.Context, req model.PaymentRequest) model.ProcessorResponse {
+	code, ok := p.codes[req.CustomerID]
+	if !ok {
+		code = model.Approved
+	}
+	return model.ProcessorResponse{
+		ProcessorName: p.name,
+		Code:          code,
+		Message:       "stub response",
+		Timestamp:     time.Now(),
+		Latency:       time.Millisecond,
+	}
+}
+
+func setupClient(t *testing.T) *Client {
+	t.Helper()
+	codes := map[string]model.ResponseCode{
+		"cust-fraud": model.DeclinedFraud,
+		"cust-error": model.ProcessorError,
+	}
+	orch := orchestrator.New([]processor.Processor{
+		stubProcessor{name: "ProcA", codes: codes},
+		stubProcessor{name: "ProcB", codes: codes},
+	}, health.NewMonitorWithConfig(50, 10*time.Minute))
+
+	mux := http.NewServeMux()
+	handler.New(orch).RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return New(srv.URL, WithHTTPClient(srv.Client()))
+}
+
+func paymentRequest(txnID, customerID string) PaymentRequest {
+	return PaymentRequest{
+		TransactionID: txnID,
+		Amount:        100.0,
+		Currency:      "USD",
+		PaymentMethod: "card",
+		CustomerID:    customerID,
+	}
+}
+
+func TestClient_ProcessPaymentAndHistory(t *testing.T) {
+	c := setupClient(t)
+	ctx := trace.WithTraceID(context.Background(), "client-trace")
+
+	result, err := c.ProcessPayment(ctx, paymentRequest("tx-client-1", "cust-ok"))
+	require.NoError(t, err)
+	assert.Equal(t, model.StatusApproved, result.Status)
+	assert.Equal(t, "ProcA", result.WinningProcessor)
+	require.Len(t, result.Attempts, 1)
+	assert.Equal(t, "client-trace", result.Attempts[0].TraceID, "trace ID should be sent as a header")
+	assert.Equal(t, time.Millisecond, result.Attempts[0].Response.Latency)
+
+	history, err := c.GetPaymentHistory(context.Background(), "tx-client-1")
+	require.NoError(t, err)
+	assert.Equal(t, result.TransactionID, history.TransactionID)
+	assert.Equal(t, result.Status, history.Status)
+	assert.Len(t, history.Attempts, 1)
+
+	replay, err := c.ReplayPayment(context.Background(), "tx-client-1")
+	require.NoError(t, err)
+	assert.NotEqual(t, result.TransactionID, replay.TransactionID)
+	assert.Equal(t, model.StatusApproved, replay.Status)
+
+	_, err = c.ReplayPayment(context.Background(), "tx-missing")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	page, err := c.GetCustomerPayments(context.Background(), "cust-ok", 0, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, page.Total)
+	require.Len(t, page.Payments, 1)
+	assert.Equal(t, replay.TransactionID, page.Payments[0].TransactionID)
+}
+
+func TestClient_ProcessPaymentErrors(t *testing.T) {
+	c := setupClient(t)
+
+	t.Run("declined returns DeclinedError with result", func(t *testing.T) {
+		_, err := c.ProcessPayment(context.Background(), paymentRequest("tx-fraud", "cust-fraud"))
+		var declined *DeclinedError
+		require.ErrorAs(t, err, &declined)
+		assert.Equal(t, model.StatusDeclined, declined.Result.Status)
+		assert.Equal(t, model.DeclinedFraud, declined.Result.FinalResponse.Code)
+	})
+
+	t.Run("transient exhaustion returns UnavailableError", func(t *testing.T) {
+		_, err := c.ProcessPayment(context.Background(), paymentRequest("tx-error", "cust-error"))
+		var unavailable *UnavailableError
+		require.ErrorAs(t, err, &unavailable)
+		assert.Equal(t, model.StatusExhaustedRetries, unavailable.Result.Status)
+		assert.Greater(t, unavailable.RetryAfter, time.Duration(0))
+	})
+
+	t.Run("validation failure returns APIError", func(t *testing.T) {
+		_, err := c.ProcessPayment(context.Background(), PaymentRequest{TransactionID: "tx-bad"})
+		var apiErr *APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+		assert.Contains(t, apiErr.Message, "amount")
+	})
+
+	t.Run("maintenance returns APIError", func(t *testing.T) {
+		orch := orchestrator.New([]processor.Processor{stubProcessor{name: "ProcA"}}, health.NewMonitorWithConfig(50, 10*time.Minute))
+		mux := http.NewServeMux()
+		handler.New(orch).RegisterRoutes(mux)
+		srv := httptest.NewServer(mux)
+		defer srv.Close()
+		resp, err := srv.Client().Post(srv.URL+"/admin/maintenance", "application/json", strings.NewReader(`{"enabled": true}`))
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		_, err = New(srv.URL, WithHTTPClient(srv.Client())).ProcessPayment(context.Background(), paymentRequest("tx-maintenance", "cust-ok"))
+		var apiErr *APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+		assert.Contains(t, apiErr.Message, "maintenance")
+		var unavailable *UnavailableError
+		assert.False(t, errors.As(err, &unavailable))
+	})
+
+	t.Run("missing payment matches ErrNotFound", func(t *testing.T) {
+		_, err := c.GetPaymentHistory(context.Background(), "tx-missing")
+		assert.True(t, errors.Is(err, ErrNotFound))
+	})
+}
+
+func TestClient_HealthAndSimulation(t *testing.T) {
+	c := setupClient(t)
+	ctx := context.Background()
+
+	summary, err := c.SimulateBatch(ctx, BatchRequest{Count: 5, Currencies: []string{"USD", "MXN"}})
+	require.NoError(t, err)
+	assert.Equal(t, 5, summary.Total)
+	assert.Equal(t, 5, summary.Approved)
+	assert.Equal(t, 3, summary.ByCurrency["USD"].Payments)
+	assert.Equal(t, 2, summary.ByCurrency["MXN"].Payments)
+
+	healths, err := c.GetProcessorHealth(ctx)
+	require.NoError(t, err)
+	require.Len(t, healths, 1, "only the primary processed traffic")
+	assert.Equal(t, "ProcA", healths[0].ProcessorName)
+
+	single, err := c.GetSingleProcessorHealth(ctx, "ProcB")
+	require.NoError(t, err)
+	assert.Equal(t, health.StatusHealthy, single.Status)
+	_, err = c.GetSingleProcessorHealth(ctx, "NoSuchPay")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	// The stubs are not mock processors, so degradation is not found
+	err = c.SimulateDegrade(ctx, "ProcA", true)
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	require.NoError(t, c.SimulateMode(ctx, "approve_all"))
+	var apiErr *APIError
+	require.ErrorAs(t, c.SimulateMode(ctx, "chaos"), &apiErr)
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+
+	reset, err := c.SimulateReset(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 5, reset.PaymentsCleared)
+	assert.Empty(t, reset.ProcessorsReset)
+}
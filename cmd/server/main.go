@@ -5,21 +5,29 @@ import (
 	"net/http"
 	"os"
 
-	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/config"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/apikey"
 	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/handler"
 	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/health"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/logging"
 	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/orchestrator"
 	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/processor"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/server"
 )
 
 func main() {
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-	}))
-	slog.SetDefault(logger)
+	logCfg, err := logging.ConfigFromEnv()
+	if err != nil {
+		slog.SetDefault(logging.New(os.Stdout, logging.DefaultConfig()))
+		slog.Error("log_config_invalid", "error", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(logging.New(os.Stdout, logCfg))
 
 	// Initialize health monitor
 	monitor := health.NewMonitor()
+	if url := os.Getenv("STATUS_WEBHOOK_URL"); url != "" {
+		monitor.OnStatusChange(health.NewWebhookNotifier(health.DefaultWebhookConfig(url)).Notify)
+	}
 
 	// Initialize processors
 	processors := []processor.Processor{
@@ -29,8 +37,16 @@ func main() {
 		processor.NewGlobalPay(),
 	}
 
-	// Initialize orchestrator
-	orch := orchestrator.New(processors, monitor)
+	// Initialize orchestrator with the API keys' processor allow-lists
+	keys, err := apikey.ConfigFromEnv()
+	if err != nil {
+		slog.Error("api_key_config_invalid", "error", err)
+		os.Exit(1)
+	}
+	orchCfg := orchestrator.DefaultConfig()
+	orchCfg.ProcessorAllowList = keys.AllowList
+	orchCfg.RejectUnknownAPIKeys = keys.RejectUnknown
+	orch := orchestrator.NewWithConfig(processors, monitor, orchCfg)
 
 	// Initialize HTTP handlers
 	h := handler.New(orch)
@@ -39,17 +55,23 @@ func main() {
 	mux := http.NewServeMux()
 	h.RegisterRoutes(mux)
 
-	port := config.ServerPort
-	if envPort := os.Getenv("PORT"); envPort != "" {
-		port = ":" + envPort
+	srvCfg, err := server.ConfigFromEnv()
+	if err != nil {
+		slog.Error("server_config_invalid", "error", err)
+		os.Exit(1)
 	}
+	srv := server.NewServer(handler.Recover(handler.APIKeys(keys, handler.JSONErrors(mux))), srvCfg)
 
 	slog.Info("server_starting",
-		"port", port,
+		"port", srvCfg.Addr,
 		"processors", []string{"PayFlow", "CardMax", "PixPay", "GlobalPay"},
+		"read_header_timeout", srvCfg.ReadHeaderTimeout.String(),
+		"read_timeout", srvCfg.ReadTimeout.String(),
+		"write_timeout", srvCfg.WriteTimeout.String(),
+		"idle_timeout", srvCfg.IdleTimeout.String(),
 	)
 
-	if err := http.ListenAndServe(port, mux); err != nil {
+	if err := srv.ListenAndServe(); err != nil {
 		slog.Error("server_failed", "error", err)
 		os.Exit(1)
 	}
@@ -0,0 +1,101 @@
+// Package apikey carries the API key a request authenticated with through context,
+// so routing can restrict each client to its allowed processors.
+package apikey
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/config"
+)
+
+// HeaderName is the HTTP header clients send their API key in.
+const HeaderName = "X-API-Key"
+
+// Environment variables configuring API keys.
+const (
+	// EnvAllowList registers API keys with the processors each may use, as
+	// semicolon-separated key=Processor,Processor entries, e.g.
+	// "key-a=PayFlow,CardMax;key-b=PixPay".
+	EnvAllowList = "API_KEY_ALLOW_LIST"
+	// EnvRejectUnknown, when true, declines payments sent without a registered key.
+	EnvRejectUnknown = "API_KEY_REJECT_UNKNOWN"
+)
+
+// Config holds the registered API keys and their processor allow-lists.
+type Config struct {
+	AllowList     map[string][]string
+	RejectUnknown bool
+}
+
+// Registered reports whether key has an allow-list entry.
+func (c Config) Registered(key string) bool {
+	_, ok := c.AllowList[key]
+	return ok
+}
+
+// ConfigFromEnv reads API_KEY_ALLOW_LIST and API_KEY_REJECT_UNKNOWN. With neither
+// set no key is registered and unknown keys follow config.RejectUnknownAPIKeys.
+func ConfigFromEnv() (Config, error) {
+	return configFromLookup(os.LookupEnv)
+}
+
+func configFromLookup(lookup func(string) (string, bool)) (Config, error) {
+	cfg := Config{RejectUnknown: config.RejectUnknownAPIKeys}
+	if raw, ok := lookup(EnvAllowList); ok && raw != "" {
+		allowList, err := parseAllowList(raw)
+		if err != nil {
+			return Config{}, fmt.Errorf("parse %s: %w", EnvAllowList, err)
+		}
+		cfg.AllowList = allowList
+	}
+	if raw, ok := lookup(EnvRejectUnknown); ok && raw != "" {
+		reject, err := strconv.ParseBool(raw)
+		if err != nil {
+			return Config{}, fmt.Errorf("parse %s: %w", EnvRejectUnknown, err)
+		}
+		cfg.RejectUnknown = reject
+	}
+	return cfg, nil
+}
+
+func parseAllowList(raw string) (map[string][]string, error) {
+	allowList := make(map[string][]string)
+	for _, entry := range strings.Split(raw, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		key, names, ok := strings.Cut(entry, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("entry %q must be key=Processor,Processor", entry)
+		}
+		if _, dup := allowList[key]; dup {
+			return nil, fmt.Errorf("duplicate key in entry %q", entry)
+		}
+		processors := []string{}
+		for _, name := range strings.Split(names, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				processors = append(processors, name)
+			}
+		}
+		allowList[key] = processors
+	}
+	return allowList, nil
+}
+
+type contextKey struct{}
+
+// WithAPIKey returns a copy of ctx carrying the given API key.
+func WithAPIKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, contextKey{}, key)
+}
+
+// FromContext returns the API key carried by ctx, or "" if none is set.
+func FromContext(ctx context.Context) string {
+	key, _ := ctx.Value(contextKey{}).(string)
+	return key
+}
@@ -0,0 +1,80 @@
+package apikey
+
+import (
+	"context"
+	"testing"
+
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromContext(t *testing.T) {
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		expected string
+	}{
+		{"no API key", context.Background(), ""},
+		{"with API key", WithAPIKey(context.Background(), "key-123"), "key-123"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, FromContext(tt.ctx))
+		})
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    Config
+		wantErr bool
+	}{
+		{
+			name: "defaults when unset",
+			env:  map[string]string{},
+			want: Config{RejectUnknown: config.RejectUnknownAPIKeys},
+		},
+		{
+			name: "allow-list and reject flag",
+			env: map[string]string{
+				EnvAllowList:     "key-a=PayFlow, CardMax; key-b=PixPay;",
+				EnvRejectUnknown: "true",
+			},
+			want: Config{
+				AllowList:     map[string][]string{"key-a": {"PayFlow", "CardMax"}, "key-b": {"PixPay"}},
+				RejectUnknown: true,
+			},
+		},
+		{
+			name: "key with no processors",
+			env:  map[string]string{EnvAllowList: "key-a="},
+			want: Config{AllowList: map[string][]string{"key-a": {}}},
+		},
+		{"entry without processors separator", map[string]string{EnvAllowList: "key-a"}, Config{}, true},
+		{"empty key", map[string]string{EnvAllowList: "=PayFlow"}, Config{}, true},
+		{"duplicate key", map[string]string{EnvAllowList: "key-a=PayFlow;key-a=CardMax"}, Config{}, true},
+		{"invalid reject flag", map[string]string{EnvRejectUnknown: "sometimes"}, Config{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lookup := func(key string) (string, bool) {
+				v, ok := tt.env[key]
+				return v, ok
+			}
+			cfg, err := configFromLookup(lookup)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cfg)
+			for key := range tt.want.AllowList {
+				assert.True(t, cfg.Registered(key))
+			}
+			assert.False(t, cfg.Registered("key-unknown"))
+		})
+	}
+}
@@ -4,18 +4,197 @@ const (
 	// MaxRetries is the maximum number of payment attempts across all processors.
 	MaxRetries = 3
 
+	// MaxProcessorsPerPayment caps how many distinct processors a payment may be routed to
+	// (the healthiest first), independent of MaxRetries. Zero means no cap.
+	MaxProcessorsPerPayment = 0
+
+	// SameProcessorRetries is how many times a processor is retried on timeout or
+	// processor error before falling back. Zero preserves immediate fallback.
+	SameProcessorRetries = 0
+
+	// SameProcessorBackoffMillis is the wait before retrying the same processor.
+	SameProcessorBackoffMillis = 50
+
+	// AttemptTimeoutMillis bounds each processor call. A processor's own timeout
+	// applies when shorter. Zero leaves calls bounded only by the request context.
+	AttemptTimeoutMillis = 0
+
 	// HealthWindowSize is the number of recent transactions to consider for health calculation.
 	HealthWindowSize = 50
 
+	// MinHealthWindowSize and MaxHealthWindowSize bound a configured window size: a
+	// non-positive size would keep no outcomes, and a huge one unbounded memory.
+	MinHealthWindowSize = 1
+	MaxHealthWindowSize = 10000
+
 	// HealthWindowDuration is the time window for health calculation.
 	HealthWindowDurationMinutes = 10
 
+	// HealthExcludesBusinessDeclines leaves business declines (insufficient funds,
+	// fraud) out of health scores, since the customer rather than the processor
+	// caused them.
+	HealthExcludesBusinessDeclines = false
+
+	// HealthCacheTTLMillis is how long a computed ProcessorHealth may be served from
+	// cache. Recording an outcome invalidates the processor's entry immediately, so
+	// the staleness bound only affects outcomes aging out of the time window. Zero
+	// disables the cache.
+	HealthCacheTTLMillis = 0
+
+	// HealthBatchFlushMillis is the flush interval a health.BatchedRecorder falls back
+	// to when created without a positive one.
+	HealthBatchFlushMillis = 100
+
+	// StatusWebhookRetries is how many times a failed status-change webhook is
+	// retried, waiting StatusWebhookBackoffMillis before the first retry and
+	// doubling after each one. Each delivery is bounded by StatusWebhookTimeoutMillis.
+	StatusWebhookRetries       = 3
+	StatusWebhookBackoffMillis = 500
+	StatusWebhookTimeoutMillis = 2000
+
+	// FeeTierMinAmount is the payment amount from which the mock processors charge
+	// their volume tier rate, FeeTierDiscountBps below their standard rate.
+	FeeTierMinAmount   = 5000.0
+	FeeTierDiscountBps = 40
+
 	// DegradedThreshold is the health score below which a processor is considered degraded.
 	DegradedThreshold = 0.5
 
+	// PrimaryMinScore is the health score below which the chosen primary processor is
+	// logged and labelled as a degraded primary.
+	PrimaryMinScore = DegradedThreshold
+
+	// RoutingHealthWeight and RoutingLatencyWeight blend health and speed when ranking
+	// processors: health*weight minus normalized latency*weight. A zero latency
+	// weight ranks by health alone.
+	RoutingHealthWeight  = 1.0
+	RoutingLatencyWeight = 0.0
+
+	// ApprovalDriftTolerance is how far a processor's windowed health score may stray
+	// from its declared approval rate before it is flagged as drifting, once its
+	// window holds at least ApprovalDriftMinSamples outcomes.
+	ApprovalDriftTolerance  = 0.15
+	ApprovalDriftMinSamples = 10
+
 	// CircuitBreakerThreshold is the health score below which a processor is skipped entirely.
 	CircuitBreakerThreshold = 0.2
 
+	// OpenCircuitPolicy controls processors with open circuits: "skip" removes them from
+	// routing, "penalize" keeps them eligible but always ranked last.
+	OpenCircuitPolicy = "skip"
+
+	// LastResortProcessor, when set, is always tried as the final attempt, even with an
+	// open circuit. Empty disables it.
+	LastResortProcessor = ""
+
+	// AttemptLogSampleRate logs one in every N payments' attempt detail at Info and
+	// the rest at Debug. Failures and circuit transitions are always logged. 1 logs all.
+	AttemptLogSampleRate = 1
+
+	// SoftDeclineHealthierOnly only falls back after a soft decline to processors at
+	// least as healthy as the one that declined; otherwise the payment stops.
+	SoftDeclineHealthierOnly = false
+
+	// FinalResponsePolicy selects the final response of an exhausted payment:
+	// "last_attempt" uses the last response, "most_informative" prefers a decline
+	// over a transient error.
+	FinalResponsePolicy = "last_attempt"
+
+	// WarmUpRampMinutes is how long a newly added processor takes to reach its full
+	// share of primary traffic.
+	WarmUpRampMinutes = 30
+
+	// RecoveryPenalty is the share of a processor's health score withheld from
+	// routing right after its circuit closes again, decaying to nothing over
+	// RecoveryDecayMinutes. Zero minutes disables the penalty.
+	RecoveryPenalty      = 0.5
+	RecoveryDecayMinutes = 0
+
+	// LeastLoadedRouting ranks processors within LoadHealthBand of the healthiest
+	// score by their in-flight calls instead of by health alone.
+	LeastLoadedRouting = false
+	LoadHealthBand     = 0.1
+
+	// MaxConcurrentPayments caps payments processed at once, queuing up to
+	// PaymentQueueDepth more and rejecting the rest with 503. Zero disables the cap.
+	MaxConcurrentPayments = 0
+	PaymentQueueDepth     = 100
+
+	// OutboundQPS caps processor calls per second across all payments, allowing bursts
+	// of OutboundBurst. Zero disables the limit.
+	OutboundQPS   = 0
+	OutboundBurst = 10
+
+	// RejectUnknownAPIKeys declines payments whose API key has no processor allow-list
+	// entry, once any allow-list is configured; otherwise such keys may use every processor.
+	RejectUnknownAPIKeys = false
+
+	// RetryAfterSeconds is the Retry-After hint returned when retries are exhausted by transient
+	// failures and no circuit recovery estimate applies.
+	RetryAfterSeconds = 30
+
+	// PaymentCreatedResponses makes POST /payments answer approvals with 201 Created
+	// and a Location header instead of 200.
+	PaymentCreatedResponses = false
+
+	// MaxBatchConcurrency caps the concurrency a /simulate/batch request may ask for.
+	MaxBatchConcurrency = 64
+
+	// MaxBatchCount caps how many payments a /simulate/batch request may simulate.
+	MaxBatchCount = 1000
+
+	// MaxStoredBatches caps how many /simulate/batch summaries are kept for batch_id
+	// replays; the oldest is dropped to make room for a new one.
+	MaxStoredBatches = 100
+
+	// StrictJSONBodies makes the API reject request bodies with unknown fields.
+	StrictJSONBodies = false
+
+	// AcceptFormBodies and AcceptXMLBodies let POST /payments parse form-encoded and
+	// XML bodies besides JSON, by Content-Type.
+	AcceptFormBodies = true
+	AcceptXMLBodies  = false
+
+	// VerboseResponses includes each payment's attempt chain in POST /payments
+	// responses by default; ?verbose=false omits it.
+	VerboseResponses = true
+
+	// DefaultPageLimit and MaxPageLimit bound the page size of paginated listings.
+	DefaultPageLimit = 50
+	MaxPageLimit     = 500
+
 	// ServerPort is the default HTTP server port.
 	ServerPort = ":8080"
+
+	// ReadHeaderTimeoutSeconds bounds how long a client may take to send request headers.
+	ReadHeaderTimeoutSeconds = 5
+
+	// ReadTimeoutSeconds bounds reading the full request, including the body.
+	ReadTimeoutSeconds = 10
+
+	// WriteTimeoutSeconds bounds writing the response.
+	WriteTimeoutSeconds = 30
+
+	// BatchWriteTimeoutSeconds is the write deadline /simulate/batch extends its own
+	// response to. It must exceed the slowest batch simulation: MaxBatchCount
+	// sequential payments of MaxRetries attempts at the slowest mock processor's
+	// 300ms take up to 900s.
+	BatchWriteTimeoutSeconds = 960
+
+	// IdleTimeoutSeconds is how long a keep-alive connection may sit idle.
+	IdleTimeoutSeconds = 120
+
+	// LogFormat is the default log output format, "json" or "text".
+	LogFormat = "json"
+
+	// LogLevel is the default minimum log level: debug, info, warn or error.
+	LogLevel = "info"
 )
+
+// MethodCurrencies restricts payment methods to the currencies they may be used
+// with (e.g. OXXO only settles in MXN). Methods not listed accept any currency.
+var MethodCurrencies = map[string][]string{
+	"pix":  {"BRL"},
+	"oxxo": {"MXN"},
+	"pse":  {"COP"},
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportPayments_CSV(t *testing.T) {
+	mux := setupStubServer(stubProcessor{"ProcA", model.Approved})
+	body := `{"transaction_id":"tx-export","amount":42.5,"currency":"BRL","payment_method":"card","customer_id":"c1"}`
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/payments", bytes.NewBufferString(body)))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/payments/export?format=csv", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+	rows, err := csv.NewReader(w.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, []string{"transaction_id", "status", "winning_processor", "attempt_count", "amount", "currency", "total_latency_ms"}, rows[0])
+	assert.Equal(t, []string{"tx-export", "approved", "ProcA", "1", "42.50", "BRL"}, rows[1][:6])
+}
+
+func TestExportPayments_EscapesFormulas(t *testing.T) {
+	mux := setupStubServer(stubProcessor{"@ProcA", model.Approved})
+	for _, txnID := range []string{"=SUM(A1:A9)", "+cmd", "-1", "@evil", "\tcmd", "\rcmd", "tx-plain"} {
+		body := `{"transaction_id":` + strconv.Quote(txnID) + `,"amount":5,"currency":"USD","payment_method":"card","customer_id":"c1"}`
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("POST", "/payments", bytes.NewBufferString(body)))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/payments/export", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	rows, err := csv.NewReader(w.Body).ReadAll()
+	require.NoError(t, err)
+	var ids []string
+	for _, row := range rows[1:] {
+		ids = append(ids, row[0])
+		assert.Equal(t, "'@ProcA", row[2], "every string column is escaped")
+	}
+	assert.ElementsMatch(t, []string{"'=SUM(A1:A9)", "'+cmd", "'-1", "'@evil", "'\tcmd", "'\rcmd", "tx-plain"}, ids)
+}
+
+func TestExportPayments_Filters(t *testing.T) {
+	mux := setupStubServer(stubProcessor{"ProcA", model.DeclinedFraud})
+	body := `{"transaction_id":"tx-export-declined","amount":10,"currency":"USD","payment_method":"card","customer_id":"c1"}`
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/payments", bytes.NewBufferString(body)))
+
+	future := url.QueryEscape(time.Now().Add(time.Hour).Format(time.RFC3339))
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantRows   int
+	}{
+		{"matching status", "?status=declined", http.StatusOK, 2},
+		{"other status", "?status=approved", http.StatusOK, 1},
+		{"stored before from", "?from=" + future, http.StatusOK, 1},
+		{"stored before to", "?to=" + future, http.StatusOK, 2},
+		{"invalid status", "?status=lost", http.StatusBadRequest, 0},
+		{"invalid date", "?from=yesterday", http.StatusBadRequest, 0},
+		{"unsupported format", "?format=xlsx", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest("GET", "/payments/export"+tt.query, nil))
+			require.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			rows, err := csv.NewReader(w.Body).ReadAll()
+			require.NoError(t, err)
+			assert.Len(t, rows, tt.wantRows)
+		})
+	}
+}
@@ -1,57 +1,288 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
+	"math"
 	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/config"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/health"
 	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/model"
 	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/orchestrator"
 	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/processor"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/trace"
 )
 
 // Handler holds HTTP handler dependencies.
 type Handler struct {
 	orch *orchestrator.Orchestrator
+	cfg  Config
+	// maintenance, when set, rejects POST /payments with 503 while health and
+	// admin endpoints keep working.
+	maintenance atomic.Bool
+	// batches holds completed batch simulation summaries by batch_id.
+	batches batchStore
+	// sim holds the simulation overrides shared by the orchestrator's mock
+	// processors, so handlers over different orchestrators don't affect each other.
+	sim *processor.Simulation
+}
+
+// Config holds the request validation and response settings.
+type Config struct {
+	// MethodCurrencies lists the currencies each payment method may be used with.
+	// Methods not in the map accept any currency.
+	MethodCurrencies map[string][]string
+	// CreatedResponses answers approved payments with 201 Created instead of 200,
+	// and sets a Location header with the payment's retrieval path on every
+	// processed payment.
+	CreatedResponses bool
+	// StrictJSON rejects request bodies containing fields the endpoint does not
+	// define, so a typo such as "amt" fails with an unknown field error instead of
+	// silently decoding to a zero value.
+	StrictJSON bool
+	// FormBodies and XMLBodies let POST /payments accept form-encoded and XML
+	// bodies, chosen by Content-Type, besides JSON. Other types get 415.
+	FormBodies bool
+	XMLBodies  bool
+	// VerboseResponses includes the attempt chain in payment responses unless a
+	// request asks otherwise with ?verbose=false. Attempts are always stored and
+	// returned by GET /payments/{id}.
+	VerboseResponses bool
+}
+
+// DefaultConfig returns the handler settings defined in the config package.
+func DefaultConfig() Config {
+	return Config{
+		MethodCurrencies: config.MethodCurrencies,
+		CreatedResponses: config.PaymentCreatedResponses,
+		StrictJSON:       config.StrictJSONBodies,
+		FormBodies:       config.AcceptFormBodies,
+		XMLBodies:        config.AcceptXMLBodies,
+		VerboseResponses: config.VerboseResponses,
+	}
 }
 
 // New creates a new Handler.
 func New(orch *orchestrator.Orchestrator) *Handler {
-	return &Handler{orch: orch}
+	return NewWithConfig(orch, DefaultConfig())
+}
+
+// NewWithConfig creates a Handler with custom settings, attaching the
+// orchestrator's mock processors to the handler's own simulation overrides.
+func NewWithConfig(orch *orchestrator.Orchestrator, cfg Config) *Handler {
+	sim := &processor.Simulation{}
+	for _, p := range orch.Processors() {
+		if mp, ok := p.(*processor.MockProcessor); ok {
+			mp.SetSimulation(sim)
+		}
+	}
+	return &Handler{orch: orch, cfg: cfg, sim: sim}
+}
+
+// decodeBody decodes the JSON request body into v, rejecting unknown fields
+// when StrictJSON is set.
+func (h *Handler) decodeBody(r *http.Request, v any) error {
+	dec := json.NewDecoder(r.Body)
+	if h.cfg.StrictJSON {
+		dec.DisallowUnknownFields()
+	}
+	return dec.Decode(v)
 }
 
 // RegisterRoutes registers all API routes on the given mux.
 func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("POST /payments", h.ProcessPayment)
+	mux.HandleFunc("GET /payments/export", h.ExportPayments)
 	mux.HandleFunc("GET /payments/{id}", h.GetPaymentHistory)
+	mux.HandleFunc("PATCH /payments/{id}", h.ResolvePayment)
+	mux.HandleFunc("POST /payments/{id}/replay", h.ReplayPayment)
+	mux.HandleFunc("POST /payments/{id}/callback", h.PaymentCallback)
+	mux.HandleFunc("GET /customers/{id}/payments", h.GetCustomerPayments)
 	mux.HandleFunc("GET /health/processors", h.GetProcessorHealth)
+	mux.HandleFunc("GET /health/processors/{name}", h.GetSingleProcessorHealth)
+	mux.HandleFunc("GET /stats/retry-depth", h.GetRetryDepthStats)
+	mux.HandleFunc("GET /stats/methods", h.GetMethodStats)
+	mux.HandleFunc("POST /routing/simulate", h.SimulateRouting)
 	mux.HandleFunc("POST /simulate/degrade", h.SimulateDegrade)
+	mux.HandleFunc("POST /simulate/degrade-all", h.SimulateDegradeAll)
 	mux.HandleFunc("POST /simulate/batch", h.SimulateBatch)
+	mux.HandleFunc("POST /simulate/reset", h.SimulateReset)
+	mux.HandleFunc("POST /simulate/mode", h.SimulateMode)
+	mux.HandleFunc("POST /simulate/customer-outcomes", h.SimulateCustomerOutcomes)
+	mux.HandleFunc("POST /admin/maintenance", h.SetMaintenance)
 }
 
 // ProcessPayment handles POST /payments
 func (h *Handler) ProcessPayment(w http.ResponseWriter, r *http.Request) {
-	var req model.PaymentRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if h.maintenance.Load() {
+		w.Header().Set("Retry-After", strconv.Itoa(config.RetryAfterSeconds))
+		writeError(w, http.StatusServiceUnavailable, "payments are unavailable during maintenance")
+		return
+	}
+
+	verbose, ok := h.verbose(w, r)
+	if !ok {
+		return
+	}
+	req, err := h.decodePaymentRequest(r)
+	if errors.Is(err, errUnsupportedContentType) {
+		writeError(w, http.StatusUnsupportedMediaType, err.Error())
+		return
+	}
+	if err != nil {
 		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
 		return
 	}
 
-	if err := validatePaymentRequest(req); err != "" {
+	if err := validatePaymentRequest(req, h.cfg.MethodCurrencies); err != "" {
 		writeError(w, http.StatusBadRequest, err)
 		return
 	}
 
-	result := h.orch.ProcessPayment(r.Context(), req)
+	h.processAndRespond(w, r, req, verbose)
+}
+
+// processAndRespond runs a payment and writes its result with the matching status.
+func (h *Handler) processAndRespond(w http.ResponseWriter, r *http.Request, req model.PaymentRequest, verbose bool) {
+	result := h.orch.ProcessPayment(withTraceID(w, r), req)
 
-	status := http.StatusOK
-	if result.Status == model.StatusDeclined || result.Status == model.StatusExhaustedRetries {
-		status = http.StatusUnprocessableEntity
+	status := paymentHTTPStatus(result)
+	if status == http.StatusServiceUnavailable {
+		w.Header().Set("Retry-After", strconv.Itoa(h.retryAfterSeconds(req.PaymentMethod)))
+	}
+	if h.cfg.CreatedResponses {
+		w.Header().Set("Location", "/payments/"+url.PathEscape(result.TransactionID))
+		if status == http.StatusOK {
+			status = http.StatusCreated
+		}
 	}
 
+	if !verbose {
+		writeConciseResult(w, status, result)
+		return
+	}
 	writeJSON(w, status, result)
 }
 
+// verbose reads the optional verbose query parameter, defaulting to
+// VerboseResponses. An invalid value is answered with 400 and ok is false.
+func (h *Handler) verbose(w http.ResponseWriter, r *http.Request) (verbose, ok bool) {
+	v := r.URL.Query().Get("verbose")
+	if v == "" {
+		return h.cfg.VerboseResponses, true
+	}
+	verbose, err := strconv.ParseBool(v)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "verbose must be true or false")
+		return false, false
+	}
+	return verbose, true
+}
+
+// writeConciseResult writes a payment result without its attempt chain, reporting
+// only how many attempts were made as attempt_count.
+func writeConciseResult(w http.ResponseWriter, status int, result model.PaymentResult) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		writeJSON(w, status, result)
+		return
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		writeJSON(w, status, result)
+		return
+	}
+	delete(fields, "attempts")
+	fields["attempt_count"] = json.RawMessage(strconv.Itoa(len(result.Attempts)))
+	writeJSON(w, status, fields)
+}
+
+// ReplayPayment handles POST /payments/{id}/replay. It re-runs the stored request
+// against current processor health under a new transaction ID, leaving the
+// original result untouched.
+func (h *Handler) ReplayPayment(w http.ResponseWriter, r *http.Request) {
+	if h.maintenance.Load() {
+		w.Header().Set("Retry-After", strconv.Itoa(config.RetryAfterSeconds))
+		writeError(w, http.StatusServiceUnavailable, "payments are unavailable during maintenance")
+		return
+	}
+
+	verbose, ok := h.verbose(w, r)
+	if !ok {
+		return
+	}
+	txnID := r.PathValue("id")
+	original, ok := h.orch.GetPaymentHistory(txnID)
+	if !ok {
+		writeError(w, http.StatusNotFound, "transaction not found: "+txnID)
+		return
+	}
+
+	req := original.Request
+	req.TransactionID = txnID + "-replay-" + randomHex(4)
+	slog.Info("payment_replay",
+		"txn_id", req.TransactionID,
+		"original_txn_id", txnID,
+	)
+	h.processAndRespond(w, r, req, verbose)
+}
+
+// paymentHTTPStatus maps a payment result to its HTTP status code. Hard declines are
+// final (422), while retries exhausted by transient processor failures may succeed
+// later (503).
+func paymentHTTPStatus(result model.PaymentResult) int {
+	switch result.Status {
+	case model.StatusApproved:
+		return http.StatusOK
+	case model.StatusPending:
+		return http.StatusAccepted
+	case model.StatusExhaustedRetries:
+		if result.FinalResponse != nil && result.FinalResponse.Code.IsTransient() {
+			return http.StatusServiceUnavailable
+		}
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusUnprocessableEntity
+	}
+}
+
+// retryAfterSeconds estimates when a payment with the given method could succeed.
+// When every processor supporting the method has an open circuit, it is the time
+// until the soonest one is expected to recover; otherwise config.RetryAfterSeconds.
+func (h *Handler) retryAfterSeconds(paymentMethod string) int {
+	monitor := h.orch.HealthMonitor()
+	var soonest time.Duration
+	found := false
+	for _, p := range h.orch.Processors() {
+		if !processor.SupportsMethod(p, paymentMethod) {
+			continue
+		}
+		recovery := monitor.EstimatedRecovery(p.Name())
+		if recovery == 0 {
+			return config.RetryAfterSeconds
+		}
+		if !found || recovery < soonest {
+			soonest, found = recovery, true
+		}
+	}
+	if !found {
+		return config.RetryAfterSeconds
+	}
+	return max(int((soonest+time.Second-1)/time.Second), 1)
+}
+
 // GetPaymentHistory handles GET /payments/{id}
 func (h *Handler) GetPaymentHistory(w http.ResponseWriter, r *http.Request) {
 	txnID := r.PathValue("id")
@@ -69,9 +300,115 @@ func (h *Handler) GetPaymentHistory(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, result)
 }
 
-// GetProcessorHealth handles GET /health/processors
+// resolveRequest is the request body for PATCH /payments/{id}
+type resolveRequest struct {
+	Code    model.ResponseCode `json:"code"`
+	Message string             `json:"message"`
+}
+
+// ResolvePayment handles PATCH /payments/{id}, applying the final outcome of a
+// pending payment. Payments that are not pending are rejected with 409.
+func (h *Handler) ResolvePayment(w http.ResponseWriter, r *http.Request) {
+	txnID := r.PathValue("id")
+	var req resolveRequest
+	if err := h.decodeBody(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	if req.Code == "" {
+		writeError(w, http.StatusBadRequest, "code is required")
+		return
+	}
+
+	result, err := h.orch.ResolvePending(txnID, model.ProcessorResponse{Code: req.Code, Message: req.Message})
+	writeResolution(w, txnID, req.Code, result, err)
+}
+
+// callbackRequest is the request body for POST /payments/{id}/callback
+type callbackRequest struct {
+	ProcessorName string             `json:"processor_name"`
+	Code          model.ResponseCode `json:"code"`
+	Message       string             `json:"message"`
+}
+
+// PaymentCallback handles POST /payments/{id}/callback, accepting the final outcome
+// of a pending payment from an asynchronous processor. The callback is appended as
+// an attempt; payments that are not pending are rejected with 409.
+func (h *Handler) PaymentCallback(w http.ResponseWriter, r *http.Request) {
+	txnID := r.PathValue("id")
+	var req callbackRequest
+	if err := h.decodeBody(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	if req.Code == "" {
+		writeError(w, http.StatusBadRequest, "code is required")
+		return
+	}
+
+	result, err := h.orch.ApplyCallback(txnID, model.ProcessorResponse{
+		ProcessorName: req.ProcessorName,
+		Code:          req.Code,
+		Message:       req.Message,
+	})
+	writeResolution(w, txnID, req.Code, result, err)
+}
+
+// writeResolution maps the outcome of resolving a pending payment to a response.
+func writeResolution(w http.ResponseWriter, txnID string, code model.ResponseCode, result model.PaymentResult, err error) {
+	switch {
+	case errors.Is(err, orchestrator.ErrInvalidResolution):
+		writeError(w, http.StatusBadRequest, "code "+string(code)+" is not a final outcome")
+	case errors.Is(err, orchestrator.ErrProcessorMismatch):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, orchestrator.ErrPaymentNotFound):
+		writeError(w, http.StatusNotFound, "transaction not found: "+txnID)
+	case errors.Is(err, orchestrator.ErrPaymentNotPending):
+		writeError(w, http.StatusConflict, "transaction "+txnID+" is not pending")
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	default:
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+// GetCustomerPayments handles GET /customers/{id}/payments, listing a customer's
+// payments most recent first. Supports limit and offset query parameters; a
+// customer without payments gets an empty list.
+func (h *Handler) GetCustomerPayments(w http.ResponseWriter, r *http.Request) {
+	customerID := r.PathValue("id")
+	offset, limit, errMsg := parsePagination(r.URL.Query())
+	if errMsg != "" {
+		writeError(w, http.StatusBadRequest, errMsg)
+		return
+	}
+
+	payments, total := h.orch.CustomerPayments(customerID, offset, limit)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"customer_id": customerID,
+		"payments":    payments,
+		"total":       total,
+		"offset":      offset,
+		"limit":       limit,
+	})
+}
+
+// GetProcessorHealth handles GET /health/processors. An optional status query
+// parameter, e.g. ?status=degraded,circuit_open, keeps only processors in the
+// listed statuses.
 func (h *Handler) GetProcessorHealth(w http.ResponseWriter, r *http.Request) {
+	statuses, errMsg := parseHealthStatuses(r.URL.Query().Get("status"))
+	if errMsg != "" {
+		writeError(w, http.StatusBadRequest, errMsg)
+		return
+	}
+
 	healths := h.orch.HealthMonitor().GetAllHealth()
+	if len(statuses) > 0 {
+		healths = slices.DeleteFunc(healths, func(ph health.ProcessorHealth) bool {
+			return !statuses[ph.Status]
+		})
+	}
 
 	response := map[string]interface{}{
 		"processors": healths,
@@ -79,16 +416,97 @@ func (h *Handler) GetProcessorHealth(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, response)
 }
 
+// parseHealthStatuses parses a comma-separated list of health statuses. An empty
+// list means no filter.
+func parseHealthStatuses(v string) (map[health.Status]bool, string) {
+	if v == "" {
+		return nil, ""
+	}
+	statuses := make(map[health.Status]bool)
+	for _, s := range strings.Split(v, ",") {
+		switch status := health.Status(strings.TrimSpace(s)); status {
+		case health.StatusHealthy, health.StatusDegraded, health.StatusOpen:
+			statuses[status] = true
+		default:
+			return nil, "status must be a comma-separated list of: healthy, degraded, circuit_open"
+		}
+	}
+	return statuses, ""
+}
+
+// GetSingleProcessorHealth handles GET /health/processors/{name}. Registered processors
+// with no recorded outcomes report the default healthy snapshot.
+func (h *Handler) GetSingleProcessorHealth(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	for _, p := range h.orch.Processors() {
+		if p.Name() == name {
+			writeJSON(w, http.StatusOK, h.orch.HealthMonitor().GetHealth(name))
+			return
+		}
+	}
+
+	writeError(w, http.StatusNotFound, "processor not found: "+name)
+}
+
+// GetRetryDepthStats handles GET /stats/retry-depth
+func (h *Handler) GetRetryDepthStats(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.orch.RetryDepth())
+}
+
+// GetMethodStats handles GET /stats/methods
+func (h *Handler) GetMethodStats(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"methods": h.orch.MethodApprovals()})
+}
+
+// routingSimulationRequest is the request body for POST /routing/simulate
+type routingSimulationRequest struct {
+	// HealthOverrides maps processor names to hypothetical health scores.
+	HealthOverrides map[string]float64   `json:"health_overrides"`
+	Request         model.PaymentRequest `json:"request"`
+}
+
+// SimulateRouting handles POST /routing/simulate, returning the ordered candidates a
+// sample payment would route to if the given processors had the overridden health
+// scores. The real health monitor is left untouched.
+func (h *Handler) SimulateRouting(w http.ResponseWriter, r *http.Request) {
+	var req routingSimulationRequest
+	if err := h.decodeBody(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	if req.Request.PaymentMethod == "" {
+		writeError(w, http.StatusBadRequest, "request.payment_method is required")
+		return
+	}
+	known := make(map[string]bool, len(h.orch.Processors()))
+	for _, p := range h.orch.Processors() {
+		known[p.Name()] = true
+	}
+	for name, score := range req.HealthOverrides {
+		if !known[name] {
+			writeError(w, http.StatusBadRequest, "unknown processor in health_overrides: "+name)
+			return
+		}
+		if score < 0 || score > 1 {
+			writeError(w, http.StatusBadRequest, "health score for "+name+" must be between 0 and 1")
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, h.orch.SimulateRouting(withTraceID(w, r), req.Request, req.HealthOverrides))
+}
+
 // degradeRequest is the request body for POST /simulate/degrade
 type degradeRequest struct {
 	ProcessorName string `json:"processor_name"`
 	Degraded      bool   `json:"degraded"`
 }
 
-// SimulateDegrade handles POST /simulate/degrade
+// SimulateDegrade handles POST /simulate/degrade. Requesting the current state is
+// a no-op that still returns 200.
 func (h *Handler) SimulateDegrade(w http.ResponseWriter, r *http.Request) {
 	var req degradeRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := h.decodeBody(r, &req); err != nil {
 		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
 		return
 	}
@@ -101,15 +519,24 @@ func (h *Handler) SimulateDegrade(w http.ResponseWriter, r *http.Request) {
 	for _, p := range h.orch.Processors() {
 		if p.Name() == req.ProcessorName {
 			if mp, ok := p.(*processor.MockProcessor); ok {
-				mp.SetDegraded(req.Degraded)
-				slog.Info("processor_degradation_toggled",
-					"processor", req.ProcessorName,
-					"degraded", req.Degraded,
-				)
+				previous := mp.SwapDegraded(req.Degraded)
+				changed := previous != req.Degraded
+				message := "degradation mode unchanged"
+				if changed {
+					message = "degradation mode updated"
+					slog.Info("processor_degradation_toggled",
+						"processor", req.ProcessorName,
+						"previous", previous,
+						"degraded", req.Degraded,
+					)
+				}
 				writeJSON(w, http.StatusOK, map[string]interface{}{
 					"processor": req.ProcessorName,
 					"degraded":  req.Degraded,
-					"message":   "degradation mode updated",
+					"previous":  previous,
+					"current":   req.Degraded,
+					"changed":   changed,
+					"message":   message,
 				})
 				return
 			}
@@ -119,23 +546,156 @@ func (h *Handler) SimulateDegrade(w http.ResponseWriter, r *http.Request) {
 	writeError(w, http.StatusNotFound, "processor not found: "+req.ProcessorName)
 }
 
+// degradeAllRequest is the request body for POST /simulate/degrade-all
+type degradeAllRequest struct {
+	Degraded bool `json:"degraded"`
+}
+
+// degradeState reports one processor's degradation toggle.
+type degradeState struct {
+	Processor string `json:"processor"`
+	Previous  bool   `json:"previous"`
+	Current   bool   `json:"current"`
+	Changed   bool   `json:"changed"`
+}
+
+// SimulateDegradeAll handles POST /simulate/degrade-all, degrading or recovering
+// every mock processor at once for chaos drills. Processors that are not mocks
+// are left out of the response.
+func (h *Handler) SimulateDegradeAll(w http.ResponseWriter, r *http.Request) {
+	var req degradeAllRequest
+	if err := h.decodeBody(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	states := make([]degradeState, 0, len(h.orch.Processors()))
+	changed := 0
+	for _, p := range h.orch.Processors() {
+		mp, ok := p.(*processor.MockProcessor)
+		if !ok {
+			continue
+		}
+		previous := mp.SwapDegraded(req.Degraded)
+		state := degradeState{
+			Processor: p.Name(),
+			Previous:  previous,
+			Current:   req.Degraded,
+			Changed:   previous != req.Degraded,
+		}
+		if state.Changed {
+			changed++
+		}
+		states = append(states, state)
+	}
+
+	slog.Info("processor_degradation_toggled_all",
+		"degraded", req.Degraded,
+		"changed", changed,
+		"processors", len(states),
+	)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"degraded":   req.Degraded,
+		"changed":    changed,
+		"processors": states,
+	})
+}
+
 // batchRequest is the request body for POST /simulate/batch
 type batchRequest struct {
 	Count    int    `json:"count"`
 	Method   string `json:"method"`
 	Currency string `json:"currency"`
+	// Currencies, when set, spreads the batch round-robin across these currencies
+	// instead of using Currency.
+	Currencies []string `json:"currencies,omitempty"`
+	// Concurrency is how many payments are processed at once (default 1, sequential).
+	Concurrency int `json:"concurrency,omitempty"`
+	// BatchID, when set, makes the batch idempotent: re-running it returns the
+	// stored summary instead of simulating again.
+	BatchID string `json:"batch_id,omitempty"`
+}
+
+// sameParams reports whether two normalized batch requests would simulate the same
+// batch. Concurrency is ignored since it only changes how fast the batch runs.
+func (b batchRequest) sameParams(other batchRequest) bool {
+	return b.Count == other.Count &&
+		b.Method == other.Method &&
+		b.Currency == other.Currency &&
+		slices.Equal(b.Currencies, other.Currencies)
+}
+
+// storedBatch is a completed batch simulation with the parameters it ran with.
+type storedBatch struct {
+	params  batchRequest
+	summary map[string]interface{}
+}
+
+// batchStore keeps completed batch summaries by batch_id, evicting the oldest once
+// it holds config.MaxStoredBatches. Its zero value is ready to use.
+type batchStore struct {
+	mu      sync.Mutex
+	batches map[string]storedBatch
+	order   []string // batch IDs, oldest first
+}
+
+func (s *batchStore) get(batchID string) (storedBatch, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	batch, ok := s.batches[batchID]
+	return batch, ok
+}
+
+// save stores batch under batchID unless a batch already finished under that ID
+// concurrently, in which case the earlier one is kept and returned.
+func (s *batchStore) save(batchID string, batch storedBatch) storedBatch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.batches[batchID]; ok {
+		return existing
+	}
+	if s.batches == nil {
+		s.batches = make(map[string]storedBatch)
+	}
+	if len(s.order) >= config.MaxStoredBatches {
+		delete(s.batches, s.order[0])
+		s.order = s.order[1:]
+	}
+	s.batches[batchID] = batch
+	s.order = append(s.order, batchID)
+	return batch
+}
+
+// reset forgets every stored batch and returns how many were dropped.
+func (s *batchStore) reset() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(s.batches)
+	s.batches = nil
+	s.order = nil
+	return n
+}
+
+// writeStoredBatch answers a re-run of a stored batch: the stored summary when the
+// parameters match, 409 Conflict otherwise.
+func writeStoredBatch(w http.ResponseWriter, req batchRequest, stored storedBatch) {
+	if !stored.params.sameParams(req) {
+		writeError(w, http.StatusConflict, "batch "+req.BatchID+" already ran with different parameters")
+		return
+	}
+	writeJSON(w, http.StatusOK, stored.summary)
 }
 
 // SimulateBatch handles POST /simulate/batch
 func (h *Handler) SimulateBatch(w http.ResponseWriter, r *http.Request) {
 	var req batchRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := h.decodeBody(r, &req); err != nil {
 		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
 		return
 	}
 
-	if req.Count <= 0 || req.Count > 1000 {
-		writeError(w, http.StatusBadRequest, "count must be between 1 and 1000")
+	if req.Count <= 0 || req.Count > config.MaxBatchCount {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("count must be between 1 and %d", config.MaxBatchCount))
 		return
 	}
 	if req.Method == "" {
@@ -144,31 +704,221 @@ func (h *Handler) SimulateBatch(w http.ResponseWriter, r *http.Request) {
 	if req.Currency == "" {
 		req.Currency = "USD"
 	}
+	if req.Concurrency < 0 || req.Concurrency > config.MaxBatchConcurrency {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("concurrency must be between 0 and %d (0 or omitted runs sequentially)", config.MaxBatchConcurrency))
+		return
+	}
+	if req.Concurrency == 0 {
+		req.Concurrency = 1
+	}
+	currencies := req.Currencies
+	if len(currencies) == 0 {
+		currencies = []string{req.Currency}
+	}
+	if req.BatchID != "" {
+		if stored, ok := h.batches.get(req.BatchID); ok {
+			writeStoredBatch(w, req, stored)
+			return
+		}
+	}
 
-	results := make([]model.PaymentResult, 0, req.Count)
-	for i := 0; i < req.Count; i++ {
+	// A large batch outlasts the server's write timeout; extend it for this
+	// response only. Writers without deadline support keep no deadline to extend.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(time.Duration(config.BatchWriteTimeoutSeconds) * time.Second))
+
+	ctx := withTraceID(w, r)
+	results := make([]model.PaymentResult, req.Count)
+	process := func(i int) {
 		payReq := model.PaymentRequest{
 			TransactionID: generateTxnID(i),
 			Amount:        randomAmount(),
-			Currency:      req.Currency,
+			Currency:      currencies[i%len(currencies)],
 			PaymentMethod: req.Method,
 			CustomerID:    generateCustomerID(i),
 		}
-		result := h.orch.ProcessPayment(r.Context(), payReq)
-		results = append(results, result)
+		results[i] = h.orch.ProcessPayment(ctx, payReq)
+	}
+
+	if req.Concurrency == 1 {
+		for i := 0; i < req.Count; i++ {
+			process(i)
+		}
+	} else {
+		// Each worker writes only its own indexes, so results keeps batch order
+		indexes := make(chan int)
+		var wg sync.WaitGroup
+		for n := 0; n < min(req.Concurrency, req.Count); n++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range indexes {
+					process(i)
+				}
+			}()
+		}
+		for i := 0; i < req.Count; i++ {
+			indexes <- i
+		}
+		close(indexes)
+		wg.Wait()
 	}
 
 	// Summarize
 	summary := summarizeBatch(results)
+	if req.BatchID != "" {
+		summary["batch_id"] = req.BatchID
+		writeStoredBatch(w, req, h.batches.save(req.BatchID, storedBatch{params: req, summary: summary}))
+		return
+	}
 	writeJSON(w, http.StatusOK, summary)
 }
 
-func validatePaymentRequest(req model.PaymentRequest) string {
+// SimulateReset handles POST /simulate/reset
+func (h *Handler) SimulateReset(w http.ResponseWriter, r *http.Request) {
+	summary := h.orch.Reset()
+	h.sim.Reset()
+	batchesCleared := h.batches.reset()
+	h.maintenance.Store(false)
+
+	processorsReset := make([]string, 0, len(h.orch.Processors()))
+	for _, p := range h.orch.Processors() {
+		if mp, ok := p.(*processor.MockProcessor); ok {
+			mp.Reset()
+			processorsReset = append(processorsReset, p.Name())
+		}
+	}
+
+	slog.Info("simulation_reset",
+		"payments_cleared", summary.PaymentsCleared,
+		"health_windows_cleared", summary.HealthWindowsCleared,
+		"processors_reset", len(processorsReset),
+		"batches_cleared", batchesCleared,
+	)
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"payments_cleared":       summary.PaymentsCleared,
+		"health_windows_cleared": summary.HealthWindowsCleared,
+		"processors_reset":       processorsReset,
+		"batches_cleared":        batchesCleared,
+		"message":                "all simulation state cleared",
+	})
+}
+
+// modeRequest is the request body for POST /simulate/mode
+type modeRequest struct {
+	Mode string `json:"mode"`
+}
+
+// SimulateMode handles POST /simulate/mode
+func (h *Handler) SimulateMode(w http.ResponseWriter, r *http.Request) {
+	var req modeRequest
+	if err := h.decodeBody(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	mode, err := processor.ParseSimulationMode(req.Mode)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "mode must be one of: normal, approve_all, decline_all")
+		return
+	}
+
+	previous := h.sim.Mode()
+	h.sim.SetMode(mode)
+	slog.Info("simulation_mode_changed",
+		"previous", previous.String(),
+		"mode", mode.String(),
+	)
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"previous_mode": previous.String(),
+		"mode":          mode.String(),
+		"message":       "simulation mode updated",
+	})
+}
+
+// customerOutcomesRequest is the request body for POST /simulate/customer-outcomes
+type customerOutcomesRequest struct {
+	Outcomes map[string]model.ResponseCode `json:"outcomes"`
+}
+
+// SimulateCustomerOutcomes handles POST /simulate/customer-outcomes, replacing the
+// response codes the handler's mock processors force for specific customer IDs.
+func (h *Handler) SimulateCustomerOutcomes(w http.ResponseWriter, r *http.Request) {
+	var req customerOutcomesRequest
+	if err := h.decodeBody(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	for customerID, code := range req.Outcomes {
+		if customerID == "" {
+			writeError(w, http.StatusBadRequest, "customer IDs must not be empty")
+			return
+		}
+		if !slices.Contains(model.ResponseCodes, code) {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown response code %q for customer %s", code, customerID))
+			return
+		}
+	}
+
+	h.sim.SetCustomerOutcomes(req.Outcomes)
+	slog.Info("customer_outcomes_changed", "customers", len(req.Outcomes))
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"customers": len(req.Outcomes),
+		"message":   "customer outcomes updated",
+	})
+}
+
+// maintenanceRequest is the request body for POST /admin/maintenance
+type maintenanceRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+// SetMaintenance handles POST /admin/maintenance
+func (h *Handler) SetMaintenance(w http.ResponseWriter, r *http.Request) {
+	var req maintenanceRequest
+	if err := h.decodeBody(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	if req.Enabled == nil {
+		writeError(w, http.StatusBadRequest, "enabled is required")
+		return
+	}
+
+	previous := h.maintenance.Swap(*req.Enabled)
+	if previous != *req.Enabled {
+		slog.Warn("maintenance_mode_toggled",
+			"previous", previous,
+			"enabled", *req.Enabled,
+		)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"previous": previous,
+		"enabled":  *req.Enabled,
+		"message":  "maintenance mode updated",
+	})
+}
+
+// withTraceID attaches the request's trace ID (from the X-Trace-ID header, or a newly
+// generated one) to its context and echoes it on the response.
+func withTraceID(w http.ResponseWriter, r *http.Request) context.Context {
+	traceID := r.Header.Get(trace.HeaderName)
+	if traceID == "" {
+		traceID = randomHex(8)
+	}
+	w.Header().Set(trace.HeaderName, traceID)
+	return trace.WithTraceID(r.Context(), traceID)
+}
+
+func validatePaymentRequest(req model.PaymentRequest, methodCurrencies map[string][]string) string {
 	if req.TransactionID == "" {
 		return "transaction_id is required"
 	}
-	if req.Amount <= 0 {
-		return "amount must be greater than 0"
+	if req.Amount < 0 || (req.Amount == 0 && !req.AllowZeroAmount) {
+		return "amount must be greater than 0 (or 0 with allow_zero_amount)"
 	}
 	if req.Currency == "" {
 		return "currency is required"
@@ -177,6 +927,15 @@ func validatePaymentRequest(req model.PaymentRequest) string {
 	if !validMethods[req.PaymentMethod] {
 		return "payment_method must be one of: card, pix, oxxo, pse"
 	}
+	for _, method := range append([]string{req.PaymentMethod}, req.FanOutMethods...) {
+		if !validMethods[method] {
+			return "fan_out_methods must be among: card, pix, oxxo, pse"
+		}
+		if allowed, ok := methodCurrencies[method]; ok && !slices.Contains(allowed, req.Currency) {
+			return fmt.Sprintf("payment_method %s is not allowed with currency %s (allowed: %s)",
+				method, req.Currency, strings.Join(allowed, ", "))
+		}
+	}
 	if req.CustomerID == "" {
 		return "customer_id is required"
 	}
@@ -211,8 +970,18 @@ func summarizeBatch(results []model.PaymentResult) map[string]interface{} {
 	declined := 0
 	exhausted := 0
 	totalAttempts := 0
+	totalFees := 0.0
+	latencies := make([]time.Duration, 0, len(results))
+	byCurrency := make(map[string]*currencyTotals)
 
 	for _, r := range results {
+		totals, ok := byCurrency[r.Request.Currency]
+		if !ok {
+			totals = &currencyTotals{}
+			byCurrency[r.Request.Currency] = totals
+		}
+		totals.add(r)
+
 		switch r.Status {
 		case model.StatusApproved:
 			approved++
@@ -222,7 +991,10 @@ func summarizeBatch(results []model.PaymentResult) map[string]interface{} {
 			exhausted++
 		}
 		totalAttempts += len(r.Attempts)
+		totalFees += r.FeeCharged
+		latencies = append(latencies, r.TotalLatency)
 	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
 
 	return map[string]interface{}{
 		"total":             len(results),
@@ -231,5 +1003,53 @@ func summarizeBatch(results []model.PaymentResult) map[string]interface{} {
 		"exhausted_retries": exhausted,
 		"approval_rate":     float64(approved) / float64(len(results)),
 		"avg_attempts":      float64(totalAttempts) / float64(len(results)),
+		"total_fees":        roundCents(totalFees),
+		"by_currency":       byCurrency,
+		"latency_min_ms":    millis(latencies[0]),
+		"latency_p50_ms":    millis(percentile(latencies, 50)),
+		"latency_p95_ms":    millis(percentile(latencies, 95)),
+		"latency_p99_ms":    millis(percentile(latencies, 99)),
+		"latency_max_ms":    millis(latencies[len(latencies)-1]),
+	}
+}
+
+// currencyTotals sums a batch's amounts per currency, since amounts in different
+// currencies can't be added together.
+type currencyTotals struct {
+	Payments       int     `json:"payments"`
+	Approved       int     `json:"approved"`
+	Amount         float64 `json:"amount"`
+	ApprovedAmount float64 `json:"approved_amount"`
+	Fees           float64 `json:"fees"`
+	NetAmount      float64 `json:"net_amount"`
+}
+
+func (t *currencyTotals) add(r model.PaymentResult) {
+	t.Payments++
+	t.Amount = roundCents(t.Amount + r.Request.Amount)
+	if r.Status != model.StatusApproved {
+		return
 	}
+	t.Approved++
+	t.ApprovedAmount = roundCents(t.ApprovedAmount + r.Request.Amount)
+	t.Fees = roundCents(t.Fees + r.FeeCharged)
+	t.NetAmount = roundCents(t.NetAmount + r.NetAmount)
+}
+
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// percentile returns the nearest-rank percentile of an ascending, non-empty slice.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// millis converts a duration to fractional milliseconds.
+func millis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
 }
@@ -7,9 +7,12 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/config"
 	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/health"
 	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/model"
 	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/orchestrator"
@@ -34,6 +37,11 @@ func setupTestServer() (*http.ServeMux, *orchestrator.Orchestrator) {
 	return mux, orch
 }
 
+// simulationOf returns the simulation overrides shared by orch's mock processors.
+func simulationOf(orch *orchestrator.Orchestrator) *processor.Simulation {
+	return orch.Processors()[0].(*processor.MockProcessor).Simulation()
+}
+
 func TestProcessPayment_Success(t *testing.T) {
 	mux, _ := setupTestServer()
 
@@ -44,7 +52,7 @@ func TestProcessPayment_Success(t *testing.T) {
 
 	mux.ServeHTTP(w, req)
 
-	assert.Contains(t, []int{http.StatusOK, http.StatusUnprocessableEntity}, w.Code)
+	assert.Contains(t, []int{http.StatusOK, http.StatusUnprocessableEntity, http.StatusServiceUnavailable}, w.Code)
 
 	var result model.PaymentResult
 	err := json.Unmarshal(w.Body.Bytes(), &result)
@@ -76,6 +84,11 @@ func TestProcessPayment_ValidationErrors(t *testing.T) {
 			`{"transaction_id":"tx","amount":-10,"currency":"USD","payment_method":"card","customer_id":"c1"}`,
 			"amount must be greater than 0",
 		},
+		{
+			"negative amount with allow_zero_amount",
+			`{"transaction_id":"tx","amount":-10,"currency":"USD","payment_method":"card","customer_id":"c1","allow_zero_amount":true}`,
+			"amount must be greater than 0",
+		},
 		{
 			"missing currency",
 			`{"transaction_id":"tx","amount":100,"payment_method":"card","customer_id":"c1"}`,
@@ -148,6 +161,65 @@ func TestGetPaymentHistory_NotFound(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, w.Code)
 }
 
+func TestProcessPayment_ConciseResponseKeepsHistory(t *testing.T) {
+	tests := []struct {
+		name         string
+		query        string
+		defaultOff   bool
+		wantAttempts bool
+	}{
+		{"verbose by default", "", false, true},
+		{"verbose=false omits attempts", "?verbose=false", false, false},
+		{"config default off", "", true, false},
+		{"verbose=true overrides config", "?verbose=true", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orch := orchestrator.New([]processor.Processor{
+				stubProcessor{"ProcA", model.ProcessorError},
+				stubProcessor{"ProcB", model.Approved},
+			}, health.NewMonitorWithConfig(50, 10*time.Minute))
+			cfg := DefaultConfig()
+			cfg.VerboseResponses = !tt.defaultOff
+			mux := http.NewServeMux()
+			NewWithConfig(orch, cfg).RegisterRoutes(mux)
+
+			body := `{"transaction_id":"tx-concise","amount":100,"currency":"USD","payment_method":"card","customer_id":"cust-1"}`
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest("POST", "/payments"+tt.query, bytes.NewBufferString(body)))
+			require.Equal(t, http.StatusOK, w.Code)
+
+			var resp map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, "approved", resp["status"])
+			assert.Equal(t, "ProcB", resp["winning_processor"])
+			if tt.wantAttempts {
+				assert.Len(t, resp["attempts"], 2)
+				assert.NotContains(t, resp, "attempt_count")
+			} else {
+				assert.NotContains(t, resp, "attempts")
+				assert.Equal(t, float64(2), resp["attempt_count"])
+			}
+
+			w = httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest("GET", "/payments/tx-concise", nil))
+			require.Equal(t, http.StatusOK, w.Code)
+			var stored model.PaymentResult
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stored))
+			assert.Len(t, stored.Attempts, 2, "history always keeps the attempt chain")
+		})
+	}
+}
+
+func TestProcessPayment_InvalidVerbose(t *testing.T) {
+	mux := setupStubServer(stubProcessor{"ProcA", model.Approved})
+	body := `{"transaction_id":"tx-verbose","amount":100,"currency":"USD","payment_method":"card","customer_id":"cust-1"}`
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/payments?verbose=maybe", bytes.NewBufferString(body)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "verbose must be true or false")
+}
+
 func TestGetProcessorHealth(t *testing.T) {
 	mux, _ := setupTestServer()
 
@@ -163,6 +235,98 @@ func TestGetProcessorHealth(t *testing.T) {
 	assert.Contains(t, resp, "processors")
 }
 
+func TestGetProcessorHealth_StatusFilter(t *testing.T) {
+	mux, orch := setupTestServer()
+	mon := orch.HealthMonitor()
+	for i := 0; i < 10; i++ {
+		// PayFlow scores 0.3 (degraded), CardMax 0 (open), PixPay 1 (healthy)
+		code := model.ProcessorError
+		if i < 3 {
+			code = model.Approved
+		}
+		mon.RecordOutcome("PayFlow", code)
+		mon.RecordOutcome("CardMax", model.ProcessorError)
+		mon.RecordOutcome("PixPay", model.Approved)
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		want       []string
+	}{
+		{"single status", "?status=degraded", http.StatusOK, []string{"PayFlow"}},
+		{"multiple statuses", "?status=degraded,circuit_open", http.StatusOK, []string{"CardMax", "PayFlow"}},
+		{"healthy only", "?status=healthy", http.StatusOK, []string{"PixPay"}},
+		{"no filter", "", http.StatusOK, []string{"CardMax", "PayFlow", "PixPay"}},
+		{"invalid status", "?status=degraded,broken", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest("GET", "/health/processors"+tt.query, nil))
+			require.Equal(t, tt.wantStatus, w.Code, w.Body.String())
+			if tt.wantStatus != http.StatusOK {
+				assert.Contains(t, w.Body.String(), "status must be")
+				return
+			}
+
+			var resp struct {
+				Processors []health.ProcessorHealth `json:"processors"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			var names []string
+			for _, ph := range resp.Processors {
+				names = append(names, ph.ProcessorName)
+			}
+			assert.ElementsMatch(t, tt.want, names)
+		})
+	}
+}
+
+func TestGetSingleProcessorHealth(t *testing.T) {
+	mux, orch := setupTestServer()
+	for i := 0; i < 4; i++ {
+		code := model.Approved
+		if i == 0 {
+			code = model.ProcessorError
+		}
+		orch.HealthMonitor().RecordOutcome("PayFlow", code)
+	}
+
+	tests := []struct {
+		name       string
+		processor  string
+		wantStatus int
+		wantScore  float64
+		wantTotal  int
+		wantHealth health.Status
+	}{
+		{"tracked processor", "PayFlow", http.StatusOK, 0.75, 4, health.StatusHealthy},
+		{"untracked registered processor", "PixPay", http.StatusOK, 1.0, 0, health.StatusHealthy},
+		{"unknown processor", "NoSuchPay", http.StatusNotFound, 0, 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/health/processors/"+tt.processor, nil)
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var h health.ProcessorHealth
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &h))
+			assert.Equal(t, tt.processor, h.ProcessorName)
+			assert.InDelta(t, tt.wantScore, h.HealthScore, 0.001)
+			assert.Equal(t, tt.wantTotal, h.TotalRecent)
+			assert.Equal(t, tt.wantHealth, h.Status)
+		})
+	}
+}
+
 func TestSimulateDegrade(t *testing.T) {
 	mux, _ := setupTestServer()
 
@@ -179,6 +343,88 @@ func TestSimulateDegrade(t *testing.T) {
 	assert.Equal(t, true, resp["degraded"])
 }
 
+func TestSimulateDegradeAll(t *testing.T) {
+	mux, orch := setupTestServer()
+	degradeAll := func(degraded bool) map[string]interface{} {
+		body := fmt.Sprintf(`{"degraded":%t}`, degraded)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("POST", "/simulate/degrade-all", bytes.NewBufferString(body)))
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+
+	resp := degradeAll(true)
+	assert.Equal(t, true, resp["degraded"])
+	assert.Equal(t, float64(4), resp["changed"])
+	states := resp["processors"].([]interface{})
+	require.Len(t, states, 4)
+	for _, s := range states {
+		state := s.(map[string]interface{})
+		assert.Equal(t, false, state["previous"], state["processor"])
+		assert.Equal(t, true, state["current"], state["processor"])
+		assert.Equal(t, true, state["changed"], state["processor"])
+	}
+	for _, p := range orch.Processors() {
+		assert.True(t, p.(*processor.MockProcessor).IsDegraded(), p.Name())
+	}
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/simulate/batch", bytes.NewBufferString(`{"count":100,"concurrency":20}`)))
+	require.Equal(t, http.StatusOK, w.Code)
+	var summary map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &summary))
+	// Degraded processors fail 80% of attempts; even with fallbacks about half of
+	// the payments fail, against nearly none when healthy
+	assert.Less(t, summary["approval_rate"].(float64), 0.75)
+
+	// Degrading again is a no-op, recovering flips every processor back
+	assert.Equal(t, float64(0), degradeAll(true)["changed"])
+	assert.Equal(t, float64(4), degradeAll(false)["changed"])
+	for _, p := range orch.Processors() {
+		assert.False(t, p.(*processor.MockProcessor).IsDegraded(), p.Name())
+	}
+}
+
+func TestSimulateDegrade_PreviousStateAndNoOp(t *testing.T) {
+	mux, orch := setupTestServer()
+
+	steps := []struct {
+		name         string
+		degraded     bool
+		wantPrevious bool
+		wantChanged  bool
+	}{
+		{"enable degradation", true, false, true},
+		{"enable again is a no-op", true, true, false},
+		{"disable degradation", false, true, true},
+		{"disable again is a no-op", false, false, false},
+	}
+
+	for _, st := range steps {
+		t.Run(st.name, func(t *testing.T) {
+			body := fmt.Sprintf(`{"processor_name":"CardMax","degraded":%t}`, st.degraded)
+			req := httptest.NewRequest("POST", "/simulate/degrade", bytes.NewBufferString(body))
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			var resp map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, st.wantPrevious, resp["previous"])
+			assert.Equal(t, st.degraded, resp["current"])
+			assert.Equal(t, st.wantChanged, resp["changed"])
+
+			for _, p := range orch.Processors() {
+				if p.Name() == "CardMax" {
+					assert.Equal(t, st.degraded, p.(*processor.MockProcessor).IsDegraded())
+				}
+			}
+		})
+	}
+}
+
 func TestSimulateDegrade_NotFound(t *testing.T) {
 	mux, _ := setupTestServer()
 
@@ -206,6 +452,8 @@ func TestSimulateBatch(t *testing.T) {
 	assert.Equal(t, float64(10), resp["total"])
 	assert.Contains(t, resp, "approved")
 	assert.Contains(t, resp, "approval_rate")
+	assert.Contains(t, resp, "total_fees")
+	assert.Contains(t, resp, "latency_p99_ms")
 }
 
 func TestSimulateBatch_InvalidCount(t *testing.T) {
@@ -243,15 +491,16 @@ func TestResponseContentType(t *testing.T) {
 func TestProcessPayment_AllPaymentMethods(t *testing.T) {
 	mux, _ := setupTestServer()
 
-	methods := []string{"card", "pix", "oxxo", "pse"}
-	for _, method := range methods {
+	// Each method uses a currency the default method/currency matrix allows
+	methods := map[string]string{"card": "USD", "pix": "BRL", "oxxo": "MXN", "pse": "COP"}
+	for method, currency := range methods {
 		t.Run(method, func(t *testing.T) {
-			body := fmt.Sprintf(`{"transaction_id":"tx-%s","amount":50,"currency":"USD","payment_method":"%s","customer_id":"c1"}`, method, method)
+			body := fmt.Sprintf(`{"transaction_id":"tx-%s","amount":50,"currency":"%s","payment_method":"%s","customer_id":"c1"}`, method, currency, method)
 			req := httptest.NewRequest("POST", "/payments", bytes.NewBufferString(body))
 			w := httptest.NewRecorder()
 			mux.ServeHTTP(w, req)
 
-			assert.Contains(t, []int{http.StatusOK, http.StatusUnprocessableEntity}, w.Code)
+			assert.Contains(t, []int{http.StatusOK, http.StatusUnprocessableEntity, http.StatusServiceUnavailable}, w.Code)
 		})
 	}
 }
@@ -267,7 +516,7 @@ func TestProcessPayment_AllCurrencies(t *testing.T) {
 			w := httptest.NewRecorder()
 			mux.ServeHTTP(w, req)
 
-			assert.Contains(t, []int{http.StatusOK, http.StatusUnprocessableEntity}, w.Code)
+			assert.Contains(t, []int{http.StatusOK, http.StatusUnprocessableEntity, http.StatusServiceUnavailable}, w.Code)
 		})
 	}
 }
@@ -296,3 +545,1144 @@ func TestSimulateBatch_DefaultMethodAndCurrency(t *testing.T) {
 	json.Unmarshal(w.Body.Bytes(), &resp)
 	assert.Equal(t, float64(5), resp["total"])
 }
+
+func TestSimulateReset_ClearsAllState(t *testing.T) {
+	mux, orch := setupTestServer()
+
+	// Create state in every subsystem: stored payments, health windows, degraded processors
+	for i := 0; i < 3; i++ {
+		orch.ProcessPayment(context.Background(), model.PaymentRequest{
+			TransactionID: fmt.Sprintf("tx-reset-%d", i),
+			Amount:        25.0,
+			Currency:      "USD",
+			PaymentMethod: "card",
+			CustomerID:    "cust-reset",
+		})
+	}
+	for _, p := range orch.Processors() {
+		p.(*processor.MockProcessor).SetDegraded(true)
+	}
+	require.NotEmpty(t, orch.HealthMonitor().GetAllHealth())
+
+	req := httptest.NewRequest("POST", "/simulate/reset", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, float64(3), resp["payments_cleared"])
+	assert.Greater(t, resp["health_windows_cleared"], float64(0))
+	assert.Len(t, resp["processors_reset"], 4)
+
+	for i := 0; i < 3; i++ {
+		_, ok := orch.GetPaymentHistory(fmt.Sprintf("tx-reset-%d", i))
+		assert.False(t, ok, "stored payments should be cleared")
+	}
+	assert.Empty(t, orch.HealthMonitor().GetAllHealth(), "health windows should be cleared")
+	for _, p := range orch.Processors() {
+		assert.False(t, p.(*processor.MockProcessor).IsDegraded(), "%s should not be degraded", p.Name())
+	}
+}
+
+// stubProcessor always returns the same response code without simulated latency.
+type stubProcessor struct {
+	name string
+	code model.ResponseCode
+}
+
+func (p stubProcessor) Name() string               { return p.name }
+func (p stubProcessor) SupportedMethods() []string { return []string{"card", "pix", "oxxo", "pse"} }
+func (p stubProcessor) Process(ctx context.Context, req model.PaymentRequest) model.ProcessorResponse {
+	return model.ProcessorResponse{
+		ProcessorName: p.name,
+		Code:          p.code,
+		Message:       "stub response",
+		Timestamp:     time.Now(),
+	}
+}
+
+func setupStubServer(procs ...processor.Processor) *http.ServeMux {
+	orch := orchestrator.New(procs, health.NewMonitorWithConfig(50, 10*time.Minute))
+	mux := http.NewServeMux()
+	New(orch).RegisterRoutes(mux)
+	return mux
+}
+
+func TestProcessPayment_TerminalStatusCodes(t *testing.T) {
+	tests := []struct {
+		name       string
+		procs      []processor.Processor
+		wantStatus int
+		retryAfter bool
+	}{
+		{
+			name:       "approved",
+			procs:      []processor.Processor{stubProcessor{"ProcA", model.Approved}},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "fraud decline",
+			procs:      []processor.Processor{stubProcessor{"ProcA", model.DeclinedFraud}},
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "all timeouts exhaust retries",
+			procs: []processor.Processor{
+				stubProcessor{"ProcA", model.Timeout},
+				stubProcessor{"ProcB", model.Timeout},
+				stubProcessor{"ProcC", model.Timeout},
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			retryAfter: true,
+		},
+		{
+			name: "soft declines exhaust retries",
+			procs: []processor.Processor{
+				stubProcessor{"ProcA", model.SoftDecline},
+				stubProcessor{"ProcB", model.SoftDecline},
+			},
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := setupStubServer(tt.procs...)
+			body := `{"transaction_id":"tx-status","amount":100,"currency":"USD","payment_method":"card","customer_id":"c1"}`
+			req := httptest.NewRequest("POST", "/payments", bytes.NewBufferString(body))
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.retryAfter {
+				assert.NotEmpty(t, w.Header().Get("Retry-After"))
+			} else {
+				assert.Empty(t, w.Header().Get("Retry-After"))
+			}
+		})
+	}
+}
+
+func TestProcessPayment_BeyondQueueDepth(t *testing.T) {
+	proc, err := processor.NewScriptedProcessor(processor.ScriptedConfig{
+		ProcessorName: "ProcA",
+		Methods:       []string{"card"},
+		Codes:         []model.ResponseCode{model.Approved},
+		Latency:       200 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	cfg := orchestrator.DefaultConfig()
+	cfg.MaxConcurrentPayments = 2
+	cfg.PaymentQueueDepth = 2
+	orch := orchestrator.NewWithConfig([]processor.Processor{proc}, health.NewMonitorWithConfig(50, 10*time.Minute), cfg)
+	mux := http.NewServeMux()
+	New(orch).RegisterRoutes(mux)
+
+	// 2 payments run, 2 wait in the queue and the other 4 are turned away
+	const payments = 8
+	codes := make([]int, payments)
+	retryAfter := make([]string, payments)
+	var wg sync.WaitGroup
+	for i := 0; i < payments; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			body := fmt.Sprintf(`{"transaction_id":"tx-queue-%d","amount":100,"currency":"USD","payment_method":"card","customer_id":"c1"}`, i)
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest("POST", "/payments", bytes.NewBufferString(body)))
+			codes[i] = w.Code
+			retryAfter[i] = w.Header().Get("Retry-After")
+		}(i)
+	}
+	wg.Wait()
+
+	var ok, unavailable int
+	for i, code := range codes {
+		switch code {
+		case http.StatusOK:
+			ok++
+		case http.StatusServiceUnavailable:
+			unavailable++
+			assert.NotEmpty(t, retryAfter[i])
+		}
+	}
+	assert.Equal(t, 4, ok)
+	assert.Equal(t, 4, unavailable)
+	assert.Equal(t, 4, proc.CallCount())
+}
+
+func TestProcessPayment_RetryAfterFromCircuitRecovery(t *testing.T) {
+	// ProcA has one fresh approval and four failures of the given age. The payment's
+	// own error opens its circuit at 1/6, which closes again as the oldest failure
+	// ages out of the 10 minute window.
+	retryAfter := func(t *testing.T, failureAge time.Duration) int {
+		monitor := health.NewMonitorWithConfig(50, 10*time.Minute)
+		orch := orchestrator.New([]processor.Processor{stubProcessor{"ProcA", model.ProcessorError}}, monitor)
+		mux := http.NewServeMux()
+		New(orch).RegisterRoutes(mux)
+
+		now := time.Now()
+		records := []health.OutcomeRecord{{ProcessorName: "ProcA", Code: model.Approved, Timestamp: now}}
+		for i := 0; i < 4; i++ {
+			records = append(records, health.OutcomeRecord{ProcessorName: "ProcA", Code: model.ProcessorError, Timestamp: now.Add(-failureAge)})
+		}
+		monitor.RecordOutcomes(records)
+
+		body := `{"transaction_id":"tx-retry-after","amount":100,"currency":"USD","payment_method":"card","customer_id":"c1"}`
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("POST", "/payments", bytes.NewBufferString(body)))
+		require.Equal(t, http.StatusServiceUnavailable, w.Code)
+		require.True(t, monitor.IsCircuitOpen("ProcA"))
+
+		seconds, err := strconv.Atoi(w.Header().Get("Retry-After"))
+		require.NoError(t, err)
+		return seconds
+	}
+
+	aging := retryAfter(t, 10*time.Minute-5*time.Second)
+	fresh := retryAfter(t, time.Minute)
+
+	assert.LessOrEqual(t, aging, 5)
+	assert.Greater(t, aging, 0)
+	assert.InDelta(t, 9*60, fresh, 2)
+}
+
+func TestSummarizeBatch_LatencyPercentiles(t *testing.T) {
+	// Latencies 1ms..100ms, shuffled so the summary must sort them
+	results := make([]model.PaymentResult, 0, 100)
+	for i := 100; i >= 1; i-- {
+		results = append(results, model.PaymentResult{
+			Status:       model.StatusApproved,
+			TotalLatency: time.Duration(i) * time.Millisecond,
+		})
+	}
+
+	summary := summarizeBatch(results)
+
+	assert.Equal(t, 1.0, summary["latency_min_ms"])
+	assert.Equal(t, 50.0, summary["latency_p50_ms"])
+	assert.Equal(t, 95.0, summary["latency_p95_ms"])
+	assert.Equal(t, 99.0, summary["latency_p99_ms"])
+	assert.Equal(t, 100.0, summary["latency_max_ms"])
+}
+
+func TestSummarizeBatch_TotalsByCurrency(t *testing.T) {
+	result := func(currency string, amount, fee float64, status model.PaymentStatus) model.PaymentResult {
+		r := model.PaymentResult{
+			Status:  status,
+			Request: model.PaymentRequest{Amount: amount, Currency: currency},
+		}
+		if status == model.StatusApproved {
+			r.FeeCharged = fee
+			r.NetAmount = amount - fee
+		}
+		return r
+	}
+	results := []model.PaymentResult{
+		result("USD", 100.00, 3.90, model.StatusApproved),
+		result("USD", 50.50, 0, model.StatusDeclined),
+		result("BRL", 200.00, 2.40, model.StatusApproved),
+		result("BRL", 10.10, 0.12, model.StatusApproved),
+	}
+
+	byCurrency := summarizeBatch(results)["by_currency"].(map[string]*currencyTotals)
+
+	assert.Equal(t, currencyTotals{
+		Payments: 2, Approved: 1, Amount: 150.50, ApprovedAmount: 100.00, Fees: 3.90, NetAmount: 96.10,
+	}, *byCurrency["USD"])
+	assert.Equal(t, currencyTotals{
+		Payments: 2, Approved: 2, Amount: 210.10, ApprovedAmount: 210.10, Fees: 2.52, NetAmount: 207.58,
+	}, *byCurrency["BRL"])
+}
+
+func TestSimulateBatch_MixedCurrencies(t *testing.T) {
+	mux := setupStubServer(stubProcessor{"ProcA", model.Approved})
+	body := `{"count":5,"method":"card","currencies":["USD","BRL"]}`
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/simulate/batch", bytes.NewBufferString(body)))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Total      int                       `json:"total"`
+		ByCurrency map[string]currencyTotals `json:"by_currency"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 5, resp.Total)
+	require.Len(t, resp.ByCurrency, 2)
+	assert.Equal(t, 3, resp.ByCurrency["USD"].Payments, "payments alternate across currencies")
+	assert.Equal(t, 2, resp.ByCurrency["BRL"].Payments)
+	for currency, totals := range resp.ByCurrency {
+		assert.Equal(t, totals.Payments, totals.Approved, currency)
+		assert.InDelta(t, totals.Amount, totals.ApprovedAmount, 0.001, currency)
+		assert.GreaterOrEqual(t, totals.Amount, 5.0*float64(totals.Payments), currency)
+	}
+}
+
+func TestSimulateBatch_Concurrency(t *testing.T) {
+	runBatch := func(t *testing.T, concurrency int) (map[string]interface{}, time.Duration) {
+		proc, err := processor.NewScriptedProcessor(processor.ScriptedConfig{
+			ProcessorName: "ProcSlow",
+			Methods:       []string{"card"},
+			Codes:         []model.ResponseCode{model.Approved, model.Approved, model.DeclinedFraud},
+			Latency:       20 * time.Millisecond,
+		})
+		require.NoError(t, err)
+		mux := setupStubServer(proc)
+
+		body := fmt.Sprintf(`{"count":30,"method":"card","currencies":["USD","BRL"],"concurrency":%d}`, concurrency)
+		start := time.Now()
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("POST", "/simulate/batch", bytes.NewBufferString(body)))
+		elapsed := time.Since(start)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp, elapsed
+	}
+
+	sequential, seqElapsed := runBatch(t, 1)
+	concurrent, concElapsed := runBatch(t, 10)
+
+	assert.Less(t, concElapsed, seqElapsed/2, "a concurrent batch should finish well ahead of a sequential one")
+	for _, key := range []string{"total", "approved", "declined", "exhausted_retries", "approval_rate", "avg_attempts"} {
+		assert.Equal(t, sequential[key], concurrent[key], key)
+	}
+	assert.Equal(t, float64(20), concurrent["approved"])
+	seqByCurrency := sequential["by_currency"].(map[string]interface{})
+	concByCurrency := concurrent["by_currency"].(map[string]interface{})
+	for _, currency := range []string{"USD", "BRL"} {
+		assert.Equal(t, seqByCurrency[currency].(map[string]interface{})["payments"],
+			concByCurrency[currency].(map[string]interface{})["payments"], currency)
+	}
+}
+
+func TestSimulateBatch_BatchID(t *testing.T) {
+	proc, err := processor.NewScriptedProcessor(processor.ScriptedConfig{
+		ProcessorName: "ProcA",
+		Methods:       []string{"card"},
+		Codes:         []model.ResponseCode{model.Approved, model.DeclinedFraud},
+	})
+	require.NoError(t, err)
+	mux := setupStubServer(proc)
+	runBatch := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("POST", "/simulate/batch", bytes.NewBufferString(body)))
+		return w
+	}
+
+	first := runBatch(`{"count":5,"method":"card","batch_id":"demo-1"}`)
+	require.Equal(t, http.StatusOK, first.Code)
+	var firstResp map[string]interface{}
+	require.NoError(t, json.Unmarshal(first.Body.Bytes(), &firstResp))
+	assert.Equal(t, "demo-1", firstResp["batch_id"])
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantReplay bool
+	}{
+		{"same parameters replay the summary", `{"count":5,"method":"card","batch_id":"demo-1"}`, http.StatusOK, true},
+		{"defaults count as the same parameters", `{"count":5,"currency":"USD","batch_id":"demo-1"}`, http.StatusOK, true},
+		{"concurrency is not a parameter", `{"count":5,"concurrency":4,"batch_id":"demo-1"}`, http.StatusOK, true},
+		{"different count conflicts", `{"count":6,"method":"card","batch_id":"demo-1"}`, http.StatusConflict, false},
+		{"different currency conflicts", `{"count":5,"currency":"BRL","batch_id":"demo-1"}`, http.StatusConflict, false},
+		{"another batch_id simulates again", `{"count":5,"method":"card","batch_id":"demo-2"}`, http.StatusOK, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := runBatch(tt.body)
+			require.Equal(t, tt.wantStatus, w.Code, w.Body.String())
+			if tt.wantReplay {
+				assert.JSONEq(t, first.Body.String(), w.Body.String())
+			}
+			if tt.wantStatus == http.StatusConflict {
+				assert.Contains(t, w.Body.String(), "batch demo-1 already ran with different parameters")
+			}
+		})
+	}
+
+	// Only the two simulated batches processed payments
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/stats/methods", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var stats struct {
+		Methods map[string]struct {
+			Total int `json:"total"`
+		} `json:"methods"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+	assert.Equal(t, 10, stats.Methods["card"].Total)
+}
+
+func TestSimulateReset_ClearsStoredBatches(t *testing.T) {
+	mux := setupStubServer(stubProcessor{"ProcA", model.Approved})
+	post := func(path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("POST", path, bytes.NewBufferString(body)))
+		return w
+	}
+
+	require.Equal(t, http.StatusOK, post("/simulate/batch", `{"count":3,"method":"card","batch_id":"demo-reset"}`).Code)
+	reset := post("/simulate/reset", "")
+	require.Equal(t, http.StatusOK, reset.Code)
+	var resetResp map[string]interface{}
+	require.NoError(t, json.Unmarshal(reset.Body.Bytes(), &resetResp))
+	assert.Equal(t, float64(1), resetResp["batches_cleared"])
+
+	// The batch_id is free again: different parameters simulate instead of conflicting
+	w := post("/simulate/batch", `{"count":4,"method":"card","batch_id":"demo-reset"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, float64(4), resp["total"])
+}
+
+func TestBatchStore_EvictsOldest(t *testing.T) {
+	var store batchStore
+	for i := 0; i <= config.MaxStoredBatches; i++ {
+		store.save(fmt.Sprintf("batch-%d", i), storedBatch{params: batchRequest{Count: i}})
+	}
+
+	_, ok := store.get("batch-0")
+	assert.False(t, ok, "the oldest batch is evicted once the store is full")
+	newest, ok := store.get(fmt.Sprintf("batch-%d", config.MaxStoredBatches))
+	require.True(t, ok)
+	assert.Equal(t, config.MaxStoredBatches, newest.params.Count)
+	assert.Equal(t, config.MaxStoredBatches, store.reset())
+}
+
+// slowProcessor is a stubProcessor that takes delay to answer.
+type slowProcessor struct {
+	stubProcessor
+	delay time.Duration
+}
+
+func (p slowProcessor) Process(ctx context.Context, req model.PaymentRequest) model.ProcessorResponse {
+	time.Sleep(p.delay)
+	return p.stubProcessor.Process(ctx, req)
+}
+
+func TestSimulateBatch_OutlastsServerWriteTimeout(t *testing.T) {
+	mux := setupStubServer(slowProcessor{stubProcessor{"ProcA", model.Approved}, 30 * time.Millisecond})
+	srv := httptest.NewUnstartedServer(mux)
+	srv.Config.WriteTimeout = 50 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/simulate/batch", "application/json", bytes.NewBufferString(`{"count":4}`))
+	require.NoError(t, err, "the batch extends its own write deadline")
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestSimulateBatch_InvalidConcurrency(t *testing.T) {
+	mux := setupStubServer(stubProcessor{"ProcA", model.Approved})
+	for _, body := range []string{`{"count":5,"concurrency":-1}`, `{"count":5,"concurrency":1000}`} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("POST", "/simulate/batch", bytes.NewBufferString(body)))
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+		assert.Contains(t, w.Body.String(), fmt.Sprintf("concurrency must be between 0 and %d", config.MaxBatchConcurrency))
+	}
+
+	// Zero is accepted and runs the batch sequentially
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/simulate/batch", bytes.NewBufferString(`{"count":5,"concurrency":0}`)))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestPercentile_SmallSamples(t *testing.T) {
+	tests := []struct {
+		name     string
+		sorted   []time.Duration
+		p        float64
+		expected time.Duration
+	}{
+		{"single sample", []time.Duration{7 * time.Millisecond}, 99, 7 * time.Millisecond},
+		{"p50 of two", []time.Duration{10 * time.Millisecond, 20 * time.Millisecond}, 50, 10 * time.Millisecond},
+		{"p95 of two", []time.Duration{10 * time.Millisecond, 20 * time.Millisecond}, 95, 20 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, percentile(tt.sorted, tt.p))
+		})
+	}
+}
+
+func TestProcessPayment_TraceIDHeader(t *testing.T) {
+	mux := setupStubServer(stubProcessor{"ProcA", model.Approved})
+	body := `{"transaction_id":"tx-trace","amount":100,"currency":"USD","payment_method":"card","customer_id":"c1"}`
+
+	t.Run("propagates client trace ID", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/payments", bytes.NewBufferString(body))
+		req.Header.Set("X-Trace-ID", "client-trace-1")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+
+		assert.Equal(t, "client-trace-1", w.Header().Get("X-Trace-ID"))
+		var result model.PaymentResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		require.Len(t, result.Attempts, 1)
+		assert.Equal(t, "client-trace-1", result.Attempts[0].TraceID)
+	})
+
+	t.Run("generates trace ID when missing", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/payments", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+
+		traceID := w.Header().Get("X-Trace-ID")
+		assert.NotEmpty(t, traceID)
+		var result model.PaymentResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		require.Len(t, result.Attempts, 1)
+		assert.Equal(t, traceID, result.Attempts[0].TraceID)
+	})
+}
+
+func setSimulationMode(t *testing.T, mux *http.ServeMux, mode string) {
+	t.Helper()
+	req := httptest.NewRequest("POST", "/simulate/mode", bytes.NewBufferString(`{"mode":"`+mode+`"}`))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestSimulateMode(t *testing.T) {
+	tests := []struct {
+		mode         string
+		wantStatus   model.PaymentStatus
+		wantAttempts int
+	}{
+		{"approve_all", model.StatusApproved, 1},
+		{"decline_all", model.StatusExhaustedRetries, config.MaxRetries},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			mux, orch := setupTestServer()
+			setSimulationMode(t, mux, tt.mode)
+
+			for i := 0; i < 5; i++ {
+				result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+					TransactionID: fmt.Sprintf("tx-mode-%s-%d", tt.mode, i),
+					Amount:        50.0,
+					Currency:      "USD",
+					PaymentMethod: "card",
+					CustomerID:    "cust-mode",
+				})
+				if i == 0 {
+					assert.Equal(t, tt.wantStatus, result.Status)
+					assert.Len(t, result.Attempts, tt.wantAttempts)
+					continue
+				}
+				// Later payments may see fewer candidates, or none, once declines open circuits
+				assert.Equal(t, tt.wantStatus == model.StatusApproved, result.Status == model.StatusApproved, result.Status)
+			}
+		})
+	}
+}
+
+func TestSimulateMode_InvalidMode(t *testing.T) {
+	mux, orch := setupTestServer()
+
+	req := httptest.NewRequest("POST", "/simulate/mode", bytes.NewBufferString(`{"mode":"chaos"}`))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, processor.ModeNormal, simulationOf(orch).Mode())
+}
+
+func TestSimulateMode_ScopedToHandler(t *testing.T) {
+	mux, orch := setupTestServer()
+	_, otherOrch := setupTestServer()
+	setSimulationMode(t, mux, "decline_all")
+
+	for _, p := range orch.Processors() {
+		assert.Equal(t, processor.ModeDeclineAll, p.(*processor.MockProcessor).Simulation().Mode(), p.Name())
+	}
+	assert.Equal(t, processor.ModeNormal, simulationOf(otherOrch).Mode(), "another handler's processors are unaffected")
+}
+
+func TestSimulateCustomerOutcomes(t *testing.T) {
+	mux, orch := setupTestServer()
+	pay := func(txnID string) model.PaymentResult {
+		return orch.ProcessPayment(context.Background(), model.PaymentRequest{
+			TransactionID: txnID,
+			Amount:        50.0,
+			Currency:      "USD",
+			PaymentMethod: "card",
+			CustomerID:    "cust-fraud",
+		})
+	}
+
+	req := httptest.NewRequest("POST", "/simulate/customer-outcomes",
+		bytes.NewBufferString(`{"outcomes": {"cust-fraud": "declined_fraud"}}`))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"customers": 1, "message": "customer outcomes updated"}`, w.Body.String())
+
+	result := pay("tx-customer-outcome")
+	assert.Equal(t, model.StatusDeclined, result.Status)
+	require.NotNil(t, result.FinalResponse)
+	assert.Equal(t, model.DeclinedFraud, result.FinalResponse.Code)
+	_, otherOrch := setupTestServer()
+	_, forced := simulationOf(otherOrch).CustomerOutcome("cust-fraud")
+	assert.False(t, forced, "another handler's processors are unaffected")
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/simulate/reset", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	_, forced = simulationOf(orch).CustomerOutcome("cust-fraud")
+	assert.False(t, forced, "reset clears customer outcomes")
+}
+
+func TestSimulateCustomerOutcomes_Invalid(t *testing.T) {
+	mux, orch := setupTestServer()
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"unknown code", `{"outcomes": {"cust-1": "maybe"}}`},
+		{"empty customer ID", `{"outcomes": {"": "approved"}}`},
+		{"malformed body", `{"outcomes": ["cust-1"]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest("POST", "/simulate/customer-outcomes", bytes.NewBufferString(tt.body)))
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			_, forced := simulationOf(orch).CustomerOutcome("cust-1")
+			assert.False(t, forced)
+		})
+	}
+}
+
+func TestSimulateReset_ClearsSimulationMode(t *testing.T) {
+	mux, orch := setupTestServer()
+	setSimulationMode(t, mux, "approve_all")
+
+	req := httptest.NewRequest("POST", "/simulate/reset", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, processor.ModeNormal, simulationOf(orch).Mode())
+}
+
+func TestProcessPayment_WinningProcessorSerialization(t *testing.T) {
+	tests := []struct {
+		name       string
+		code       model.ResponseCode
+		wantWinner bool
+	}{
+		{"approved", model.Approved, true},
+		{"hard decline", model.DeclinedFraud, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := setupStubServer(stubProcessor{"ProcA", tt.code})
+			body := `{"transaction_id":"tx-winner","amount":100,"currency":"USD","payment_method":"card","customer_id":"c1"}`
+			req := httptest.NewRequest("POST", "/payments", bytes.NewBufferString(body))
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+
+			var resp map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			if tt.wantWinner {
+				assert.Equal(t, "ProcA", resp["winning_processor"])
+			} else {
+				assert.NotContains(t, resp, "winning_processor")
+			}
+		})
+	}
+}
+
+func TestGetRetryDepthStats(t *testing.T) {
+	mux := setupStubServer(stubProcessor{"ProcA", model.SoftDecline}, stubProcessor{"ProcB", model.Approved})
+	body := `{"transaction_id":"tx-depth","amount":100,"currency":"USD","payment_method":"card","customer_id":"c1"}`
+	req := httptest.NewRequest("POST", "/payments", bytes.NewBufferString(body))
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+
+	req = httptest.NewRequest("GET", "/stats/retry-depth", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp map[string]map[string]float64
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, map[string]float64{"2": 1}, resp["succeeded"])
+	assert.Empty(t, resp["failed"])
+}
+
+func TestMaintenanceMode(t *testing.T) {
+	mux := setupStubServer(stubProcessor{"ProcA", model.Approved})
+	pay := func(txnID string) *httptest.ResponseRecorder {
+		body := `{"transaction_id":"` + txnID + `","amount":100,"currency":"USD","payment_method":"card","customer_id":"c1"}`
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("POST", "/payments", bytes.NewBufferString(body)))
+		return w
+	}
+	setMaintenance := func(enabled bool) {
+		w := httptest.NewRecorder()
+		body := fmt.Sprintf(`{"enabled":%t}`, enabled)
+		mux.ServeHTTP(w, httptest.NewRequest("POST", "/admin/maintenance", bytes.NewBufferString(body)))
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+
+	setMaintenance(true)
+	w := pay("tx-maint-1")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, strconv.Itoa(config.RetryAfterSeconds), w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), "maintenance")
+
+	// Health and admin endpoints keep working
+	hw := httptest.NewRecorder()
+	mux.ServeHTTP(hw, httptest.NewRequest("GET", "/health/processors", nil))
+	assert.Equal(t, http.StatusOK, hw.Code)
+
+	setMaintenance(false)
+	assert.Equal(t, http.StatusOK, pay("tx-maint-2").Code)
+
+	// A simulation reset also leaves maintenance mode
+	setMaintenance(true)
+	rw := httptest.NewRecorder()
+	mux.ServeHTTP(rw, httptest.NewRequest("POST", "/simulate/reset", nil))
+	require.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, http.StatusOK, pay("tx-maint-3").Code)
+}
+
+func TestSetMaintenance_RequiresEnabled(t *testing.T) {
+	mux, _ := setupTestServer()
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/admin/maintenance", bytes.NewBufferString(`{}`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestProcessPayment_ZeroAmountVerification(t *testing.T) {
+	mux := setupStubServer(stubProcessor{"ProcA", model.Approved})
+	body := `{"transaction_id":"tx-verify","amount":0,"currency":"USD","payment_method":"card","customer_id":"c1","allow_zero_amount":true}`
+	req := httptest.NewRequest("POST", "/payments", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var result model.PaymentResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, model.StatusApproved, result.Status)
+	assert.Zero(t, result.FeeCharged)
+}
+
+func TestReplayPayment(t *testing.T) {
+	// ProcA soft-declines once, opening its circuit, so the replay routes differently
+	procA, err := processor.NewScriptedProcessor(processor.ScriptedConfig{
+		ProcessorName: "ProcA",
+		Methods:       []string{"card"},
+		Codes:         []model.ResponseCode{model.SoftDecline},
+	})
+	require.NoError(t, err)
+	mux := setupStubServer(procA, stubProcessor{"ProcB", model.Approved})
+
+	body := `{"transaction_id":"tx-replay","amount":42.5,"currency":"BRL","payment_method":"card","customer_id":"c1"}`
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/payments", bytes.NewBufferString(body)))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/payments/tx-replay/replay", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var replay model.PaymentResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &replay))
+	assert.Equal(t, model.StatusApproved, replay.Status)
+	assert.Contains(t, replay.TransactionID, "tx-replay-replay-")
+	require.Len(t, replay.Attempts, 1, "replay routes on current health")
+	assert.Equal(t, "ProcB", replay.Attempts[0].ProcessorName)
+	assert.Equal(t, 42.5, replay.Request.Amount)
+	assert.Equal(t, "BRL", replay.Request.Currency)
+	assert.Equal(t, 1, procA.CallCount())
+
+	// The original record is unchanged
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/payments/tx-replay", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var original model.PaymentResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &original))
+	assert.Equal(t, "tx-replay", original.TransactionID)
+	require.Len(t, original.Attempts, 2)
+	assert.Equal(t, "ProcA", original.Attempts[0].ProcessorName)
+
+	// The replay is stored under its own ID
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/payments/"+replay.TransactionID, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestReplayPayment_NotFound(t *testing.T) {
+	mux := setupStubServer(stubProcessor{"ProcA", model.Approved})
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/payments/tx-missing/replay", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestProcessPayment_MethodCurrencyMatrix(t *testing.T) {
+	orch := orchestrator.New([]processor.Processor{stubProcessor{"ProcA", model.Approved}},
+		health.NewMonitorWithConfig(50, 10*time.Minute))
+	mux := http.NewServeMux()
+	NewWithConfig(orch, Config{
+		MethodCurrencies: map[string][]string{"oxxo": {"MXN"}, "pix": {"BRL", "USD"}},
+	}).RegisterRoutes(mux)
+
+	tests := []struct {
+		name       string
+		method     string
+		currency   string
+		wantStatus int
+		wantError  string
+	}{
+		{"allowed pair", "oxxo", "MXN", http.StatusOK, ""},
+		{"second allowed currency", "pix", "USD", http.StatusOK, ""},
+		{"disallowed pair", "oxxo", "BRL", http.StatusBadRequest, "payment_method oxxo is not allowed with currency BRL (allowed: MXN)"},
+		{"unconfigured method allows any currency", "card", "COP", http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := fmt.Sprintf(`{"transaction_id":"tx-matrix-%s-%s","amount":50,"currency":"%s","payment_method":"%s","customer_id":"c1"}`,
+				tt.method, tt.currency, tt.currency, tt.method)
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest("POST", "/payments", bytes.NewBufferString(body)))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantError != "" {
+				var resp map[string]string
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, tt.wantError, resp["error"])
+			}
+		})
+	}
+}
+
+func TestProcessPayment_DefaultMethodCurrencyMatrix(t *testing.T) {
+	mux := setupStubServer(stubProcessor{"ProcA", model.Approved})
+	body := `{"transaction_id":"tx-oxxo-usd","amount":50,"currency":"USD","payment_method":"oxxo","customer_id":"c1"}`
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/payments", bytes.NewBufferString(body)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "not allowed with currency USD")
+}
+
+func TestProcessPayment_FanOutMethodValidation(t *testing.T) {
+	mux := setupStubServer(stubProcessor{"ProcA", model.Approved})
+	tests := []struct {
+		name       string
+		fanOut     string
+		wantStatus int
+	}{
+		{"allowed method", `["pix"]`, http.StatusOK},
+		{"unknown method", `["cash"]`, http.StatusBadRequest},
+		{"method not allowed with currency", `["oxxo"]`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"transaction_id":"tx-fanout","amount":50,"currency":"BRL","payment_method":"card","customer_id":"c1","fan_out_methods":` + tt.fanOut + `}`
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest("POST", "/payments", bytes.NewBufferString(body)))
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestGetMethodStats(t *testing.T) {
+	mux := setupStubServer(stubProcessor{"ProcA", model.Approved})
+	payments := []struct{ method, currency string }{
+		{"card", "USD"}, {"card", "USD"}, {"pix", "BRL"},
+	}
+	for i, p := range payments {
+		body := fmt.Sprintf(`{"transaction_id":"tx-ms-%d","amount":10,"currency":"%s","payment_method":"%s","customer_id":"c1"}`,
+			i, p.currency, p.method)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("POST", "/payments", bytes.NewBufferString(body)))
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/stats/methods", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Methods map[string]orchestrator.MethodApproval `json:"methods"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, orchestrator.MethodApproval{Total: 2, Approved: 2, ApprovalRate: 1}, resp.Methods["card"])
+	assert.Equal(t, orchestrator.MethodApproval{Total: 1, Approved: 1, ApprovalRate: 1}, resp.Methods["pix"])
+}
+
+func TestResolvePayment(t *testing.T) {
+	procA, err := processor.NewScriptedProcessor(processor.ScriptedConfig{
+		ProcessorName: "ProcA",
+		Methods:       []string{"card"},
+		Codes:         []model.ResponseCode{model.Pending},
+	})
+	require.NoError(t, err)
+	mux := setupStubServer(procA)
+
+	body := `{"transaction_id":"tx-pending","amount":42.5,"currency":"USD","payment_method":"card","customer_id":"c1"}`
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/payments", bytes.NewBufferString(body)))
+	require.Equal(t, http.StatusAccepted, w.Code)
+	var pending model.PaymentResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &pending))
+	assert.Equal(t, model.StatusPending, pending.Status)
+
+	tests := []struct {
+		name     string
+		id       string
+		body     string
+		wantCode int
+	}{
+		{"invalid body", "tx-pending", `{`, http.StatusBadRequest},
+		{"missing code", "tx-pending", `{}`, http.StatusBadRequest},
+		{"non-final code", "tx-pending", `{"code":"timeout"}`, http.StatusBadRequest},
+		{"unknown transaction", "tx-missing", `{"code":"approved"}`, http.StatusNotFound},
+		{"resolves pending", "tx-pending", `{"code":"approved","message":"settled"}`, http.StatusOK},
+		{"already resolved", "tx-pending", `{"code":"soft_decline"}`, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest("PATCH", "/payments/"+tt.id, bytes.NewBufferString(tt.body)))
+			assert.Equal(t, tt.wantCode, w.Code, w.Body.String())
+		})
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/payments/tx-pending", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var resolved model.PaymentResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resolved))
+	assert.Equal(t, model.StatusApproved, resolved.Status)
+	assert.Equal(t, "ProcA", resolved.WinningProcessor)
+	assert.Equal(t, "settled", resolved.FinalResponse.Message)
+}
+
+func TestPaymentCallback(t *testing.T) {
+	procA, err := processor.NewScriptedProcessor(processor.ScriptedConfig{
+		ProcessorName: "ProcA",
+		Methods:       []string{"card"},
+		Codes:         []model.ResponseCode{model.Pending},
+	})
+	require.NoError(t, err)
+	mux := setupStubServer(procA)
+
+	body := `{"transaction_id":"tx-async","amount":42.5,"currency":"USD","payment_method":"card","customer_id":"c1"}`
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/payments", bytes.NewBufferString(body)))
+	require.Equal(t, http.StatusAccepted, w.Code)
+
+	tests := []struct {
+		name     string
+		id       string
+		body     string
+		wantCode int
+	}{
+		{"missing code", "tx-async", `{"processor_name":"ProcA"}`, http.StatusBadRequest},
+		{"wrong processor", "tx-async", `{"processor_name":"ProcB","code":"approved"}`, http.StatusBadRequest},
+		{"unknown transaction", "tx-missing", `{"processor_name":"ProcA","code":"approved"}`, http.StatusNotFound},
+		{"valid transition", "tx-async", `{"processor_name":"ProcA","code":"declined_insufficient_funds"}`, http.StatusOK},
+		{"not pending", "tx-async", `{"processor_name":"ProcA","code":"approved"}`, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest("POST", "/payments/"+tt.id+"/callback", bytes.NewBufferString(tt.body)))
+			assert.Equal(t, tt.wantCode, w.Code, w.Body.String())
+		})
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/payments/tx-async", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var result model.PaymentResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, model.StatusDeclined, result.Status)
+	require.Len(t, result.Attempts, 2)
+	assert.Equal(t, model.DeclinedInsufficientFunds, result.Attempts[1].Response.Code)
+}
+
+func TestProcessPayment_CreatedResponses(t *testing.T) {
+	tests := []struct {
+		name         string
+		created      bool
+		code         model.ResponseCode
+		wantStatus   int
+		wantLocation string
+	}{
+		{"approved with created responses", true, model.Approved, http.StatusCreated, "/payments/tx%2Fcreated"},
+		{"declined with created responses", true, model.DeclinedFraud, http.StatusUnprocessableEntity, "/payments/tx%2Fcreated"},
+		{"approved by default", false, model.Approved, http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orch := orchestrator.New([]processor.Processor{stubProcessor{"ProcA", tt.code}},
+				health.NewMonitorWithConfig(50, 10*time.Minute))
+			cfg := DefaultConfig()
+			cfg.CreatedResponses = tt.created
+			mux := http.NewServeMux()
+			NewWithConfig(orch, cfg).RegisterRoutes(mux)
+
+			body := `{"transaction_id":"tx/created","amount":10,"currency":"USD","payment_method":"card","customer_id":"c1"}`
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest("POST", "/payments", bytes.NewBufferString(body)))
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, tt.wantLocation, w.Header().Get("Location"))
+
+			if tt.wantLocation != "" {
+				w = httptest.NewRecorder()
+				mux.ServeHTTP(w, httptest.NewRequest("GET", tt.wantLocation, nil))
+				assert.Equal(t, http.StatusOK, w.Code, "Location resolves to the stored payment")
+			}
+		})
+	}
+}
+
+func TestProcessPayment_StrictJSON(t *testing.T) {
+	tests := []struct {
+		name       string
+		strict     bool
+		body       string
+		wantStatus int
+		wantError  string
+	}{
+		{"strict rejects unknown field", true, `{"transaction_id":"tx-1","amt":10,"amount":10,"currency":"USD","payment_method":"card","customer_id":"c1"}`, http.StatusBadRequest, `unknown field "amt"`},
+		{"strict accepts known fields", true, `{"transaction_id":"tx-2","amount":10,"currency":"USD","payment_method":"card","customer_id":"c1"}`, http.StatusOK, ""},
+		{"lenient ignores unknown field", false, `{"transaction_id":"tx-3","amt":10,"amount":10,"currency":"USD","payment_method":"card","customer_id":"c1"}`, http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orch := orchestrator.New([]processor.Processor{stubProcessor{"ProcA", model.Approved}},
+				health.NewMonitorWithConfig(50, 10*time.Minute))
+			cfg := DefaultConfig()
+			cfg.StrictJSON = tt.strict
+			mux := http.NewServeMux()
+			NewWithConfig(orch, cfg).RegisterRoutes(mux)
+
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest("POST", "/payments", bytes.NewBufferString(tt.body)))
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantError != "" {
+				var resp map[string]string
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Contains(t, resp["error"], tt.wantError)
+			}
+		})
+	}
+}
+
+func TestGetCustomerPayments(t *testing.T) {
+	mux := setupStubServer(stubProcessor{"ProcA", model.Approved})
+	for i, customer := range []string{"cust-a", "cust-b", "cust-a", "cust-a"} {
+		body := fmt.Sprintf(`{"transaction_id":"tx-%d","amount":10,"currency":"USD","payment_method":"card","customer_id":%q}`, i, customer)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("POST", "/payments", bytes.NewBufferString(body)))
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+
+	type page struct {
+		CustomerID string                `json:"customer_id"`
+		Payments   []model.PaymentResult `json:"payments"`
+		Total      int                   `json:"total"`
+		Offset     int                   `json:"offset"`
+		Limit      int                   `json:"limit"`
+	}
+	tests := []struct {
+		name      string
+		path      string
+		wantCode  int
+		wantIDs   []string
+		wantTotal int
+		wantLimit int
+	}{
+		{"all payments", "/customers/cust-a/payments", http.StatusOK, []string{"tx-3", "tx-2", "tx-0"}, 3, config.DefaultPageLimit},
+		{"paginated", "/customers/cust-a/payments?limit=1&offset=1", http.StatusOK, []string{"tx-2"}, 3, 1},
+		{"limit capped", "/customers/cust-b/payments?limit=100000", http.StatusOK, []string{"tx-1"}, 1, config.MaxPageLimit},
+		{"no payments", "/customers/cust-none/payments", http.StatusOK, []string{}, 0, config.DefaultPageLimit},
+		{"invalid limit", "/customers/cust-a/payments?limit=0", http.StatusBadRequest, nil, 0, 0},
+		{"invalid offset", "/customers/cust-a/payments?offset=-1", http.StatusBadRequest, nil, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+			require.Equal(t, tt.wantCode, w.Code)
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+			var got page
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+			require.NotNil(t, got.Payments, "an empty list, not null")
+			ids := make([]string, 0, len(got.Payments))
+			for _, p := range got.Payments {
+				ids = append(ids, p.TransactionID)
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+			assert.Equal(t, tt.wantTotal, got.Total)
+			assert.Equal(t, tt.wantLimit, got.Limit)
+		})
+	}
+}
+
+func TestSimulateRouting(t *testing.T) {
+	mux := setupStubServer(
+		stubProcessor{"ProcA", model.Approved},
+		stubProcessor{"ProcB", model.Approved},
+		stubProcessor{"ProcC", model.Approved},
+	)
+	simulate := func(overrides string) (int, orchestrator.RoutingSimulation) {
+		body := `{"health_overrides":` + overrides + `,"request":{"amount":10,"currency":"USD","payment_method":"card"}}`
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("POST", "/routing/simulate", bytes.NewBufferString(body)))
+		var sim orchestrator.RoutingSimulation
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &sim))
+		}
+		return w.Code, sim
+	}
+	names := func(sim orchestrator.RoutingSimulation) []string {
+		var out []string
+		for _, c := range sim.Candidates {
+			out = append(out, c.Processor)
+		}
+		return out
+	}
+
+	// Really every processor is healthy, so registration order decides
+	code, real := simulate(`{}`)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"ProcA", "ProcB", "ProcC"}, names(real))
+	assert.Equal(t, "health", real.Ordering)
+
+	code, hypothetical := simulate(`{"ProcA":0.3,"ProcC":0.1}`)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"ProcB", "ProcA"}, names(hypothetical), "ProcA drops behind ProcB and ProcC's circuit opens")
+	assert.Equal(t, health.StatusDegraded, hypothetical.Candidates[1].Status)
+	assert.Equal(t, 0.3, hypothetical.Candidates[1].HealthScore)
+
+	code, none := simulate(`{"ProcA":0,"ProcB":0,"ProcC":0}`)
+	require.Equal(t, http.StatusOK, code)
+	assert.Empty(t, none.Candidates)
+	assert.Equal(t, "all eligible processors have open circuits", none.Reason)
+
+	// The real monitor is untouched
+	code, after := simulate(`{}`)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, names(real), names(after))
+
+	for _, overrides := range []string{`{"Unknown":0.5}`, `{"ProcA":1.5}`} {
+		code, _ := simulate(overrides)
+		assert.Equal(t, http.StatusBadRequest, code, overrides)
+	}
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/apikey"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/trace"
+)
+
+// Recover wraps next so a panicking handler is logged with its trace ID and stack
+// and answered with a 500 JSON error, instead of dropping the connection.
+// http.ErrAbortHandler is re-panicked so deliberate aborts still behave as usual.
+func Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+
+			traceID := w.Header().Get(trace.HeaderName)
+			if traceID == "" {
+				traceID = r.Header.Get(trace.HeaderName)
+			}
+			slog.Error("handler_panic",
+				"trace_id", traceID,
+				"method", r.Method,
+				"path", r.URL.Path,
+				"panic", fmt.Sprint(rec),
+				"stack", string(debug.Stack()),
+			)
+			writeError(w, http.StatusInternalServerError, "internal server error")
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// APIKeys wraps next so the request's X-API-Key header travels in its context,
+// where routing reads it to apply the orchestrator's processor allow-list. Once
+// keys are registered, a key that is not one of them is rejected with 401, so a
+// client can only act as a tenant whose key it holds.
+func APIKeys(keys apikey.Config, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if key := r.Header.Get(apikey.HeaderName); key != "" {
+			if len(keys.AllowList) > 0 && !keys.Registered(key) {
+				writeError(w, http.StatusUnauthorized, "unknown API key")
+				return
+			}
+			r = r.WithContext(apikey.WithAPIKey(r.Context(), key))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// JSONErrors wraps mux so requests that match no route get the structured JSON
+// error body instead of ServeMux's plain-text 404 and 405 responses. Errors written
+// by the route handlers themselves are untouched.
+func JSONErrors(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fallback, pattern := mux.Handler(r)
+		if pattern != "" {
+			mux.ServeHTTP(w, r)
+			return
+		}
+
+		iw := &interceptWriter{ResponseWriter: w}
+		fallback.ServeHTTP(iw, r)
+
+		switch iw.intercepted {
+		case http.StatusNotFound:
+			writeError(w, http.StatusNotFound, "route not found: "+r.URL.Path)
+		case http.StatusMethodNotAllowed:
+			writeError(w, http.StatusMethodNotAllowed, "method "+r.Method+" not allowed on "+r.URL.Path)
+		}
+	})
+}
+
+// interceptWriter swallows the mux's plain-text 404/405 so a JSON error can be
+// written instead. Headers such as Allow pass through; any other status is
+// forwarded unchanged.
+type interceptWriter struct {
+	http.ResponseWriter
+	intercepted int
+}
+
+func (w *interceptWriter) WriteHeader(code int) {
+	if code == http.StatusNotFound || code == http.StatusMethodNotAllowed {
+		w.intercepted = code
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *interceptWriter) Write(b []byte) (int, error) {
+	if w.intercepted != 0 {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
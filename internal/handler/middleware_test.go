@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/apikey"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/trace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONErrors_UnmatchedRoutes(t *testing.T) {
+	mux, _ := setupTestServer()
+	h := JSONErrors(mux)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+		wantAllow  bool
+	}{
+		{"unknown path", "GET", "/no/such/route", http.StatusNotFound, false},
+		{"wrong method on payments", "GET", "/payments", http.StatusMethodNotAllowed, true},
+		{"wrong method on payment by id", "DELETE", "/payments/tx-1", http.StatusMethodNotAllowed, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			var resp map[string]string
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp), "body should be JSON: %s", w.Body.String())
+			assert.Contains(t, resp["error"], tt.path)
+			if tt.wantAllow {
+				assert.NotEmpty(t, w.Header().Get("Allow"))
+			}
+		})
+	}
+}
+
+func TestJSONErrors_PassesThroughMatchedRoutes(t *testing.T) {
+	mux, _ := setupTestServer()
+	h := JSONErrors(mux)
+
+	// Handler-written 404s and 400s keep their own messages
+	req := httptest.NewRequest("GET", "/payments/tx-missing", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "transaction not found")
+
+	req = httptest.NewRequest("POST", "/payments", bytes.NewBufferString(`{}`))
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestRecover_PanicReturnsJSONAndServerKeepsServing(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /boom", func(w http.ResponseWriter, r *http.Request) {
+		panic("mapping function exploded")
+	})
+	mux.HandleFunc("GET /ok", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	srv := httptest.NewServer(Recover(mux))
+	defer srv.Close()
+
+	req, err := http.NewRequest("GET", srv.URL+"/boom", nil)
+	require.NoError(t, err)
+	req.Header.Set(trace.HeaderName, "trace-panic")
+	resp, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	var body map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "internal server error", body["error"])
+
+	for i := 0; i < 2; i++ {
+		resp, err := srv.Client().Get(srv.URL + "/ok")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode, "server keeps serving after a panic")
+	}
+}
+
+func TestRecover_PassesThroughWithoutPanic(t *testing.T) {
+	mux, _ := setupTestServer()
+	w := httptest.NewRecorder()
+	Recover(mux).ServeHTTP(w, httptest.NewRequest("GET", "/health/processors", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestAPIKeys_CarriesHeaderInContext(t *testing.T) {
+	registered := apikey.Config{AllowList: map[string][]string{"key-123": {"PayFlow"}}}
+	tests := []struct {
+		name     string
+		keys     apikey.Config
+		header   string
+		wantCode int
+		expected string
+	}{
+		{"registered key", registered, "key-123", http.StatusOK, "key-123"},
+		{"without key", registered, "", http.StatusOK, ""},
+		{"unregistered key is rejected", registered, "key-other", http.StatusUnauthorized, ""},
+		{"any key without registered keys", apikey.Config{}, "key-other", http.StatusOK, "key-other"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = apikey.FromContext(r.Context())
+			})
+			req := httptest.NewRequest("GET", "/health/processors", nil)
+			if tt.header != "" {
+				req.Header.Set(apikey.HeaderName, tt.header)
+			}
+			w := httptest.NewRecorder()
+			APIKeys(tt.keys, next).ServeHTTP(w, req)
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Equal(t, tt.expected, got)
+			if tt.wantCode == http.StatusUnauthorized {
+				assert.JSONEq(t, `{"error": "unknown API key"}`, w.Body.String())
+			}
+		})
+	}
+}
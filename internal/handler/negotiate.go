@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/model"
+)
+
+// Content types POST /payments can parse.
+const (
+	contentTypeJSON = "application/json"
+	contentTypeForm = "application/x-www-form-urlencoded"
+	contentTypeXML  = "application/xml"
+)
+
+// errUnsupportedContentType marks a body whose Content-Type the handler won't parse.
+var errUnsupportedContentType = errors.New("unsupported content type")
+
+// decodePaymentRequest parses a payment request body according to its Content-Type.
+// JSON is always accepted, including when no Content-Type is sent; form-encoded
+// and XML bodies only when enabled. Other types fail with errUnsupportedContentType.
+func (h *Handler) decodePaymentRequest(r *http.Request) (model.PaymentRequest, error) {
+	var req model.PaymentRequest
+	mediaType := contentTypeJSON
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		parsed, _, err := mime.ParseMediaType(ct)
+		if err != nil {
+			return req, fmt.Errorf("%w: %s", errUnsupportedContentType, ct)
+		}
+		mediaType = parsed
+	}
+
+	switch {
+	case mediaType == contentTypeJSON:
+		return req, h.decodeBody(r, &req)
+	case mediaType == contentTypeForm && h.cfg.FormBodies:
+		return h.decodeFormPayment(r)
+	case (mediaType == contentTypeXML || mediaType == "text/xml") && h.cfg.XMLBodies:
+		return decodeXMLPayment(r)
+	default:
+		return req, fmt.Errorf("%w: %s; accepted: %s", errUnsupportedContentType, mediaType, strings.Join(h.acceptedContentTypes(), ", "))
+	}
+}
+
+// acceptedContentTypes lists the payment body types enabled in the config.
+func (h *Handler) acceptedContentTypes() []string {
+	types := []string{contentTypeJSON}
+	if h.cfg.FormBodies {
+		types = append(types, contentTypeForm)
+	}
+	if h.cfg.XMLBodies {
+		types = append(types, contentTypeXML)
+	}
+	return types
+}
+
+// paymentFormFields are the form keys a payment request understands. List fields
+// take repeated keys, e.g. exclude_processors=PayFlow&exclude_processors=CardMax.
+var paymentFormFields = map[string]bool{
+	"transaction_id": true, "amount": true, "currency": true, "payment_method": true,
+	"customer_id": true, "exclude_processors": true, "preferred_processor": true,
+	"allow_zero_amount": true, "fan_out_methods": true, "avoid_degraded": true,
+}
+
+// decodeFormPayment parses a form-encoded payment request, using the JSON field
+// names as keys. Unknown keys are rejected when StrictJSON is set.
+func (h *Handler) decodeFormPayment(r *http.Request) (model.PaymentRequest, error) {
+	var req model.PaymentRequest
+	if err := r.ParseForm(); err != nil {
+		return req, err
+	}
+	form := r.PostForm
+	if h.cfg.StrictJSON {
+		for key := range form {
+			if !paymentFormFields[key] {
+				return req, fmt.Errorf("unknown field %q", key)
+			}
+		}
+	}
+
+	req = model.PaymentRequest{
+		TransactionID:      form.Get("transaction_id"),
+		Currency:           form.Get("currency"),
+		PaymentMethod:      form.Get("payment_method"),
+		CustomerID:         form.Get("customer_id"),
+		ExcludeProcessors:  form["exclude_processors"],
+		PreferredProcessor: form.Get("preferred_processor"),
+		FanOutMethods:      form["fan_out_methods"],
+	}
+	var err error
+	if req.Amount, err = parseFormFloat(form, "amount"); err != nil {
+		return req, err
+	}
+	if v := form.Get("allow_zero_amount"); v != "" {
+		if req.AllowZeroAmount, err = strconv.ParseBool(v); err != nil {
+			return req, errors.New("allow_zero_amount must be a boolean")
+		}
+	}
+	if v := form.Get("avoid_degraded"); v != "" {
+		if req.AvoidDegraded, err = strconv.ParseBool(v); err != nil {
+			return req, errors.New("avoid_degraded must be a boolean")
+		}
+	}
+	return req, nil
+}
+
+func parseFormFloat(form url.Values, key string) (float64, error) {
+	v := form.Get(key)
+	if v == "" {
+		return 0, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a number", key)
+	}
+	return f, nil
+}
+
+// paymentRequestXML is the XML body of a payment request:
+// <payment><transaction_id>…</transaction_id>…</payment>, with one element per
+// entry of a list field.
+type paymentRequestXML struct {
+	XMLName            xml.Name `xml:"payment"`
+	TransactionID      string   `xml:"transaction_id"`
+	Amount             float64  `xml:"amount"`
+	Currency           string   `xml:"currency"`
+	PaymentMethod      string   `xml:"payment_method"`
+	CustomerID         string   `xml:"customer_id"`
+	ExcludeProcessors  []string `xml:"exclude_processors"`
+	PreferredProcessor string   `xml:"preferred_processor"`
+	AllowZeroAmount    bool     `xml:"allow_zero_amount"`
+	FanOutMethods      []string `xml:"fan_out_methods"`
+	AvoidDegraded      bool     `xml:"avoid_degraded"`
+}
+
+func decodeXMLPayment(r *http.Request) (model.PaymentRequest, error) {
+	var body paymentRequestXML
+	if err := xml.NewDecoder(r.Body).Decode(&body); err != nil {
+		return model.PaymentRequest{}, err
+	}
+	return model.PaymentRequest{
+		TransactionID:      body.TransactionID,
+		Amount:             body.Amount,
+		Currency:           body.Currency,
+		PaymentMethod:      body.PaymentMethod,
+		CustomerID:         body.CustomerID,
+		ExcludeProcessors:  body.ExcludeProcessors,
+		PreferredProcessor: body.PreferredProcessor,
+		AllowZeroAmount:    body.AllowZeroAmount,
+		FanOutMethods:      body.FanOutMethods,
+		AvoidDegraded:      body.AvoidDegraded,
+	}, nil
+}
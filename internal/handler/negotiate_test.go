@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/health"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/model"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/orchestrator"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/processor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupNegotiatingServer(cfg Config) *http.ServeMux {
+	orch := orchestrator.New([]processor.Processor{
+		stubProcessor{"ProcA", model.Approved},
+		stubProcessor{"ProcB", model.Approved},
+	}, health.NewMonitorWithConfig(50, 10*time.Minute))
+	mux := http.NewServeMux()
+	NewWithConfig(orch, cfg).RegisterRoutes(mux)
+	return mux
+}
+
+func TestProcessPayment_ContentTypes(t *testing.T) {
+	want := model.PaymentRequest{
+		TransactionID:     "tx-negotiate",
+		Amount:            100.5,
+		Currency:          "USD",
+		PaymentMethod:     "card",
+		CustomerID:        "cust-1",
+		ExcludeProcessors: []string{"ProcA"},
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{
+			name:        "json",
+			contentType: "application/json",
+			body:        `{"transaction_id":"tx-negotiate","amount":100.5,"currency":"USD","payment_method":"card","customer_id":"cust-1","exclude_processors":["ProcA"]}`,
+		},
+		{
+			name:        "form-encoded",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "transaction_id=tx-negotiate&amount=100.5&currency=USD&payment_method=card&customer_id=cust-1&exclude_processors=ProcA",
+		},
+		{
+			name:        "form-encoded with charset",
+			contentType: "application/x-www-form-urlencoded; charset=utf-8",
+			body:        "transaction_id=tx-negotiate&amount=100.5&currency=USD&payment_method=card&customer_id=cust-1&exclude_processors=ProcA",
+		},
+		{
+			name:        "xml",
+			contentType: "application/xml",
+			body: `<payment><transaction_id>tx-negotiate</transaction_id><amount>100.5</amount><currency>USD</currency>` +
+				`<payment_method>card</payment_method><customer_id>cust-1</customer_id><exclude_processors>ProcA</exclude_processors></payment>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.XMLBodies = true
+			mux := setupNegotiatingServer(cfg)
+
+			req := httptest.NewRequest("POST", "/payments", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+			var result model.PaymentResult
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+			assert.Equal(t, model.StatusApproved, result.Status)
+			assert.Equal(t, "ProcB", result.WinningProcessor)
+			assert.Equal(t, want, result.Request)
+		})
+	}
+}
+
+func TestProcessPayment_UnsupportedContentTypes(t *testing.T) {
+	const formBody = "transaction_id=tx-1&amount=10"
+	tests := []struct {
+		name        string
+		cfg         func(*Config)
+		contentType string
+		body        string
+		wantStatus  int
+		wantError   string
+	}{
+		{"plain text", func(*Config) {}, "text/plain", formBody, http.StatusUnsupportedMediaType, "unsupported content type: text/plain"},
+		{"xml disabled by default", func(*Config) {}, "application/xml", formBody, http.StatusUnsupportedMediaType, "accepted: application/json, application/x-www-form-urlencoded"},
+		{"form disabled", func(c *Config) { c.FormBodies = false }, "application/x-www-form-urlencoded", formBody, http.StatusUnsupportedMediaType, "accepted: application/json"},
+		{"malformed content type", func(*Config) {}, "application/", formBody, http.StatusUnsupportedMediaType, "unsupported content type"},
+		{"invalid form amount", func(*Config) {}, "application/x-www-form-urlencoded", "transaction_id=tx-1&amount=abc", http.StatusBadRequest, "amount must be a number"},
+		{"unknown form field in strict mode", func(c *Config) { c.StrictJSON = true }, "application/x-www-form-urlencoded", "transaction_id=tx-1&amt=10", http.StatusBadRequest, "unknown field"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			tt.cfg(&cfg)
+			mux := setupNegotiatingServer(cfg)
+
+			req := httptest.NewRequest("POST", "/payments", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantError)
+		})
+	}
+}
@@ -5,6 +5,10 @@ import (
 	"encoding/hex"
 	"fmt"
 	mrand "math/rand"
+	"net/url"
+	"strconv"
+
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/config"
 )
 
 func randomHex(n int) string {
@@ -20,3 +24,24 @@ func itoa(i int) string {
 func randInt(max int) int {
 	return mrand.Intn(max)
 }
+
+// parsePagination reads the offset and limit query parameters, defaulting the
+// limit to config.DefaultPageLimit and capping it at config.MaxPageLimit.
+func parsePagination(q url.Values) (offset, limit int, errMsg string) {
+	limit = config.DefaultPageLimit
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return 0, 0, "limit must be a positive integer"
+		}
+		limit = min(n, config.MaxPageLimit)
+	}
+	if v := q.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return 0, 0, "offset must be a non-negative integer"
+		}
+		offset = n
+	}
+	return offset, limit, ""
+}
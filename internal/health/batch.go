@@ -0,0 +1,129 @@
+package health
+
+import (
+	"sync"
+	"time"
+
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/config"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/model"
+)
+
+// BatchedRecorder buffers outcomes and flushes them to a Monitor in batches,
+// trading bounded staleness for fewer write-lock acquisitions under high QPS.
+// Outcomes become visible to health reads within FlushInterval, or as soon as
+// BatchSize outcomes are buffered.
+type BatchedRecorder struct {
+	monitor       *Monitor
+	batchSize     int
+	flushInterval time.Duration
+
+	in       chan OutcomeRecord
+	flushReq chan chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewBatchedRecorder starts a recorder that flushes to monitor every flushInterval
+// or whenever batchSize outcomes are buffered. A flushInterval that isn't positive
+// falls back to config.HealthBatchFlushMillis. Call Close to flush and stop it.
+func NewBatchedRecorder(monitor *Monitor, batchSize int, flushInterval time.Duration) *BatchedRecorder {
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	if flushInterval <= 0 {
+		flushInterval = time.Duration(config.HealthBatchFlushMillis) * time.Millisecond
+	}
+	r := &BatchedRecorder{
+		monitor:       monitor,
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		in:            make(chan OutcomeRecord, batchSize*4),
+		flushReq:      make(chan chan struct{}),
+		done:          make(chan struct{}),
+	}
+	go r.run()
+	return r
+}
+
+// RecordOutcome buffers an outcome, timestamped now. It must not be called after Close.
+func (r *BatchedRecorder) RecordOutcome(processorName string, code model.ResponseCode) {
+	r.RecordResponse(processorName, model.ProcessorResponse{Code: code})
+}
+
+// RecordResponse buffers a processor response, timestamped now. It must not be
+// called after Close.
+func (r *BatchedRecorder) RecordResponse(processorName string, resp model.ProcessorResponse) {
+	r.in <- OutcomeRecord{
+		ProcessorName: processorName,
+		Code:          resp.Code,
+		Message:       resp.Message,
+		Latency:       resp.Latency,
+		Timestamp:     time.Now(),
+	}
+}
+
+// Flush writes every outcome recorded before the call to the monitor.
+func (r *BatchedRecorder) Flush() {
+	reply := make(chan struct{})
+	select {
+	case r.flushReq <- reply:
+		<-reply
+	case <-r.done:
+	}
+}
+
+// Close flushes buffered outcomes and stops the background goroutine.
+func (r *BatchedRecorder) Close() {
+	r.stopOnce.Do(func() {
+		close(r.in)
+	})
+	<-r.done
+}
+
+func (r *BatchedRecorder) run() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.flushInterval)
+	defer ticker.Stop()
+
+	buf := make([]OutcomeRecord, 0, r.batchSize)
+	flush := func() {
+		r.monitor.RecordOutcomes(buf)
+		buf = buf[:0]
+	}
+
+	for {
+		select {
+		case rec, ok := <-r.in:
+			if !ok {
+				flush()
+				return
+			}
+			buf = append(buf, rec)
+			if len(buf) >= r.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case reply := <-r.flushReq:
+			buf = r.drain(buf)
+			flush()
+			close(reply)
+		}
+	}
+}
+
+// drain appends every outcome already queued without blocking.
+func (r *BatchedRecorder) drain(buf []OutcomeRecord) []OutcomeRecord {
+	for {
+		select {
+		case rec, ok := <-r.in:
+			if !ok {
+				return buf
+			}
+			buf = append(buf, rec)
+		default:
+			return buf
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package health
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/config"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchedRecorder_EventuallyConsistent(t *testing.T) {
+	m := NewMonitorWithConfig(1000, 10*time.Minute)
+	r := NewBatchedRecorder(m, 64, 10*time.Millisecond)
+	defer r.Close()
+
+	const goroutines, perGoroutine = 8, 50
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < perGoroutine; i++ {
+				code := model.Approved
+				if i%5 == 0 {
+					code = model.ProcessorError
+				}
+				r.RecordOutcome(fmt.Sprintf("Proc%d", g%2), code)
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	// No explicit flush: the periodic flush must publish every outcome
+	assert.Eventually(t, func() bool {
+		return m.GetHealth("Proc0").TotalProcessed+m.GetHealth("Proc1").TotalProcessed == goroutines*perGoroutine
+	}, time.Second, 5*time.Millisecond)
+
+	for _, name := range []string{"Proc0", "Proc1"} {
+		h := m.GetHealth(name)
+		assert.Equal(t, int64(goroutines/2*perGoroutine), h.TotalProcessed)
+		assert.Equal(t, int64(goroutines/2*perGoroutine*4/5), h.TotalApproved)
+		assert.InDelta(t, 0.8, h.HealthScore, 0.001)
+	}
+}
+
+func TestBatchedRecorder_FlushAndClose(t *testing.T) {
+	m := NewMonitorWithConfig(50, 10*time.Minute)
+	// A long interval and large batch ensure only explicit flushes publish outcomes
+	r := NewBatchedRecorder(m, 1000, time.Hour)
+
+	r.RecordOutcome("ProcA", model.Approved)
+	r.RecordOutcome("ProcA", model.SoftDecline)
+	r.Flush()
+	assert.Equal(t, 2, m.GetHealth("ProcA").TotalRecent)
+
+	r.RecordOutcome("ProcA", model.Approved)
+	r.Close()
+	assert.Equal(t, 3, m.GetHealth("ProcA").TotalRecent, "Close should flush buffered outcomes")
+
+	r.Close() // idempotent
+	r.Flush() // no-op after close
+}
+
+func TestBatchedRecorder_DefaultsFlushInterval(t *testing.T) {
+	m := NewMonitorWithConfig(50, 10*time.Minute)
+	for _, interval := range []time.Duration{0, -time.Second} {
+		r := NewBatchedRecorder(m, 1000, interval)
+		assert.Equal(t, time.Duration(config.HealthBatchFlushMillis)*time.Millisecond, r.flushInterval)
+		r.Close()
+	}
+}
+
+func TestMonitor_RecordOutcomes(t *testing.T) {
+	m := NewMonitorWithConfig(3, 10*time.Minute)
+	now := time.Now()
+	m.RecordOutcomes([]OutcomeRecord{
+		{ProcessorName: "ProcA", Code: model.ProcessorError, Timestamp: now},
+		{ProcessorName: "ProcA", Code: model.Approved, Timestamp: now},
+		{ProcessorName: "ProcB", Code: model.Approved, Timestamp: now},
+		{ProcessorName: "ProcA", Code: model.Approved, Timestamp: now},
+		{ProcessorName: "ProcA", Code: model.Approved, Timestamp: now},
+	})
+
+	a := m.GetHealth("ProcA")
+	assert.Equal(t, 3, a.TotalRecent, "window size still applies to batches")
+	assert.Equal(t, 1.0, a.HealthScore)
+	assert.Equal(t, int64(4), a.TotalProcessed)
+	assert.Equal(t, 1, m.GetHealth("ProcB").TotalRecent)
+}
+
+// benchmarkRecording records outcomes from parallel goroutines while a reader
+// polls health, approximating the concurrent-payments path.
+func benchmarkRecording(b *testing.B, m *Monitor, rec Recorder) {
+	stop := make(chan struct{})
+	var readers sync.WaitGroup
+	readers.Add(1)
+	go func() {
+		defer readers.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				m.GetHealth("ProcA")
+			}
+		}
+	}()
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			rec.RecordResponse("ProcA", model.ProcessorResponse{Code: model.Approved})
+		}
+	})
+	b.StopTimer()
+
+	close(stop)
+	readers.Wait()
+}
+
+func BenchmarkRecordOutcome_Direct(b *testing.B) {
+	m := NewMonitorWithConfig(50, 10*time.Minute)
+	benchmarkRecording(b, m, m)
+}
+
+func BenchmarkRecordOutcome_Batched(b *testing.B) {
+	m := NewMonitorWithConfig(50, 10*time.Minute)
+	r := NewBatchedRecorder(m, 256, 10*time.Millisecond)
+	defer r.Close()
+	benchmarkRecording(b, m, r)
+}
@@ -0,0 +1,31 @@
+//go:build debug
+
+package health
+
+import "time"
+
+// OutcomeInfo is a copy of one outcome in a processor's active window.
+type OutcomeInfo struct {
+	Approved  bool          `json:"approved"`
+	Latency   time.Duration `json:"latency"`
+	Timestamp time.Time     `json:"timestamp"`
+}
+
+// GetRecentOutcomes returns the outcomes in a processor's active window, oldest
+// first. It is only built with the debug tag, for analytics and for testing the
+// windowing logic; the result is a copy and safe to modify.
+func (m *Monitor) GetRecentOutcomes(processorName string) []OutcomeInfo {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	window := m.getActiveWindow(processorName)
+	outcomes := make([]OutcomeInfo, 0, len(window))
+	for _, o := range window {
+		outcomes = append(outcomes, OutcomeInfo{
+			Approved:  o.approved,
+			Latency:   o.latency,
+			Timestamp: o.timestamp,
+		})
+	}
+	return outcomes
+}
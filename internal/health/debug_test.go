@@ -0,0 +1,50 @@
+//go:build debug
+
+package health
+
+import (
+	"testing"
+	"time"
+
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMonitor_GetRecentOutcomes(t *testing.T) {
+	m := NewMonitorWithConfig(3, 10*time.Minute)
+	now := time.Now()
+	m.RecordOutcomes([]OutcomeRecord{
+		{ProcessorName: "ProcA", Code: model.Approved, Timestamp: now.Add(-20 * time.Minute)}, // outside the time window
+		{ProcessorName: "ProcA", Code: model.ProcessorError, Timestamp: now.Add(-3 * time.Second)},
+		{ProcessorName: "ProcA", Code: model.Approved, Latency: 40 * time.Millisecond, Timestamp: now.Add(-2 * time.Second)},
+		{ProcessorName: "ProcB", Code: model.Approved, Timestamp: now},
+		{ProcessorName: "ProcA", Code: model.SoftDecline, Timestamp: now.Add(-time.Second)},
+	})
+
+	outcomes := m.GetRecentOutcomes("ProcA")
+	require.Len(t, outcomes, 3)
+	assert.Equal(t, []OutcomeInfo{
+		{Approved: false, Timestamp: now.Add(-3 * time.Second)},
+		{Approved: true, Latency: 40 * time.Millisecond, Timestamp: now.Add(-2 * time.Second)},
+		{Approved: false, Timestamp: now.Add(-time.Second)},
+	}, outcomes)
+
+	// The result is a copy: modifying it leaves the window intact
+	outcomes[0].Approved = true
+	assert.False(t, m.GetRecentOutcomes("ProcA")[0].Approved)
+
+	assert.Empty(t, m.GetRecentOutcomes("ProcC"))
+}
+
+func TestMonitor_GetRecentOutcomesRespectsWindowSize(t *testing.T) {
+	m := NewMonitorWithConfig(2, 10*time.Minute)
+	m.RecordOutcome("ProcA", model.ProcessorError)
+	m.RecordOutcome("ProcA", model.Approved)
+	m.RecordOutcome("ProcA", model.Approved)
+
+	outcomes := m.GetRecentOutcomes("ProcA")
+	require.Len(t, outcomes, 2)
+	assert.True(t, outcomes[0].Approved)
+	assert.False(t, outcomes[1].Timestamp.Before(outcomes[0].Timestamp), "oldest first")
+}
@@ -1,7 +1,12 @@
 package health
 
 import (
+	"log/slog"
+	"math"
+	"slices"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/config"
@@ -18,113 +23,586 @@ const (
 )
 
 // ProcessorHealth contains the current health information for a processor.
+// TotalProcessed and TotalApproved are lifetime counters, unaffected by the sliding window.
+// The LastFailure fields describe the most recent non-approved outcome, if any.
+// SuccessStreak and FailureStreak count consecutive approvals and non-approvals;
+// at most one of them is non-zero. AvgLatencyMs averages the timed responses in
+// the active window. ExpectedScore is the processor's approval target, if set;
+// Drift flags a windowed score further than the drift tolerance from it.
 type ProcessorHealth struct {
-	ProcessorName string    `json:"processor_name"`
-	HealthScore   float64   `json:"health_score"`
-	Status        Status    `json:"status"`
-	TotalRecent   int       `json:"total_recent"`
-	ApprovedCount int       `json:"approved_count"`
-	ErrorCount    int       `json:"error_count"`
-	LastUpdated   time.Time `json:"last_updated"`
+	ProcessorName      string             `json:"processor_name"`
+	HealthScore        float64            `json:"health_score"`
+	Status             Status             `json:"status"`
+	TotalRecent        int                `json:"total_recent"`
+	ApprovedCount      int                `json:"approved_count"`
+	ErrorCount         int                `json:"error_count"`
+	TotalProcessed     int64              `json:"total_processed"`
+	TotalApproved      int64              `json:"total_approved"`
+	LastFailureCode    model.ResponseCode `json:"last_failure_code,omitempty"`
+	LastFailureMessage string             `json:"last_failure_message,omitempty"`
+	LastFailureAt      *time.Time         `json:"last_failure_at,omitempty"`
+	SuccessStreak      int                `json:"success_streak"`
+	FailureStreak      int                `json:"failure_streak"`
+	LatencyHistogram   *LatencyHistogram  `json:"latency_histogram,omitempty"`
+	AvgLatencyMs       float64            `json:"avg_latency_ms,omitempty"`
+	ExpectedScore      float64            `json:"expected_score,omitempty"`
+	Drift              bool               `json:"drift,omitempty"`
+	LastUpdated        time.Time          `json:"last_updated"`
+}
+
+// LatencyHistogram buckets the response latencies in the active window. Outcomes
+// recorded without a latency are not counted. Bucket lower bounds are inclusive.
+type LatencyHistogram struct {
+	Under50ms  int `json:"under_50ms"`
+	Under100ms int `json:"50_to_100ms"`
+	Under250ms int `json:"100_to_250ms"`
+	Over250ms  int `json:"250ms_plus"`
+}
+
+func (h *LatencyHistogram) add(latency time.Duration) {
+	switch {
+	case latency < 50*time.Millisecond:
+		h.Under50ms++
+	case latency < 100*time.Millisecond:
+		h.Under100ms++
+	case latency < 250*time.Millisecond:
+		h.Under250ms++
+	default:
+		h.Over250ms++
+	}
 }
 
 // outcome records a single transaction outcome.
 type outcome struct {
-	approved  bool
+	approved bool
+	// businessDecline marks customer-caused declines (insufficient funds, fraud).
+	businessDecline bool
+	latency         time.Duration
+	timestamp       time.Time
+}
+
+// lifetimeCounters accumulate outcomes for the lifetime of the monitor.
+type lifetimeCounters struct {
+	processed atomic.Int64
+	approved  atomic.Int64
+}
+
+// failure records the most recent non-approved outcome for a processor.
+type failure struct {
+	code      model.ResponseCode
+	message   string
 	timestamp time.Time
 }
 
+// streak counts consecutive outcomes of the same kind; recording the opposite
+// outcome resets the other counter.
+type streak struct {
+	successes int
+	failures  int
+}
+
+// cachedHealth is a computed ProcessorHealth and when it stops being served.
+type cachedHealth struct {
+	health  ProcessorHealth
+	expires time.Time
+}
+
 // Monitor tracks processor health using a sliding window.
 type Monitor struct {
 	mu             sync.RWMutex
 	windows        map[string][]outcome
+	lifetime       map[string]*lifetimeCounters
+	lastFailures   map[string]failure
+	streaks        map[string]streak
 	windowSize     int
 	windowDuration time.Duration
+
+	// targets holds each processor's expected approval rate for drift detection.
+	targets        map[string]float64
+	driftTolerance float64
+
+	// excludeBusinessDeclines leaves business declines out of health scoring.
+	excludeBusinessDeclines bool
+
+	// statuses holds the last status reported to status-change hooks.
+	hooks    []func(StatusChange)
+	statuses map[string]Status
+
+	// recoveredAt holds when each processor last left StatusOpen, while
+	// trackRecoveries is set.
+	trackRecoveries bool
+	recoveredAt     map[string]time.Time
+
+	// The health cache is written by readers holding mu.RLock, so it has its own
+	// lock. Entries are invalidated under mu.Lock, which excludes every reader.
+	cacheMu  sync.Mutex
+	cache    map[string]cachedHealth
+	cacheTTL time.Duration
 }
 
 // NewMonitor creates a new health monitor with default configuration.
 func NewMonitor() *Monitor {
 	return &Monitor{
 		windows:        make(map[string][]outcome),
-		windowSize:     config.HealthWindowSize,
+		lifetime:       make(map[string]*lifetimeCounters),
+		lastFailures:   make(map[string]failure),
+		streaks:        make(map[string]streak),
+		windowSize:     clampWindowSize(config.HealthWindowSize),
 		windowDuration: time.Duration(config.HealthWindowDurationMinutes) * time.Minute,
+		targets:        make(map[string]float64),
+		driftTolerance: config.ApprovalDriftTolerance,
+		cache:          make(map[string]cachedHealth),
+		cacheTTL:       time.Duration(config.HealthCacheTTLMillis) * time.Millisecond,
+		statuses:       make(map[string]Status),
+		recoveredAt:    make(map[string]time.Time),
+
+		excludeBusinessDeclines: config.HealthExcludesBusinessDeclines,
 	}
 }
 
 // NewMonitorWithConfig creates a monitor with custom window settings for testing.
+// A window size outside [config.MinHealthWindowSize, config.MaxHealthWindowSize]
+// is clamped to the nearest bound.
 func NewMonitorWithConfig(windowSize int, windowDuration time.Duration) *Monitor {
+	windowSize = clampWindowSize(windowSize)
 	return &Monitor{
 		windows:        make(map[string][]outcome),
+		lifetime:       make(map[string]*lifetimeCounters),
+		lastFailures:   make(map[string]failure),
+		streaks:        make(map[string]streak),
 		windowSize:     windowSize,
 		windowDuration: windowDuration,
+		targets:        make(map[string]float64),
+		driftTolerance: config.ApprovalDriftTolerance,
+		cache:          make(map[string]cachedHealth),
+		statuses:       make(map[string]Status),
+		recoveredAt:    make(map[string]time.Time),
 	}
 }
 
-// RecordOutcome records a transaction outcome for a processor.
-func (m *Monitor) RecordOutcome(processorName string, code model.ResponseCode) {
+// clampWindowSize bounds a configured window size, logging any adjustment.
+func clampWindowSize(windowSize int) int {
+	clamped := min(max(windowSize, config.MinHealthWindowSize), config.MaxHealthWindowSize)
+	if clamped != windowSize {
+		slog.Warn("health_window_size_clamped",
+			"requested", windowSize,
+			"window_size", clamped,
+		)
+	}
+	return clamped
+}
+
+// SetCacheTTL serves computed health from a per-processor cache for up to ttl.
+// Recording an outcome for a processor invalidates its entry, so cached health
+// only lags outcomes expiring from the time window, by at most ttl. Zero
+// disables the cache.
+func (m *Monitor) SetCacheTTL(ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cacheTTL = ttl
+	m.clearCacheLocked()
+}
+
+// SetApprovalTarget sets the approval rate a processor is expected to sustain.
+// Its health then reports the target as ExpectedScore and flags Drift once the
+// window holds config.ApprovalDriftMinSamples outcomes and its score deviates
+// from the target by more than the drift tolerance. Zero removes the target.
+func (m *Monitor) SetApprovalTarget(processorName string, expected float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if expected > 0 {
+		m.targets[processorName] = expected
+	} else {
+		delete(m.targets, processorName)
+	}
+	m.invalidateLocked(processorName)
+}
+
+// SetExcludeBusinessDeclines decides whether business declines (insufficient
+// funds, fraud), which the customer rather than the processor causes, count
+// against health. Excluded declines are ignored by health scoring entirely, so a
+// processor's score reflects only approvals against processor-side failures and
+// soft declines. Outcomes already recorded are rescored.
+func (m *Monitor) SetExcludeBusinessDeclines(exclude bool) {
+	m.mu.Lock()
+	m.excludeBusinessDeclines = exclude
+	m.clearCacheLocked()
+	names := make([]string, 0, len(m.windows))
+	for name := range m.windows {
+		names = append(names, name)
+	}
+	changes := m.statusChangesLocked(names...)
+	m.mu.Unlock()
+
+	m.notify(changes)
+}
+
+// SetDriftTolerance sets how far a windowed score may deviate from its approval
+// target before Drift is flagged.
+func (m *Monitor) SetDriftTolerance(tolerance float64) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	m.driftTolerance = tolerance
+	m.clearCacheLocked()
+}
+
+// Recorder accepts processor responses for health tracking. Both Monitor and
+// BatchedRecorder implement it.
+type Recorder interface {
+	RecordResponse(processorName string, resp model.ProcessorResponse)
+}
+
+// OutcomeRecord is a single outcome captured for deferred recording.
+type OutcomeRecord struct {
+	ProcessorName string
+	Code          model.ResponseCode
+	Message       string
+	Latency       time.Duration
+	Timestamp     time.Time
+}
+
+// StatusChange reports a processor moving between health statuses as a result
+// of recorded outcomes.
+type StatusChange struct {
+	ProcessorName string
+	From          Status
+	To            Status
+	HealthScore   float64
+}
+
+// OnStatusChange registers fn to be called, after the recording lock is released,
+// whenever recording outcomes changes a processor's status. Processors without
+// outcomes start out healthy. Changes caused only by outcomes aging out of the
+// time window are reported with the next recorded outcome.
+func (m *Monitor) OnStatusChange(fn func(StatusChange)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, fn)
+}
+
+// TrackRecoveries starts recording when processors recover, i.e. leave
+// StatusOpen, as reported by RecoveredAt. Like status-change hooks, a recovery
+// caused only by outcomes aging out of the time window is seen with the next
+// recorded outcome.
+func (m *Monitor) TrackRecoveries() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.trackRecoveries = true
+}
+
+// RecoveredAt returns when a processor's circuit last closed again, if it has
+// since TrackRecoveries was called.
+func (m *Monitor) RecoveredAt(processorName string) (time.Time, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	at, ok := m.recoveredAt[processorName]
+	return at, ok
+}
+
+// statusChangesLocked compares the status of each named processor with the last
+// one reported, called under write lock. It does nothing without hooks or
+// recovery tracking.
+func (m *Monitor) statusChangesLocked(names ...string) []StatusChange {
+	if len(m.hooks) == 0 && !m.trackRecoveries {
+		return nil
+	}
+	var changes []StatusChange
+	for _, name := range names {
+		h := m.computeHealth(name)
+		prev, ok := m.statuses[name]
+		if !ok {
+			prev = StatusHealthy
+		}
+		if h.Status != prev {
+			changes = append(changes, StatusChange{ProcessorName: name, From: prev, To: h.Status, HealthScore: h.HealthScore})
+			if prev == StatusOpen && m.trackRecoveries {
+				m.recoveredAt[name] = time.Now()
+			}
+		}
+		m.statuses[name] = h.Status
+	}
+	return changes
+}
+
+// notify calls the status-change hooks, outside the lock so hooks may read health.
+func (m *Monitor) notify(changes []StatusChange) {
+	if len(changes) == 0 {
+		return
+	}
+	m.mu.RLock()
+	hooks := make([]func(StatusChange), len(m.hooks))
+	copy(hooks, m.hooks)
+	m.mu.RUnlock()
+	for _, c := range changes {
+		for _, fn := range hooks {
+			fn(c)
+		}
+	}
+}
+
+// RecordOutcome records a transaction outcome for a processor.
+func (m *Monitor) RecordOutcome(processorName string, code model.ResponseCode) {
+	m.RecordOutcomeAt(processorName, code, time.Now())
+}
 
-	approved := code == model.Approved
-	m.windows[processorName] = append(m.windows[processorName], outcome{
-		approved:  approved,
-		timestamp: time.Now(),
+// RecordOutcomeAt records an outcome that happened at t, e.g. to restore health
+// state or backfill historical data. Outcomes already outside the time window are
+// counted in the lifetime totals but pruned from the window straight away.
+func (m *Monitor) RecordOutcomeAt(processorName string, code model.ResponseCode, t time.Time) {
+	m.record(OutcomeRecord{
+		ProcessorName: processorName,
+		Code:          code,
+		Timestamp:     t,
+	})
+}
+
+// RecordResponse records a processor response, keeping its code and message as
+// the last failure when it is not an approval.
+func (m *Monitor) RecordResponse(processorName string, resp model.ProcessorResponse) {
+	m.record(OutcomeRecord{
+		ProcessorName: processorName,
+		Code:          resp.Code,
+		Message:       resp.Message,
+		Latency:       resp.Latency,
+		Timestamp:     time.Now(),
 	})
+}
+
+// record adds a single outcome and prunes its processor's window.
+func (m *Monitor) record(r OutcomeRecord) {
+	m.mu.Lock()
+	m.appendLocked(r)
+	m.pruneWindow(r.ProcessorName)
+	changes := m.statusChangesLocked(r.ProcessorName)
+	m.mu.Unlock()
+
+	m.notify(changes)
+}
+
+// RecordOutcomes records a batch of outcomes under a single lock acquisition,
+// pruning each affected window once.
+func (m *Monitor) RecordOutcomes(records []OutcomeRecord) {
+	if len(records) == 0 {
+		return
+	}
+
+	m.mu.Lock()
+	touched := make(map[string]struct{})
+	var names []string
+	for _, r := range records {
+		m.appendLocked(r)
+		if _, ok := touched[r.ProcessorName]; !ok {
+			touched[r.ProcessorName] = struct{}{}
+			names = append(names, r.ProcessorName)
+		}
+	}
+	for _, name := range names {
+		m.pruneWindow(name)
+	}
+	changes := m.statusChangesLocked(names...)
+	m.mu.Unlock()
+
+	m.notify(changes)
+}
 
-	m.pruneWindow(processorName)
+// appendLocked adds an outcome to the window, lifetime counters, streaks and last
+// failure, called under write lock. The window stays in timestamp order, so an
+// outcome older than the latest one is inserted in place and leaves streaks alone.
+func (m *Monitor) appendLocked(r OutcomeRecord) {
+	m.invalidateLocked(r.ProcessorName)
+	approved := r.Code == model.Approved
+	o := outcome{
+		approved:        approved,
+		businessDecline: r.Code.Classification() == model.ClassificationBusinessDecline,
+		latency:         r.Latency,
+		timestamp:       r.Timestamp,
+	}
+	window := m.windows[r.ProcessorName]
+	latest := len(window) == 0 || !r.Timestamp.Before(window[len(window)-1].timestamp)
+	if latest {
+		m.windows[r.ProcessorName] = append(window, o)
+	} else {
+		i := sort.Search(len(window), func(i int) bool { return window[i].timestamp.After(r.Timestamp) })
+		m.windows[r.ProcessorName] = slices.Insert(window, i, o)
+	}
+
+	counters, ok := m.lifetime[r.ProcessorName]
+	if !ok {
+		counters = &lifetimeCounters{}
+		m.lifetime[r.ProcessorName] = counters
+	}
+	counters.processed.Add(1)
+	if approved {
+		counters.approved.Add(1)
+		if latest {
+			m.streaks[r.ProcessorName] = streak{successes: m.streaks[r.ProcessorName].successes + 1}
+		}
+		return
+	}
+	if latest {
+		m.streaks[r.ProcessorName] = streak{failures: m.streaks[r.ProcessorName].failures + 1}
+	}
+
+	if last, ok := m.lastFailures[r.ProcessorName]; !ok || !r.Timestamp.Before(last.timestamp) {
+		m.lastFailures[r.ProcessorName] = failure{code: r.Code, message: r.Message, timestamp: r.Timestamp}
+	}
 }
 
-// GetHealth returns the current health information for a processor.
+// GetHealth returns the current health information for a processor, served from
+// the cache when one is configured with SetCacheTTL.
 func (m *Monitor) GetHealth(processorName string) ProcessorHealth {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
+	if m.cacheTTL <= 0 {
+		return m.computeHealth(processorName)
+	}
+	now := time.Now()
+	m.cacheMu.Lock()
+	entry, ok := m.cache[processorName]
+	m.cacheMu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.health.clone()
+	}
+
+	h := m.computeHealth(processorName)
+	m.cacheMu.Lock()
+	m.cache[processorName] = cachedHealth{health: h.clone(), expires: now.Add(m.cacheTTL)}
+	m.cacheMu.Unlock()
+	return h
+}
+
+// clone copies h so the pointer fields are not shared with the cache.
+func (h ProcessorHealth) clone() ProcessorHealth {
+	if h.LatencyHistogram != nil {
+		hist := *h.LatencyHistogram
+		h.LatencyHistogram = &hist
+	}
+	if h.LastFailureAt != nil {
+		at := *h.LastFailureAt
+		h.LastFailureAt = &at
+	}
+	return h
+}
+
+// invalidateLocked drops a processor's cached health, called under write lock.
+func (m *Monitor) invalidateLocked(processorName string) {
+	m.cacheMu.Lock()
+	delete(m.cache, processorName)
+	m.cacheMu.Unlock()
+}
+
+// clearCacheLocked drops all cached health, called under write lock.
+func (m *Monitor) clearCacheLocked() {
+	m.cacheMu.Lock()
+	m.cache = make(map[string]cachedHealth)
+	m.cacheMu.Unlock()
+}
+
+// computeHealth derives a processor's health from its window, called under read lock.
+func (m *Monitor) computeHealth(processorName string) ProcessorHealth {
 	window := m.getActiveWindow(processorName)
 
+	var totalProcessed, totalApproved int64
+	if counters, ok := m.lifetime[processorName]; ok {
+		totalProcessed = counters.processed.Load()
+		totalApproved = counters.approved.Load()
+	}
+	last, hasFailure := m.lastFailures[processorName]
+	st := m.streaks[processorName]
+
 	if len(window) == 0 {
-		return ProcessorHealth{
-			ProcessorName: processorName,
-			HealthScore:   1.0, // New/unknown processors default to healthy
-			Status:        StatusHealthy,
-			TotalRecent:   0,
-			ApprovedCount: 0,
-			ErrorCount:    0,
-			LastUpdated:   time.Now(),
+		h := ProcessorHealth{
+			ProcessorName:  processorName,
+			HealthScore:    1.0, // New/unknown processors default to healthy
+			Status:         StatusHealthy,
+			TotalRecent:    0,
+			ApprovedCount:  0,
+			ErrorCount:     0,
+			TotalProcessed: totalProcessed,
+			TotalApproved:  totalApproved,
+			SuccessStreak:  st.successes,
+			FailureStreak:  st.failures,
+			ExpectedScore:  m.targets[processorName],
+			LastUpdated:    time.Now(),
 		}
+		if hasFailure {
+			h.setLastFailure(last)
+		}
+		return h
 	}
 
 	approved := 0
 	errors := 0
+	var latencies LatencyHistogram
+	var timed int
+	var latencySum time.Duration
 	for _, o := range window {
 		if o.approved {
 			approved++
 		} else {
 			errors++
 		}
+		if o.latency > 0 {
+			latencies.add(o.latency)
+			timed++
+			latencySum += o.latency
+		}
 	}
 
 	total := len(window)
 	score := float64(approved) / float64(total)
 
-	status := StatusHealthy
-	if score < config.CircuitBreakerThreshold {
-		status = StatusOpen
-	} else if score < config.DegradedThreshold {
-		status = StatusDegraded
+	status := StatusForScore(score)
+
+	h := ProcessorHealth{
+		ProcessorName:  processorName,
+		HealthScore:    score,
+		Status:         status,
+		TotalRecent:    total,
+		ApprovedCount:  approved,
+		ErrorCount:     errors,
+		TotalProcessed: totalProcessed,
+		TotalApproved:  totalApproved,
+		SuccessStreak:  st.successes,
+		FailureStreak:  st.failures,
+		LastUpdated:    time.Now(),
+	}
+	if expected, ok := m.targets[processorName]; ok {
+		h.ExpectedScore = expected
+		h.Drift = total >= config.ApprovalDriftMinSamples && math.Abs(score-expected) > m.driftTolerance
+	}
+	if timed > 0 {
+		h.LatencyHistogram = &latencies
+		h.AvgLatencyMs = float64(latencySum) / float64(timed) / float64(time.Millisecond)
 	}
+	if hasFailure {
+		h.setLastFailure(last)
+	}
+	return h
+}
 
-	return ProcessorHealth{
-		ProcessorName: processorName,
-		HealthScore:   score,
-		Status:        status,
-		TotalRecent:   total,
-		ApprovedCount: approved,
-		ErrorCount:    errors,
-		LastUpdated:   time.Now(),
+// StatusForScore returns the status a processor with the given health score is in.
+func StatusForScore(score float64) Status {
+	switch {
+	case score < config.CircuitBreakerThreshold:
+		return StatusOpen
+	case score < config.DegradedThreshold:
+		return StatusDegraded
+	default:
+		return StatusHealthy
 	}
 }
 
-// GetAllHealth returns health information for all tracked processors.
+func (h *ProcessorHealth) setLastFailure(f failure) {
+	at := f.timestamp
+	h.LastFailureCode = f.code
+	h.LastFailureMessage = f.message
+	h.LastFailureAt = &at
+}
+
+// GetAllHealth returns health information for all tracked processors, sorted by
+// name so the order is stable across calls.
 func (m *Monitor) GetAllHealth() []ProcessorHealth {
 	m.mu.RLock()
 	processors := make([]string, 0, len(m.windows))
@@ -132,6 +610,7 @@ func (m *Monitor) GetAllHealth() []ProcessorHealth {
 		processors = append(processors, name)
 	}
 	m.mu.RUnlock()
+	slices.Sort(processors)
 
 	healths := make([]ProcessorHealth, 0, len(processors))
 	for _, name := range processors {
@@ -146,7 +625,57 @@ func (m *Monitor) IsCircuitOpen(processorName string) bool {
 	return h.Status == StatusOpen
 }
 
-// getActiveWindow returns outcomes within the time window, already under read lock.
+// EstimatedRecovery estimates how long until the processor's circuit closes if no
+// further outcomes are recorded, by ageing its oldest outcomes out of the time
+// window until the score climbs back to the circuit breaker threshold. It is zero
+// when the circuit is not open.
+func (m *Monitor) EstimatedRecovery(processorName string) time.Duration {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	window := m.getActiveWindow(processorName)
+	if m.computeHealth(processorName).Status != StatusOpen {
+		return 0
+	}
+	sort.SliceStable(window, func(i, j int) bool { return window[i].timestamp.Before(window[j].timestamp) })
+
+	approved := 0
+	for _, o := range window {
+		if o.approved {
+			approved++
+		}
+	}
+	now := time.Now()
+	for i, o := range window {
+		if o.approved {
+			approved--
+		}
+		remaining := len(window) - i - 1
+		if remaining == 0 || float64(approved)/float64(remaining) >= config.CircuitBreakerThreshold {
+			return max(o.timestamp.Add(m.windowDuration).Sub(now), 0)
+		}
+	}
+	return 0
+}
+
+// Reset clears all health windows, lifetime counters, streaks, last failures and
+// recoveries, returning how many processors were tracked.
+func (m *Monitor) Reset() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cleared := len(m.windows)
+	m.windows = make(map[string][]outcome)
+	m.lifetime = make(map[string]*lifetimeCounters)
+	m.lastFailures = make(map[string]failure)
+	m.streaks = make(map[string]streak)
+	m.statuses = make(map[string]Status)
+	m.recoveredAt = make(map[string]time.Time)
+	m.clearCacheLocked()
+	return cleared
+}
+
+// getActiveWindow returns the scored outcomes within the time window, already
+// under read lock. Business declines are left out when excluded from health.
 func (m *Monitor) getActiveWindow(processorName string) []outcome {
 	window := m.windows[processorName]
 	if len(window) == 0 {
@@ -156,6 +685,9 @@ func (m *Monitor) getActiveWindow(processorName string) []outcome {
 	cutoff := time.Now().Add(-m.windowDuration)
 	active := make([]outcome, 0, len(window))
 	for _, o := range window {
+		if o.businessDecline && m.excludeBusinessDeclines {
+			continue
+		}
 		if o.timestamp.After(cutoff) {
 			active = append(active, o)
 		}
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/config"
 	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -186,6 +187,39 @@ func TestMonitor_WindowSize(t *testing.T) {
 	assert.Equal(t, 5, h.TotalRecent)
 }
 
+func TestNewMonitorWithConfig_ClampsWindowSize(t *testing.T) {
+	tests := []struct {
+		name       string
+		windowSize int
+		wantRecent int
+		wantScore  float64
+	}{
+		{"zero falls back to the minimum", 0, config.MinHealthWindowSize, 1.0},
+		{"negative falls back to the minimum", -5, config.MinHealthWindowSize, 1.0},
+		{"one keeps only the latest outcome", 1, 1, 1.0},
+		{"huge is capped", 1_000_000_000, 20, 0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMonitorWithConfig(tt.windowSize, 10*time.Minute)
+			assert.GreaterOrEqual(t, m.windowSize, config.MinHealthWindowSize)
+			assert.LessOrEqual(t, m.windowSize, config.MaxHealthWindowSize)
+
+			// Ten failures followed by ten approvals
+			for i := 0; i < 20; i++ {
+				code := model.ProcessorError
+				if i >= 10 {
+					code = model.Approved
+				}
+				m.RecordOutcome("Proc", code)
+			}
+			h := m.GetHealth("Proc")
+			assert.Equal(t, tt.wantRecent, h.TotalRecent)
+			assert.Equal(t, tt.wantScore, h.HealthScore)
+		})
+	}
+}
+
 func TestMonitor_TimeWindowExpiry(t *testing.T) {
 	m := NewMonitorWithConfig(50, 100*time.Millisecond)
 
@@ -224,6 +258,184 @@ func TestMonitor_IsCircuitOpen(t *testing.T) {
 	assert.True(t, m.IsCircuitOpen("BadProc"))
 }
 
+func TestMonitor_EstimatedRecovery(t *testing.T) {
+	m := NewMonitorWithConfig(50, 10*time.Minute)
+	now := time.Now()
+
+	assert.Zero(t, m.EstimatedRecovery("Unknown"))
+
+	m.RecordOutcome("HealthyProc", model.Approved)
+	assert.Zero(t, m.EstimatedRecovery("HealthyProc"))
+
+	// Only failures: the circuit closes once the newest one ages out
+	m.RecordOutcomes([]OutcomeRecord{
+		{ProcessorName: "BadProc", Code: model.ProcessorError, Timestamp: now.Add(-9 * time.Minute)},
+		{ProcessorName: "BadProc", Code: model.ProcessorError, Timestamp: now.Add(-5 * time.Minute)},
+	})
+	assert.InDelta(t, 5*time.Minute, m.EstimatedRecovery("BadProc"), float64(time.Second))
+
+	// 1/6 approved is open; ageing out the oldest failure reaches 1/5 = threshold
+	m.RecordOutcomes([]OutcomeRecord{
+		{ProcessorName: "AgingProc", Code: model.ProcessorError, Timestamp: now.Add(-9*time.Minute - 50*time.Second)},
+		{ProcessorName: "AgingProc", Code: model.ProcessorError, Timestamp: now.Add(-8 * time.Minute)},
+		{ProcessorName: "AgingProc", Code: model.ProcessorError, Timestamp: now.Add(-8 * time.Minute)},
+		{ProcessorName: "AgingProc", Code: model.ProcessorError, Timestamp: now.Add(-8 * time.Minute)},
+		{ProcessorName: "AgingProc", Code: model.ProcessorError, Timestamp: now},
+		{ProcessorName: "AgingProc", Code: model.Approved, Timestamp: now},
+	})
+	require.True(t, m.IsCircuitOpen("AgingProc"))
+	assert.InDelta(t, 10*time.Second, m.EstimatedRecovery("AgingProc"), float64(time.Second))
+}
+
+func TestMonitor_ExcludeBusinessDeclines(t *testing.T) {
+	record := func(m *Monitor) {
+		// Mostly customer-caused declines for one, mostly timeouts for the other
+		for i := 0; i < 10; i++ {
+			declined, timedOut := model.DeclinedInsufficientFunds, model.Timeout
+			if i%5 == 0 {
+				declined, timedOut = model.Approved, model.Approved
+			}
+			if i == 7 {
+				declined = model.DeclinedFraud
+			}
+			m.RecordOutcome("ProcDeclines", declined)
+			m.RecordOutcome("ProcTimeouts", timedOut)
+		}
+	}
+
+	tests := []struct {
+		name             string
+		exclude          bool
+		wantDeclines     Status
+		wantDeclineScore float64
+	}{
+		{"declines count by default", false, StatusDegraded, 0.2},
+		{"declines excluded", true, StatusHealthy, 1.0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMonitorWithConfig(50, 10*time.Minute)
+			m.SetExcludeBusinessDeclines(tt.exclude)
+			record(m)
+
+			declines := m.GetHealth("ProcDeclines")
+			assert.Equal(t, tt.wantDeclines, declines.Status)
+			assert.InDelta(t, tt.wantDeclineScore, declines.HealthScore, 0.001)
+
+			timeouts := m.GetHealth("ProcTimeouts")
+			assert.Equal(t, StatusDegraded, timeouts.Status, "processor-side failures always count")
+			assert.InDelta(t, 0.2, timeouts.HealthScore, 0.001)
+			assert.Equal(t, 10, timeouts.TotalRecent)
+		})
+	}
+}
+
+func TestMonitor_ExcludeBusinessDeclinesRescoresRecordedOutcomes(t *testing.T) {
+	m := NewMonitorWithConfig(50, 10*time.Minute)
+	var changes []StatusChange
+	m.OnStatusChange(func(c StatusChange) { changes = append(changes, c) })
+	for i := 0; i < 5; i++ {
+		m.RecordOutcome("Proc", model.DeclinedInsufficientFunds)
+	}
+	m.RecordOutcome("Proc", model.Approved)
+	require.Equal(t, StatusOpen, m.GetHealth("Proc").Status)
+
+	m.SetExcludeBusinessDeclines(true)
+	h := m.GetHealth("Proc")
+	assert.Equal(t, StatusHealthy, h.Status)
+	assert.Equal(t, 1, h.TotalRecent)
+	require.NotEmpty(t, changes)
+	assert.Equal(t, StatusHealthy, changes[len(changes)-1].To)
+}
+
+func TestMonitor_RecordOutcomeAt(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name         string
+		backfill     []time.Duration // how long ago each failure happened
+		wantRecent   int
+		wantScore    float64
+		wantLifetime int64
+	}{
+		{"outcomes inside the window count", []time.Duration{time.Minute, 5 * time.Minute}, 3, 1.0 / 3, 3},
+		{"outcomes older than the window are pruned", []time.Duration{11 * time.Minute, time.Hour}, 1, 1.0, 3},
+		{"mixed ages keep only the recent ones", []time.Duration{2 * time.Minute, 20 * time.Minute}, 2, 0.5, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMonitorWithConfig(50, 10*time.Minute)
+			m.RecordOutcome("Proc", model.Approved)
+			for _, ago := range tt.backfill {
+				m.RecordOutcomeAt("Proc", model.ProcessorError, now.Add(-ago))
+			}
+
+			h := m.GetHealth("Proc")
+			assert.Equal(t, tt.wantRecent, h.TotalRecent)
+			assert.InDelta(t, tt.wantScore, h.HealthScore, 0.001)
+			assert.Equal(t, tt.wantLifetime, h.TotalProcessed)
+			assert.Equal(t, 1, h.SuccessStreak, "backfilled outcomes don't break the current streak")
+		})
+	}
+}
+
+func TestMonitor_RecordOutcomeAtKeepsWindowOrdered(t *testing.T) {
+	// With a window of 2, the two most recent outcomes count even when an older
+	// one is backfilled after them
+	now := time.Now()
+	m := NewMonitorWithConfig(2, 10*time.Minute)
+	m.RecordOutcomeAt("Proc", model.Approved, now.Add(-time.Minute))
+	m.RecordOutcomeAt("Proc", model.Approved, now)
+	m.RecordOutcomeAt("Proc", model.ProcessorError, now.Add(-5*time.Minute))
+
+	h := m.GetHealth("Proc")
+	assert.Equal(t, 2, h.TotalRecent)
+	assert.Equal(t, 1.0, h.HealthScore)
+}
+
+func TestMonitor_ApprovalDrift(t *testing.T) {
+	record := func(m *Monitor, name string, approved, total int) {
+		for i := 0; i < total; i++ {
+			code := model.ProcessorError
+			if i < approved {
+				code = model.Approved
+			}
+			m.RecordOutcome(name, code)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		approved  int
+		total     int
+		wantDrift bool
+	}{
+		{"far below target", 8, 20, true},
+		{"within tolerance", 16, 20, false},
+		{"too few samples", 2, 5, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMonitorWithConfig(50, 10*time.Minute)
+			m.SetApprovalTarget("ProcA", 0.85)
+			record(m, "ProcA", tt.approved, tt.total)
+
+			h := m.GetHealth("ProcA")
+			assert.Equal(t, 0.85, h.ExpectedScore)
+			assert.Equal(t, tt.wantDrift, h.Drift)
+		})
+	}
+
+	m := NewMonitorWithConfig(50, 10*time.Minute)
+	record(m, "NoTarget", 0, 20)
+	assert.Zero(t, m.GetHealth("NoTarget").ExpectedScore)
+	assert.False(t, m.GetHealth("NoTarget").Drift)
+
+	m.SetApprovalTarget("NoTarget", 0.85)
+	assert.True(t, m.GetHealth("NoTarget").Drift)
+	m.SetDriftTolerance(1)
+	assert.False(t, m.GetHealth("NoTarget").Drift)
+}
+
 func TestMonitor_GetAllHealth(t *testing.T) {
 	m := NewMonitorWithConfig(50, 10*time.Minute)
 
@@ -243,6 +455,22 @@ func TestMonitor_GetAllHealth(t *testing.T) {
 	assert.True(t, names["ProcC"])
 }
 
+func TestMonitor_GetAllHealthSortedByName(t *testing.T) {
+	m := NewMonitorWithConfig(50, 10*time.Minute)
+	for _, name := range []string{"PixPay", "CardMax", "GlobalPay", "PayFlow", "AlphaPay"} {
+		m.RecordOutcome(name, model.Approved)
+	}
+
+	want := []string{"AlphaPay", "CardMax", "GlobalPay", "PayFlow", "PixPay"}
+	for i := 0; i < 20; i++ {
+		var names []string
+		for _, h := range m.GetAllHealth() {
+			names = append(names, h.ProcessorName)
+		}
+		require.Equal(t, want, names)
+	}
+}
+
 func TestMonitor_RecoveryAfterDegradation(t *testing.T) {
 	m := NewMonitorWithConfig(10, 10*time.Minute)
 
@@ -310,3 +538,284 @@ func TestMonitor_ConcurrentAccess(t *testing.T) {
 	h := m.GetHealth("ConcProc")
 	assert.Equal(t, 50, h.TotalRecent)
 }
+
+func TestMonitor_Reset(t *testing.T) {
+	m := NewMonitorWithConfig(50, 10*time.Minute)
+	for i := 0; i < 10; i++ {
+		m.RecordOutcome("ProcA", model.ProcessorError)
+	}
+	m.RecordOutcome("ProcB", model.Approved)
+	require.True(t, m.IsCircuitOpen("ProcA"))
+
+	assert.Equal(t, 2, m.Reset())
+	assert.Empty(t, m.GetAllHealth())
+	assert.False(t, m.IsCircuitOpen("ProcA"))
+	assert.Equal(t, 0, m.GetHealth("ProcA").TotalRecent)
+}
+
+func TestMonitor_LifetimeCountersSurviveWindowExpiry(t *testing.T) {
+	m := NewMonitorWithConfig(50, 100*time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+		m.RecordOutcome("Proc", model.Approved)
+	}
+	m.RecordOutcome("Proc", model.ProcessorError)
+
+	h := m.GetHealth("Proc")
+	assert.Equal(t, 4, h.TotalRecent)
+	assert.Equal(t, int64(4), h.TotalProcessed)
+	assert.Equal(t, int64(3), h.TotalApproved)
+
+	// Let the window expire: windowed counts reset, lifetime counters do not
+	time.Sleep(150 * time.Millisecond)
+	h = m.GetHealth("Proc")
+	assert.Equal(t, 0, h.TotalRecent)
+	assert.Equal(t, int64(4), h.TotalProcessed)
+	assert.Equal(t, int64(3), h.TotalApproved)
+
+	m.RecordOutcome("Proc", model.Approved)
+	m.RecordOutcome("Proc", model.SoftDecline)
+	h = m.GetHealth("Proc")
+	assert.Equal(t, 2, h.TotalRecent)
+	assert.Equal(t, 1, h.ApprovedCount)
+	assert.Equal(t, int64(6), h.TotalProcessed)
+	assert.Equal(t, int64(4), h.TotalApproved)
+}
+
+func TestMonitor_LifetimeCountersBeyondWindowSize(t *testing.T) {
+	m := NewMonitorWithConfig(5, 10*time.Minute)
+	for i := 0; i < 20; i++ {
+		m.RecordOutcome("Proc", model.Approved)
+	}
+
+	h := m.GetHealth("Proc")
+	assert.Equal(t, 5, h.TotalRecent)
+	assert.Equal(t, int64(20), h.TotalProcessed)
+	assert.Equal(t, int64(20), h.TotalApproved)
+}
+
+func TestMonitor_LastFailure(t *testing.T) {
+	m := NewMonitorWithConfig(50, 10*time.Minute)
+
+	h := m.GetHealth("ProcA")
+	assert.Empty(t, h.LastFailureCode)
+	assert.Nil(t, h.LastFailureAt)
+
+	m.RecordResponse("ProcA", model.ProcessorResponse{Code: model.Timeout, Message: "request timed out"})
+	before := time.Now()
+	m.RecordResponse("ProcA", model.ProcessorResponse{Code: model.ProcessorError, Message: "internal processor error"})
+	m.RecordResponse("ProcA", model.ProcessorResponse{Code: model.Approved, Message: "transaction approved"})
+
+	h = m.GetHealth("ProcA")
+	assert.Equal(t, model.ProcessorError, h.LastFailureCode, "approvals don't overwrite the last failure")
+	assert.Equal(t, "internal processor error", h.LastFailureMessage)
+	require.NotNil(t, h.LastFailureAt)
+	assert.False(t, h.LastFailureAt.Before(before))
+
+	m.Reset()
+	assert.Empty(t, m.GetHealth("ProcA").LastFailureCode)
+}
+
+func TestMonitor_Streaks(t *testing.T) {
+	m := NewMonitorWithConfig(50, 10*time.Minute)
+
+	steps := []struct {
+		code         model.ResponseCode
+		wantSuccess  int
+		wantFailures int
+	}{
+		{model.Approved, 1, 0},
+		{model.Approved, 2, 0},
+		{model.SoftDecline, 0, 1},
+		{model.Timeout, 0, 2},
+		{model.ProcessorError, 0, 3},
+		{model.Approved, 1, 0},
+		{model.DeclinedFraud, 0, 1},
+		{model.Approved, 1, 0},
+		{model.Approved, 2, 0},
+		{model.Approved, 3, 0},
+	}
+	for i, step := range steps {
+		m.RecordOutcome("ProcA", step.code)
+		h := m.GetHealth("ProcA")
+		assert.Equal(t, step.wantSuccess, h.SuccessStreak, "step %d success streak", i)
+		assert.Equal(t, step.wantFailures, h.FailureStreak, "step %d failure streak", i)
+	}
+
+	other := m.GetHealth("ProcB")
+	assert.Zero(t, other.SuccessStreak, "streaks are per processor")
+	assert.Zero(t, other.FailureStreak)
+
+	m.Reset()
+	assert.Zero(t, m.GetHealth("ProcA").SuccessStreak)
+}
+
+func TestMonitor_StreaksOutliveWindow(t *testing.T) {
+	m := NewMonitorWithConfig(2, 10*time.Minute)
+	for i := 0; i < 5; i++ {
+		m.RecordOutcome("ProcA", model.ProcessorError)
+	}
+	h := m.GetHealth("ProcA")
+	assert.Equal(t, 2, h.TotalRecent)
+	assert.Equal(t, 5, h.FailureStreak, "streaks are not capped by the window size")
+}
+
+func TestMonitor_LatencyHistogram(t *testing.T) {
+	m := NewMonitorWithConfig(50, 10*time.Minute)
+	latencies := []time.Duration{
+		10 * time.Millisecond, 49 * time.Millisecond,
+		50 * time.Millisecond, 99 * time.Millisecond, 75 * time.Millisecond,
+		100 * time.Millisecond,
+		250 * time.Millisecond, 2 * time.Second,
+	}
+	for _, l := range latencies {
+		m.RecordResponse("ProcA", model.ProcessorResponse{Code: model.Approved, Latency: l})
+	}
+	m.RecordOutcome("ProcA", model.Approved) // no latency: not bucketed
+
+	h := m.GetHealth("ProcA")
+	require.NotNil(t, h.LatencyHistogram)
+	assert.Equal(t, LatencyHistogram{Under50ms: 2, Under100ms: 3, Under250ms: 1, Over250ms: 2}, *h.LatencyHistogram)
+
+	assert.Nil(t, m.GetHealth("ProcB").LatencyHistogram, "omitted without latency samples")
+}
+
+func TestMonitor_LatencyHistogramUsesActiveWindow(t *testing.T) {
+	m := NewMonitorWithConfig(3, 10*time.Minute)
+	m.RecordResponse("ProcA", model.ProcessorResponse{Code: model.Approved, Latency: 500 * time.Millisecond})
+	for i := 0; i < 3; i++ {
+		m.RecordResponse("ProcA", model.ProcessorResponse{Code: model.Approved, Latency: 20 * time.Millisecond})
+	}
+
+	h := m.GetHealth("ProcA")
+	require.NotNil(t, h.LatencyHistogram)
+	assert.Equal(t, LatencyHistogram{Under50ms: 3}, *h.LatencyHistogram, "outcomes evicted from the window are not counted")
+}
+
+func TestMonitor_HealthCache(t *testing.T) {
+	m := NewMonitorWithConfig(10, 40*time.Millisecond)
+	m.SetCacheTTL(200 * time.Millisecond)
+
+	m.RecordOutcome("ProcA", model.Approved)
+	assert.Equal(t, 1, m.GetHealth("ProcA").TotalRecent)
+
+	// Recording invalidates the cached entry immediately
+	m.RecordOutcome("ProcA", model.ProcessorError)
+	h := m.GetHealth("ProcA")
+	assert.Equal(t, 2, h.TotalRecent)
+	assert.Equal(t, 0.5, h.HealthScore)
+
+	// Outcomes aging out of the window are only seen once the entry expires
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(t, 2, m.GetHealth("ProcA").TotalRecent, "served from cache within the TTL")
+	assert.Eventually(t, func() bool {
+		return m.GetHealth("ProcA").TotalRecent == 0
+	}, time.Second, 10*time.Millisecond, "cache refreshes after the TTL")
+
+	// Reset clears cached health
+	m.RecordOutcome("ProcA", model.Approved)
+	m.GetHealth("ProcA")
+	m.Reset()
+	assert.Equal(t, 0, m.GetHealth("ProcA").TotalRecent)
+}
+
+func TestMonitor_HealthCacheReturnsCopies(t *testing.T) {
+	m := NewMonitorWithConfig(10, 10*time.Minute)
+	m.SetCacheTTL(time.Minute)
+	m.RecordResponse("ProcA", model.ProcessorResponse{Code: model.Timeout, Latency: 10 * time.Millisecond})
+
+	h := m.GetHealth("ProcA")
+	require.NotNil(t, h.LatencyHistogram)
+	h.LatencyHistogram.Under50ms = 99
+	assert.Equal(t, 1, m.GetHealth("ProcA").LatencyHistogram.Under50ms)
+}
+
+func benchmarkGetHealth(b *testing.B, ttl time.Duration) {
+	m := NewMonitor()
+	m.SetCacheTTL(ttl)
+	for i := 0; i < config.HealthWindowSize; i++ {
+		m.RecordResponse("ProcA", model.ProcessorResponse{Code: model.Approved, Latency: time.Duration(i) * time.Millisecond})
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			m.GetHealth("ProcA")
+		}
+	})
+}
+
+func BenchmarkGetHealth_Uncached(b *testing.B) {
+	benchmarkGetHealth(b, 0)
+}
+
+func BenchmarkGetHealth_Cached(b *testing.B) {
+	benchmarkGetHealth(b, 100*time.Millisecond)
+}
+
+func TestMonitor_OnStatusChange(t *testing.T) {
+	m := NewMonitorWithConfig(4, 10*time.Minute)
+	var changes []StatusChange
+	m.OnStatusChange(func(c StatusChange) {
+		// Hooks run outside the lock and may read health
+		assert.Equal(t, c.To, m.GetHealth(c.ProcessorName).Status)
+		changes = append(changes, c)
+	})
+
+	m.RecordOutcome("ProcA", model.Approved)
+	assert.Empty(t, changes, "new processors start healthy")
+
+	m.RecordOutcomes([]OutcomeRecord{
+		{ProcessorName: "ProcA", Code: model.ProcessorError, Timestamp: time.Now()},
+		{ProcessorName: "ProcA", Code: model.ProcessorError, Timestamp: time.Now()},
+	})
+	m.RecordOutcome("ProcA", model.ProcessorError)
+	m.RecordOutcome("ProcA", model.ProcessorError)
+	m.RecordOutcome("ProcA", model.Approved)
+	m.RecordOutcome("ProcA", model.Approved)
+
+	assert.Equal(t, []StatusChange{
+		{ProcessorName: "ProcA", From: StatusHealthy, To: StatusDegraded, HealthScore: 1.0 / 3},
+		{ProcessorName: "ProcA", From: StatusDegraded, To: StatusOpen, HealthScore: 0},
+		{ProcessorName: "ProcA", From: StatusOpen, To: StatusDegraded, HealthScore: 0.25},
+		{ProcessorName: "ProcA", From: StatusDegraded, To: StatusHealthy, HealthScore: 0.5},
+	}, changes)
+}
+
+func TestMonitor_RecoveredAt(t *testing.T) {
+	m := NewMonitorWithConfig(4, 10*time.Minute)
+	for i := 0; i < 4; i++ {
+		m.RecordOutcome("ProcA", model.ProcessorError)
+	}
+	m.RecordOutcome("ProcA", model.Approved)
+	_, ok := m.RecoveredAt("ProcA")
+	assert.False(t, ok, "recoveries are only tracked once enabled")
+
+	m.TrackRecoveries()
+	for i := 0; i < 4; i++ {
+		m.RecordOutcome("ProcA", model.ProcessorError)
+	}
+	require.Equal(t, StatusOpen, m.GetHealth("ProcA").Status)
+	_, ok = m.RecoveredAt("ProcA")
+	assert.False(t, ok, "opening is not a recovery")
+
+	before := time.Now()
+	m.RecordOutcome("ProcA", model.Approved)
+	at, ok := m.RecoveredAt("ProcA")
+	require.True(t, ok)
+	assert.False(t, at.Before(before))
+
+	m.Reset()
+	_, ok = m.RecoveredAt("ProcA")
+	assert.False(t, ok)
+}
+
+func TestMonitor_AvgLatency(t *testing.T) {
+	m := NewMonitorWithConfig(3, 10*time.Minute)
+	m.RecordOutcome("ProcA", model.Approved)
+	assert.Zero(t, m.GetHealth("ProcA").AvgLatencyMs, "untimed outcomes are ignored")
+
+	for _, ms := range []int{500, 100, 200, 300} {
+		m.RecordResponse("ProcA", model.ProcessorResponse{Code: model.Approved, Latency: time.Duration(ms) * time.Millisecond})
+	}
+	assert.InDelta(t, 200.0, m.GetHealth("ProcA").AvgLatencyMs, 0.001, "averages the active window only")
+}
@@ -0,0 +1,115 @@
+package health
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/config"
+)
+
+// WebhookConfig configures a WebhookNotifier.
+type WebhookConfig struct {
+	// URL receives a POST for every status change.
+	URL string
+	// Retries is how many times a failed delivery (transport error or non-2xx
+	// response) is retried before it is dropped.
+	Retries int
+	// Backoff is waited before the first retry and doubles for each one after.
+	Backoff time.Duration
+	// Timeout bounds each delivery.
+	Timeout time.Duration
+}
+
+// DefaultWebhookConfig returns a webhook config for url with the retry settings
+// from the config package.
+func DefaultWebhookConfig(url string) WebhookConfig {
+	return WebhookConfig{
+		URL:     url,
+		Retries: config.StatusWebhookRetries,
+		Backoff: time.Duration(config.StatusWebhookBackoffMillis) * time.Millisecond,
+		Timeout: time.Duration(config.StatusWebhookTimeoutMillis) * time.Millisecond,
+	}
+}
+
+// StatusWebhookPayload is the JSON body POSTed for a status change.
+type StatusWebhookPayload struct {
+	Processor string    `json:"processor"`
+	OldStatus Status    `json:"old_status"`
+	NewStatus Status    `json:"new_status"`
+	Score     float64   `json:"score"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// WebhookNotifier POSTs status changes to a URL so ops tooling can react to
+// circuit trips. Register Notify as a status-change hook:
+//
+//	monitor.OnStatusChange(health.NewWebhookNotifier(cfg).Notify)
+//
+// Deliveries run in the background, so a slow or failing endpoint never holds up
+// outcome recording; a delivery still failing after its retries is logged and dropped.
+type WebhookNotifier struct {
+	cfg    WebhookConfig
+	client *http.Client
+}
+
+// NewWebhookNotifier creates a notifier for cfg. Negative retries count as none.
+func NewWebhookNotifier(cfg WebhookConfig) *WebhookNotifier {
+	cfg.Retries = max(cfg.Retries, 0)
+	return &WebhookNotifier{cfg: cfg, client: &http.Client{Timeout: cfg.Timeout}}
+}
+
+// Notify sends c to the webhook asynchronously.
+func (n *WebhookNotifier) Notify(c StatusChange) {
+	body, err := json.Marshal(StatusWebhookPayload{
+		Processor: c.ProcessorName,
+		OldStatus: c.From,
+		NewStatus: c.To,
+		Score:     c.HealthScore,
+		Timestamp: time.Now(),
+	})
+	if err != nil {
+		slog.Error("status_webhook_failed", "processor", c.ProcessorName, "error", err)
+		return
+	}
+	go n.deliver(c, body)
+}
+
+// deliver POSTs body, retrying failures with backoff.
+func (n *WebhookNotifier) deliver(c StatusChange, body []byte) {
+	backoff := n.cfg.Backoff
+	err := n.post(body)
+	for retry := 1; err != nil && retry <= n.cfg.Retries; retry++ {
+		slog.Warn("status_webhook_retrying",
+			"processor", c.ProcessorName,
+			"retry", retry,
+			"error", err,
+		)
+		time.Sleep(backoff)
+		backoff *= 2
+		err = n.post(body)
+	}
+	if err != nil {
+		slog.Error("status_webhook_failed",
+			"processor", c.ProcessorName,
+			"from", c.From,
+			"to", c.To,
+			"error", err,
+		)
+	}
+}
+
+func (n *WebhookNotifier) post(body []byte) error {
+	resp, err := n.client.Post(n.cfg.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
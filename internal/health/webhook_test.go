@@ -0,0 +1,79 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookNotifier_OpenTransition(t *testing.T) {
+	payloads := make(chan StatusWebhookPayload, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		var p StatusWebhookPayload
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&p))
+		payloads <- p
+	}))
+	defer srv.Close()
+
+	m := NewMonitorWithConfig(4, 10*time.Minute)
+	m.OnStatusChange(NewWebhookNotifier(WebhookConfig{URL: srv.URL, Timeout: time.Second}).Notify)
+	before := time.Now()
+	for i := 0; i < 4; i++ {
+		m.RecordOutcome("ProcA", model.ProcessorError)
+	}
+
+	select {
+	case p := <-payloads:
+		assert.Equal(t, "ProcA", p.Processor)
+		assert.Equal(t, StatusHealthy, p.OldStatus)
+		assert.Equal(t, StatusOpen, p.NewStatus)
+		assert.Equal(t, 0.0, p.Score)
+		assert.False(t, p.Timestamp.Before(before.Truncate(time.Second)))
+	case <-time.After(2 * time.Second):
+		t.Fatal("webhook not called")
+	}
+	select {
+	case p := <-payloads:
+		t.Fatalf("unexpected second webhook: %+v", p)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestWebhookNotifier_RetriesFailures(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  int32
+		retries   int
+		wantCalls int32
+	}{
+		{"succeeds after retrying", 2, 3, 3},
+		{"gives up after the last retry", 10, 2, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) <= tt.failures {
+					w.WriteHeader(http.StatusInternalServerError)
+				}
+			}))
+			defer srv.Close()
+
+			n := NewWebhookNotifier(WebhookConfig{URL: srv.URL, Retries: tt.retries, Backoff: time.Millisecond, Timeout: time.Second})
+			n.Notify(StatusChange{ProcessorName: "ProcA", From: StatusDegraded, To: StatusOpen, HealthScore: 0.1})
+
+			require.Eventually(t, func() bool { return calls.Load() == tt.wantCalls }, 2*time.Second, time.Millisecond)
+			time.Sleep(20 * time.Millisecond)
+			assert.Equal(t, tt.wantCalls, calls.Load(), "no calls past the retry budget")
+		})
+	}
+}
@@ -0,0 +1,73 @@
+// Package logging creates the slog logger, in JSON or text at a minimum level read
+// from LOG_FORMAT and LOG_LEVEL.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/config"
+)
+
+// Environment variables selecting the log output.
+const (
+	EnvFormat = "LOG_FORMAT"
+	EnvLevel  = "LOG_LEVEL"
+)
+
+// Log output formats.
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Config holds the log output format and minimum level.
+type Config struct {
+	Format string
+	Level  slog.Level
+}
+
+// DefaultConfig returns the logging settings defined in the config package.
+func DefaultConfig() Config {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(config.LogLevel)); err != nil {
+		level = slog.LevelInfo
+	}
+	return Config{Format: config.LogFormat, Level: level}
+}
+
+// ConfigFromEnv returns DefaultConfig with LOG_FORMAT (json or text) and
+// LOG_LEVEL (debug, info, warn or error) applied.
+func ConfigFromEnv() (Config, error) {
+	return configFromLookup(os.LookupEnv)
+}
+
+func configFromLookup(lookup func(string) (string, bool)) (Config, error) {
+	cfg := DefaultConfig()
+	if format, ok := lookup(EnvFormat); ok && format != "" {
+		switch format {
+		case FormatJSON, FormatText:
+			cfg.Format = format
+		default:
+			return Config{}, fmt.Errorf("%s must be %s or %s, got %q", EnvFormat, FormatJSON, FormatText, format)
+		}
+	}
+	if level, ok := lookup(EnvLevel); ok && level != "" {
+		if err := cfg.Level.UnmarshalText([]byte(level)); err != nil {
+			return Config{}, fmt.Errorf("parse %s: %w", EnvLevel, err)
+		}
+	}
+	return cfg, nil
+}
+
+// New creates a logger writing to w in the configured format, dropping records
+// below the configured level.
+func New(w io.Writer, cfg Config) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: cfg.Level}
+	if cfg.Format == FormatText {
+		return slog.New(slog.NewTextHandler(w, opts))
+	}
+	return slog.New(slog.NewJSONHandler(w, opts))
+}
@@ -0,0 +1,100 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    Config
+		wantErr bool
+	}{
+		{
+			name: "defaults when unset",
+			env:  map[string]string{},
+			want: Config{Format: FormatJSON, Level: slog.LevelInfo},
+		},
+		{
+			name: "text at debug",
+			env:  map[string]string{EnvFormat: "text", EnvLevel: "debug"},
+			want: Config{Format: FormatText, Level: slog.LevelDebug},
+		},
+		{
+			name: "level is case-insensitive",
+			env:  map[string]string{EnvLevel: "WARN"},
+			want: Config{Format: FormatJSON, Level: slog.LevelWarn},
+		},
+		{
+			name:    "unknown format",
+			env:     map[string]string{EnvFormat: "xml"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown level",
+			env:     map[string]string{EnvLevel: "verbose"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lookup := func(key string) (string, bool) {
+				v, ok := tt.env[key]
+				return v, ok
+			}
+			cfg, err := configFromLookup(lookup)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cfg)
+		})
+	}
+}
+
+func TestNew_AppliesFormatAndLevel(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    Config
+		isJSON bool
+	}{
+		{"json", Config{Format: FormatJSON, Level: slog.LevelWarn}, true},
+		{"text", Config{Format: FormatText, Level: slog.LevelWarn}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := New(&buf, tt.cfg)
+
+			logger.Info("below_level")
+			logger.Warn("at_level", "processor", "PayFlow")
+
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			require.Len(t, lines, 1, "records below the level are dropped")
+			assert.NotContains(t, lines[0], "below_level")
+
+			var entry map[string]any
+			if tt.isJSON {
+				require.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+				assert.Equal(t, "at_level", entry["msg"])
+				assert.Equal(t, "PayFlow", entry["processor"])
+				return
+			}
+			assert.Error(t, json.Unmarshal([]byte(lines[0]), &entry))
+			assert.Contains(t, lines[0], "level=WARN")
+			assert.Contains(t, lines[0], "msg=at_level")
+			assert.Contains(t, lines[0], "processor=PayFlow")
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
+
+// TimestampLayout is the wire format for timestamps: RFC3339 in UTC with millisecond precision.
+const TimestampLayout = "2006-01-02T15:04:05.000Z07:00"
+
+// The wire types below are the API's read model. Internal types keep time.Time and
+// time.Duration; on the wire, latencies are fractional milliseconds and timestamps
+// are RFC3339 strings. Each wire type embeds an alias of its model type, which has
+// the same fields but none of the methods, and overrides only the time fields, so
+// every other field is serialized from the model type's own tags.
+
+type (
+	processorResponseAlias ProcessorResponse
+	attemptAlias           Attempt
+	skipInfoAlias          SkipInfo
+	paymentResultAlias     PaymentResult
+)
+
+type processorResponseJSON struct {
+	processorResponseAlias
+	Timestamp string  `json:"timestamp"`
+	LatencyMs float64 `json:"latency_ms"`
+}
+
+type attemptJSON struct {
+	attemptAlias
+	Timestamp string `json:"timestamp"`
+}
+
+type skipInfoJSON struct {
+	skipInfoAlias
+	Timestamp string `json:"timestamp"`
+}
+
+type paymentResultJSON struct {
+	paymentResultAlias
+	TotalLatencyMs float64 `json:"total_latency_ms"`
+}
+
+// MarshalJSON emits latency in milliseconds and the timestamp in RFC3339.
+func (r ProcessorResponse) MarshalJSON() ([]byte, error) {
+	return json.Marshal(processorResponseJSON{
+		processorResponseAlias: processorResponseAlias(r),
+		Timestamp:              formatTimestamp(r.Timestamp),
+		LatencyMs:              durationToMillis(r.Latency),
+	})
+}
+
+// UnmarshalJSON parses the wire format produced by MarshalJSON.
+func (r *ProcessorResponse) UnmarshalJSON(data []byte) error {
+	var w processorResponseJSON
+	if err := json.Unmarshal(data, &w); err != nil {
+		return err
+	}
+	ts, err := parseTimestamp(w.Timestamp)
+	if err != nil {
+		return err
+	}
+	*r = ProcessorResponse(w.processorResponseAlias)
+	r.Timestamp = ts
+	r.Latency = millisToDuration(w.LatencyMs)
+	return nil
+}
+
+// MarshalJSON emits the attempt timestamp in RFC3339.
+func (a Attempt) MarshalJSON() ([]byte, error) {
+	return json.Marshal(attemptJSON{
+		attemptAlias: attemptAlias(a),
+		Timestamp:    formatTimestamp(a.Timestamp),
+	})
+}
+
+// UnmarshalJSON parses the wire format produced by MarshalJSON.
+func (a *Attempt) UnmarshalJSON(data []byte) error {
+	var w attemptJSON
+	if err := json.Unmarshal(data, &w); err != nil {
+		return err
+	}
+	ts, err := parseTimestamp(w.Timestamp)
+	if err != nil {
+		return err
+	}
+	*a = Attempt(w.attemptAlias)
+	a.Timestamp = ts
+	return nil
+}
+
+// MarshalJSON emits the skip timestamp in RFC3339.
+func (s SkipInfo) MarshalJSON() ([]byte, error) {
+	return json.Marshal(skipInfoJSON{
+		skipInfoAlias: skipInfoAlias(s),
+		Timestamp:     formatTimestamp(s.Timestamp),
+	})
+}
+
+// UnmarshalJSON parses the wire format produced by MarshalJSON.
+func (s *SkipInfo) UnmarshalJSON(data []byte) error {
+	var w skipInfoJSON
+	if err := json.Unmarshal(data, &w); err != nil {
+		return err
+	}
+	ts, err := parseTimestamp(w.Timestamp)
+	if err != nil {
+		return err
+	}
+	*s = SkipInfo(w.skipInfoAlias)
+	s.Timestamp = ts
+	return nil
+}
+
+// MarshalJSON emits the total latency in milliseconds.
+func (r PaymentResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(paymentResultJSON{
+		paymentResultAlias: paymentResultAlias(r),
+		TotalLatencyMs:     durationToMillis(r.TotalLatency),
+	})
+}
+
+// UnmarshalJSON parses the wire format produced by MarshalJSON.
+func (r *PaymentResult) UnmarshalJSON(data []byte) error {
+	var w paymentResultJSON
+	if err := json.Unmarshal(data, &w); err != nil {
+		return err
+	}
+	*r = PaymentResult(w.paymentResultAlias)
+	r.TotalLatency = millisToDuration(w.TotalLatencyMs)
+	return nil
+}
+
+func formatTimestamp(t time.Time) string {
+	return t.UTC().Format(TimestampLayout)
+}
+
+func parseTimestamp(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q: %w", s, err)
+	}
+	return t, nil
+}
+
+func durationToMillis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func millisToDuration(ms float64) time.Duration {
+	return time.Duration(math.Round(ms * float64(time.Millisecond)))
+}
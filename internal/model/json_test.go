@@ -0,0 +1,176 @@
+package model
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessorResponse_JSONShape(t *testing.T) {
+	resp := ProcessorResponse{
+		ProcessorName: "PayFlow",
+		Code:          Approved,
+		Message:       "transaction approved",
+		Timestamp:     time.Date(2024, 1, 15, 10, 30, 0, 123456789, time.FixedZone("BRT", -3*3600)),
+		Latency:       125500 * time.Microsecond,
+	}
+
+	data, err := json.Marshal(resp)
+	require.NoError(t, err)
+
+	var raw map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &raw))
+	assert.Equal(t, 125.5, raw["latency_ms"])
+	assert.Equal(t, "2024-01-15T13:30:00.123Z", raw["timestamp"], "timestamps are UTC RFC3339 with milliseconds")
+	assert.NotContains(t, raw, "latency")
+}
+
+func TestPaymentResult_JSONShape(t *testing.T) {
+	ts := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	resp := ProcessorResponse{ProcessorName: "CardMax", Code: Approved, Timestamp: ts, Latency: 80 * time.Millisecond}
+	result := PaymentResult{
+		TransactionID: "tx-001",
+		Status:        StatusApproved,
+		Attempts: []Attempt{
+			{ProcessorName: "CardMax", Response: resp, AttemptNumber: 1, Timestamp: ts},
+		},
+		FinalResponse: &resp,
+		TotalLatency:  1500 * time.Millisecond,
+	}
+
+	data, err := json.Marshal(result)
+	require.NoError(t, err)
+
+	var raw map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &raw))
+	assert.Equal(t, 1500.0, raw["total_latency_ms"])
+	assert.NotContains(t, raw, "total_latency")
+
+	attempts := raw["attempts"].([]interface{})
+	require.Len(t, attempts, 1)
+	attempt := attempts[0].(map[string]interface{})
+	assert.Equal(t, "2024-01-15T10:30:00.000Z", attempt["timestamp"])
+	assert.Equal(t, 80.0, attempt["response"].(map[string]interface{})["latency_ms"])
+	assert.Equal(t, 80.0, raw["final_response"].(map[string]interface{})["latency_ms"])
+}
+
+func TestPaymentResult_JSONRoundTrip(t *testing.T) {
+	ts := time.Date(2024, 1, 15, 10, 30, 0, 250*int(time.Millisecond), time.UTC)
+	resp := ProcessorResponse{ProcessorName: "PixPay", Code: SoftDecline, Message: "soft decline - try again", Timestamp: ts, Latency: 42 * time.Millisecond}
+	original := PaymentResult{
+		TransactionID: "tx-rt",
+		Status:        StatusExhaustedRetries,
+		Attempts: []Attempt{
+			{ProcessorName: "PixPay", Response: resp, RoutingReason: "primary", AttemptNumber: 1, TraceID: "abc", PaymentMethod: "pix", TimedOut: true, Timestamp: ts},
+		},
+		FinalResponse: &resp,
+		TotalLatency:  45 * time.Millisecond,
+		Message:       "retries exhausted after 1 attempt",
+	}
+
+	data, err := json.Marshal(original)
+	require.NoError(t, err)
+
+	var decoded PaymentResult
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, original.TotalLatency, decoded.TotalLatency)
+	require.Len(t, decoded.Attempts, 1)
+	assert.True(t, ts.Equal(decoded.Attempts[0].Timestamp))
+	assert.Equal(t, resp.Latency, decoded.Attempts[0].Response.Latency)
+	assert.Equal(t, "abc", decoded.Attempts[0].TraceID)
+	assert.True(t, decoded.Attempts[0].TimedOut)
+	assert.Equal(t, "pix", decoded.Attempts[0].PaymentMethod)
+	assert.Equal(t, original.Message, decoded.Message)
+	require.NotNil(t, decoded.FinalResponse)
+	assert.Equal(t, SoftDecline, decoded.FinalResponse.Code)
+}
+
+func TestPaymentResult_JSONRoundTripsRequest(t *testing.T) {
+	req := PaymentRequest{
+		TransactionID:      "tx-req",
+		Amount:             0,
+		Currency:           "MXN",
+		PaymentMethod:      "oxxo",
+		CustomerID:         "cust-9",
+		ExcludeProcessors:  []string{"PayFlow"},
+		PreferredProcessor: "CardMax",
+		AllowZeroAmount:    true,
+	}
+	data, err := json.Marshal(PaymentResult{TransactionID: req.TransactionID, Status: StatusApproved, Request: req})
+	require.NoError(t, err)
+
+	var raw map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &raw))
+	assert.Equal(t, "oxxo", raw["request"].(map[string]interface{})["payment_method"])
+
+	var decoded PaymentResult
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, req, decoded.Request)
+}
+
+func TestPaymentResult_JSONRoundTripsSkippedProcessors(t *testing.T) {
+	ts := time.Date(2024, 1, 15, 10, 30, 0, 500*int(time.Millisecond), time.UTC)
+	original := PaymentResult{
+		TransactionID: "tx-skip",
+		Status:        StatusApproved,
+		SkippedProcessors: []SkipInfo{
+			{ProcessorName: "CardMax", Reason: "circuit opened during the payment", Timestamp: ts},
+		},
+	}
+	data, err := json.Marshal(original)
+	require.NoError(t, err)
+
+	var raw map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &raw))
+	skipped := raw["skipped_processors"].([]interface{})
+	require.Len(t, skipped, 1)
+	skip := skipped[0].(map[string]interface{})
+	assert.Equal(t, "CardMax", skip["processor_name"])
+	assert.Equal(t, "2024-01-15T10:30:00.500Z", skip["timestamp"])
+
+	var decoded PaymentResult
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Len(t, decoded.SkippedProcessors, 1)
+	assert.Equal(t, "CardMax", decoded.SkippedProcessors[0].ProcessorName)
+	assert.Equal(t, original.SkippedProcessors[0].Reason, decoded.SkippedProcessors[0].Reason)
+	assert.True(t, ts.Equal(decoded.SkippedProcessors[0].Timestamp))
+
+	data, err = json.Marshal(PaymentResult{TransactionID: "tx-none"})
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "skipped_processors")
+}
+
+func TestPaymentResult_UnmarshalsLatencyMillis(t *testing.T) {
+	var decoded PaymentResult
+	body := `{"transaction_id":"tx-ms","status":"approved","total_latency_ms":12.5,"final_response":{"code":"approved","latency_ms":3}}`
+	require.NoError(t, json.Unmarshal([]byte(body), &decoded))
+
+	assert.Equal(t, 12500*time.Microsecond, decoded.TotalLatency)
+	require.NotNil(t, decoded.FinalResponse)
+	assert.Equal(t, 3*time.Millisecond, decoded.FinalResponse.Latency)
+}
+
+func TestWireTypes_SerializeEveryTaggedField(t *testing.T) {
+	for _, v := range []any{ProcessorResponse{}, Attempt{}, SkipInfo{}, PaymentResult{}} {
+		typ := reflect.TypeOf(v)
+		t.Run(typ.Name(), func(t *testing.T) {
+			data, err := json.Marshal(v)
+			require.NoError(t, err)
+			var raw map[string]interface{}
+			require.NoError(t, json.Unmarshal(data, &raw))
+
+			for i := 0; i < typ.NumField(); i++ {
+				name, opts, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+				if name == "" || name == "-" || strings.Contains(opts, "omitempty") {
+					continue
+				}
+				assert.Contains(t, raw, name)
+			}
+		})
+	}
+}
@@ -2,13 +2,37 @@ package model
 
 import "time"
 
-// PaymentRequest represents an incoming payment authorization request.
+// PaymentRequest represents an incoming payment authorization request. It is stored
+// verbatim on PaymentResult.Request and returned by the history endpoint, so any
+// sensitive field added later (e.g. card data) must be tagged `json:"-"`.
 type PaymentRequest struct {
 	TransactionID string  `json:"transaction_id"`
 	Amount        float64 `json:"amount"`
 	Currency      string  `json:"currency"`
 	PaymentMethod string  `json:"payment_method"`
 	CustomerID    string  `json:"customer_id"`
+	// ExcludeProcessors lists processors that must not be attempted for this payment.
+	// Names that don't match a registered processor are ignored.
+	ExcludeProcessors []string `json:"exclude_processors,omitempty"`
+	// PreferredProcessor, when eligible and its circuit is not open, is tried first;
+	// health ordering still applies to fallbacks. Otherwise it is ignored.
+	PreferredProcessor string `json:"preferred_processor,omitempty"`
+	// AllowZeroAmount opts in to $0 account-verification auths. Negative amounts
+	// are always rejected.
+	AllowZeroAmount bool `json:"allow_zero_amount,omitempty"`
+	// FanOutMethods lists extra payment methods tried in parallel with PaymentMethod,
+	// each on its best processor; the first approval wins and the rest are cancelled.
+	FanOutMethods []string `json:"fan_out_methods,omitempty"`
+	// AvoidDegraded restricts routing to healthy processors: degraded ones, and
+	// open circuits the last resort or penalize policy would otherwise try, are
+	// skipped, and the payment is declined if none is left.
+	AvoidDegraded bool `json:"avoid_degraded,omitempty"`
+}
+
+// IsVerification reports whether the request is a zero-amount account verification.
+// Verifications are routable to every processor supporting the method.
+func (r PaymentRequest) IsVerification() bool {
+	return r.Amount == 0 && r.AllowZeroAmount
 }
 
 // ResponseCode represents the outcome of a processor authorization attempt.
@@ -22,8 +46,17 @@ const (
 	ProcessorError            ResponseCode = "processor_error"
 	Timeout                   ResponseCode = "timeout"
 	RateLimited               ResponseCode = "rate_limited"
+	// Pending means the processor accepted the payment for asynchronous
+	// processing (e.g. awaiting 3DS); the final outcome arrives later.
+	Pending ResponseCode = "pending"
 )
 
+// ResponseCodes lists every response code a processor may return.
+var ResponseCodes = []ResponseCode{
+	Approved, SoftDecline, DeclinedInsufficientFunds, DeclinedFraud,
+	ProcessorError, Timeout, RateLimited, Pending,
+}
+
 // IsRetriable returns true if the response code indicates a retriable failure.
 func (rc ResponseCode) IsRetriable() bool {
 	switch rc {
@@ -44,13 +77,54 @@ func (rc ResponseCode) IsHardDecline() bool {
 	}
 }
 
+// IsTransient returns true if the response code indicates a temporary processor-side
+// failure rather than an issuer or customer decision.
+func (rc ResponseCode) IsTransient() bool {
+	switch rc {
+	case ProcessorError, Timeout, RateLimited:
+		return true
+	default:
+		return false
+	}
+}
+
+// Classification groups response codes by the kind of outcome they represent.
+type Classification string
+
+const (
+	ClassificationApproved        Classification = "approved"
+	ClassificationBusinessDecline Classification = "business_decline"
+	ClassificationTransient       Classification = "transient"
+	ClassificationSoft            Classification = "soft"
+	ClassificationPending         Classification = "pending"
+)
+
+// Classification returns whether the code is an approval, an issuer or customer
+// decision (business decline), a processor-side failure (transient), a soft
+// decline, or an asynchronous pending outcome.
+func (rc ResponseCode) Classification() Classification {
+	switch {
+	case rc == Approved:
+		return ClassificationApproved
+	case rc == Pending:
+		return ClassificationPending
+	case rc.IsHardDecline():
+		return ClassificationBusinessDecline
+	case rc.IsTransient():
+		return ClassificationTransient
+	default:
+		return ClassificationSoft
+	}
+}
+
 // ProcessorResponse represents the result of a single processor authorization attempt.
 type ProcessorResponse struct {
-	ProcessorName string        `json:"processor_name"`
-	Code          ResponseCode  `json:"code"`
-	Message       string        `json:"message"`
-	Timestamp     time.Time     `json:"timestamp"`
-	Latency       time.Duration `json:"latency"`
+	ProcessorName string       `json:"processor_name"`
+	Code          ResponseCode `json:"code"`
+	Message       string       `json:"message"`
+	Timestamp     time.Time    `json:"timestamp"`
+	// Latency is sent as latency_ms by MarshalJSON.
+	Latency time.Duration `json:"-"`
 }
 
 // Attempt represents a single routing attempt within a payment orchestration.
@@ -59,7 +133,22 @@ type Attempt struct {
 	Response      ProcessorResponse `json:"response"`
 	RoutingReason string            `json:"routing_reason"`
 	AttemptNumber int               `json:"attempt_number"`
-	Timestamp     time.Time         `json:"timestamp"`
+	TraceID       string            `json:"trace_id,omitempty"`
+	// PaymentMethod is the method this attempt charged, which differs from the
+	// request's for fan-out attempts.
+	PaymentMethod string `json:"payment_method,omitempty"`
+	// Classification is derived from the response code when the attempt is recorded.
+	Classification Classification `json:"classification"`
+	// HealthBefore and HealthAfter are the processor's health score just before and
+	// just after this attempt's outcome was recorded. With a batched health recorder
+	// the outcome may not be visible yet, so HealthAfter can equal HealthBefore.
+	HealthBefore float64 `json:"health_before"`
+	HealthAfter  float64 `json:"health_after"`
+	// TimedOut marks an attempt cut short by the orchestrator's own deadline or
+	// cancellation, as opposed to a timeout reported by the processor. Its latency
+	// is the time actually spent waiting.
+	TimedOut  bool      `json:"timed_out,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
 }
 
 // PaymentStatus represents the final status of a payment after orchestration.
@@ -69,12 +158,72 @@ const (
 	StatusApproved         PaymentStatus = "approved"
 	StatusDeclined         PaymentStatus = "declined"
 	StatusExhaustedRetries PaymentStatus = "exhausted_retries"
+	// StatusPending awaits an asynchronous outcome from the processor.
+	StatusPending PaymentStatus = "pending"
 )
 
+// Payment state machine: ProcessPayment ends a payment as approved, declined,
+// exhausted_retries or pending. Only pending may change afterwards, and only once:
+//
+//	pending --approved-------------------------> approved
+//	pending --hard or soft decline-------------> declined
+//
+// Every other status is final.
+
+// IsFinal reports whether the status can no longer change.
+func (s PaymentStatus) IsFinal() bool {
+	return s != StatusPending
+}
+
+// ResolvedStatus returns the status a pending payment moves to when its final
+// outcome arrives with the given code. Transient failures and Pending itself are
+// not final outcomes, so they report false.
+func ResolvedStatus(code ResponseCode) (PaymentStatus, bool) {
+	switch {
+	case code == Approved:
+		return StatusApproved, true
+	case code.IsHardDecline() || code == SoftDecline:
+		return StatusDeclined, true
+	default:
+		return "", false
+	}
+}
+
 // PaymentResult represents the final outcome of a payment orchestration.
+// WinningProcessor is set only when the payment is approved.
 type PaymentResult struct {
-	TransactionID string             `json:"transaction_id"`
-	Status        PaymentStatus      `json:"status"`
-	Attempts      []Attempt          `json:"attempts"`
-	FinalResponse *ProcessorResponse `json:"final_response"`
+	TransactionID    string             `json:"transaction_id"`
+	Status           PaymentStatus      `json:"status"`
+	WinningProcessor string             `json:"winning_processor,omitempty"`
+	Attempts         []Attempt          `json:"attempts"`
+	FinalResponse    *ProcessorResponse `json:"final_response"`
+	Reason           string             `json:"reason,omitempty"`
+	// TotalLatency is sent as total_latency_ms by MarshalJSON.
+	TotalLatency time.Duration `json:"-"`
+	FeeCharged   float64       `json:"fee_charged,omitempty"`
+	NetAmount    float64       `json:"net_amount,omitempty"`
+	// Message is a client-facing summary of the outcome, e.g. "declined after 3
+	// attempts; last reason: insufficient funds", set when the payment completes.
+	Message string `json:"message,omitempty"`
+	// ApprovedWhileDegraded flags an approval from a processor that was degraded
+	// (or had an open circuit) when routed, for risk review. Only set when the
+	// orchestrator is configured to flag such approvals.
+	ApprovedWhileDegraded bool `json:"approved_while_degraded,omitempty"`
+	// DuplicateApprovals lists processors that also approved a fan-out payment after
+	// the winner, so the same payment was captured more than once and those
+	// captures must be voided.
+	DuplicateApprovals []string `json:"duplicate_approvals,omitempty"`
+	// SkippedProcessors lists fallbacks that were routed but never attempted because
+	// their circuit opened while the payment was in progress.
+	SkippedProcessors []SkipInfo `json:"skipped_processors,omitempty"`
+	// Request is the original request as received, kept so replay, refunds and
+	// support tooling can recover the amount, currency and method.
+	Request PaymentRequest `json:"request"`
+}
+
+// SkipInfo records a routed processor that was passed over without an attempt.
+type SkipInfo struct {
+	ProcessorName string    `json:"processor_name"`
+	Reason        string    `json:"reason"`
+	Timestamp     time.Time `json:"timestamp"`
 }
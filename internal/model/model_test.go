@@ -98,13 +98,44 @@ func TestPaymentStatus_Values(t *testing.T) {
 	assert.Equal(t, PaymentStatus("approved"), StatusApproved)
 	assert.Equal(t, PaymentStatus("declined"), StatusDeclined)
 	assert.Equal(t, PaymentStatus("exhausted_retries"), StatusExhaustedRetries)
+	assert.Equal(t, PaymentStatus("pending"), StatusPending)
+}
+
+func TestPaymentStatus_IsFinal(t *testing.T) {
+	assert.False(t, StatusPending.IsFinal())
+	for _, s := range []PaymentStatus{StatusApproved, StatusDeclined, StatusExhaustedRetries} {
+		assert.True(t, s.IsFinal(), s)
+	}
+}
+
+func TestResolvedStatus(t *testing.T) {
+	tests := []struct {
+		code   ResponseCode
+		status PaymentStatus
+		ok     bool
+	}{
+		{Approved, StatusApproved, true},
+		{DeclinedFraud, StatusDeclined, true},
+		{DeclinedInsufficientFunds, StatusDeclined, true},
+		{SoftDecline, StatusDeclined, true},
+		{Timeout, "", false},
+		{ProcessorError, "", false},
+		{Pending, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.code), func(t *testing.T) {
+			status, ok := ResolvedStatus(tt.code)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.status, status)
+		})
+	}
 }
 
 func TestResponseCode_MutualExclusivity(t *testing.T) {
 	// A code should never be both retriable and hard decline
 	allCodes := []ResponseCode{
 		Approved, SoftDecline, DeclinedInsufficientFunds,
-		DeclinedFraud, ProcessorError, Timeout, RateLimited,
+		DeclinedFraud, ProcessorError, Timeout, RateLimited, Pending,
 	}
 	for _, code := range allCodes {
 		t.Run(string(code), func(t *testing.T) {
@@ -115,3 +146,44 @@ func TestResponseCode_MutualExclusivity(t *testing.T) {
 		})
 	}
 }
+
+func TestResponseCode_IsTransient(t *testing.T) {
+	tests := []struct {
+		code     ResponseCode
+		expected bool
+	}{
+		{ProcessorError, true},
+		{Timeout, true},
+		{RateLimited, true},
+		{SoftDecline, false},
+		{Approved, false},
+		{DeclinedInsufficientFunds, false},
+		{DeclinedFraud, false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.code), func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.code.IsTransient())
+		})
+	}
+}
+
+func TestResponseCode_Classification(t *testing.T) {
+	tests := []struct {
+		code     ResponseCode
+		expected Classification
+	}{
+		{Approved, ClassificationApproved},
+		{DeclinedInsufficientFunds, ClassificationBusinessDecline},
+		{DeclinedFraud, ClassificationBusinessDecline},
+		{ProcessorError, ClassificationTransient},
+		{Timeout, ClassificationTransient},
+		{RateLimited, ClassificationTransient},
+		{SoftDecline, ClassificationSoft},
+		{Pending, ClassificationPending},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.code), func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.code.Classification())
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// errQueueFull is returned by admission.acquire when the payment queue is full.
+var errQueueFull = errors.New("payment queue full")
+
+// admission bounds concurrent payments: a payment takes one of a fixed number of
+// slots, waiting in a queue of bounded depth when none is free. A nil admission
+// admits everything.
+type admission struct {
+	slots  chan struct{}
+	depth  int64
+	queued atomic.Int64
+}
+
+// newAdmission returns an admission allowing limit concurrent payments with up to
+// depth more queued, or nil when limit is not positive.
+func newAdmission(limit, depth int) *admission {
+	if limit <= 0 {
+		return nil
+	}
+	return &admission{slots: make(chan struct{}, limit), depth: int64(max(depth, 0))}
+}
+
+// acquire takes a slot, queuing for one if the queue has room. It fails with
+// errQueueFull when the queue is full, or with ctx's error if ctx ends while
+// queued. The returned func frees the slot.
+func (a *admission) acquire(ctx context.Context) (release func(), err error) {
+	if a == nil {
+		return func() {}, nil
+	}
+	select {
+	case a.slots <- struct{}{}:
+		return a.release, nil
+	default:
+	}
+
+	if a.queued.Add(1) > a.depth {
+		a.queued.Add(-1)
+		return nil, errQueueFull
+	}
+	defer a.queued.Add(-1)
+	select {
+	case a.slots <- struct{}{}:
+		return a.release, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (a *admission) release() {
+	<-a.slots
+}
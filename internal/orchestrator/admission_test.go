@@ -0,0 +1,58 @@
+package orchestrator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdmission(t *testing.T) {
+	a := newAdmission(1, 1)
+	release, err := a.acquire(context.Background())
+	require.NoError(t, err)
+
+	// The second payment queues until the slot frees up
+	acquired := make(chan error, 1)
+	go func() {
+		rel, err := a.acquire(context.Background())
+		if err == nil {
+			rel()
+		}
+		acquired <- err
+	}()
+	require.Eventually(t, func() bool { return a.queued.Load() == 1 }, time.Second, time.Millisecond)
+
+	// The queue is full, so a third is rejected straight away
+	_, err = a.acquire(context.Background())
+	assert.ErrorIs(t, err, errQueueFull)
+
+	release()
+	assert.NoError(t, <-acquired)
+	assert.Equal(t, int64(0), a.queued.Load())
+}
+
+func TestAdmission_ContextEndsWhileQueued(t *testing.T) {
+	a := newAdmission(1, 5)
+	release, err := a.acquire(context.Background())
+	require.NoError(t, err)
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = a.acquire(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, int64(0), a.queued.Load())
+}
+
+func TestAdmission_NilAdmitsEverything(t *testing.T) {
+	a := newAdmission(0, 0)
+	assert.Nil(t, a)
+	for i := 0; i < 3; i++ {
+		release, err := a.acquire(context.Background())
+		require.NoError(t, err)
+		release()
+	}
+}
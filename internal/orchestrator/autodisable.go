@@ -0,0 +1,108 @@
+package orchestrator
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/health"
+)
+
+// disabledSet tracks processors taken out of routing by AutoDisableOpenCircuits.
+// It is safe for concurrent use.
+type disabledSet struct {
+	mu    sync.Mutex
+	names map[string]struct{}
+}
+
+func newDisabledSet() *disabledSet {
+	return &disabledSet{names: make(map[string]struct{})}
+}
+
+// set marks name disabled or enabled, reporting whether that changed anything.
+func (s *disabledSet) set(name string, disabled bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, was := s.names[name]
+	if disabled {
+		s.names[name] = struct{}{}
+	} else {
+		delete(s.names, name)
+	}
+	return was != disabled
+}
+
+func (s *disabledSet) has(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.names[name]
+	return ok
+}
+
+func (s *disabledSet) list() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := make([]string, 0, len(s.names))
+	for name := range s.names {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *disabledSet) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.names = make(map[string]struct{})
+}
+
+// DisabledProcessors returns the processors currently auto-disabled, sorted by name.
+func (o *Orchestrator) DisabledProcessors() []string {
+	return o.disabled.list()
+}
+
+// handleStatusChange is the monitor hook behind AutoDisableOpenCircuits: a
+// processor is disabled when its circuit opens and re-enabled once it leaves
+// the open state.
+func (o *Orchestrator) handleStatusChange(c health.StatusChange) {
+	switch {
+	case c.To == health.StatusOpen:
+		o.setDisabled(c.ProcessorName, true, c.HealthScore, "circuit opened")
+	case c.From == health.StatusOpen:
+		o.setDisabled(c.ProcessorName, false, c.HealthScore, "circuit recovered to "+string(c.To))
+	}
+}
+
+func (o *Orchestrator) setDisabled(name string, disabled bool, score float64, reason string) {
+	if !o.disabled.set(name, disabled) {
+		return
+	}
+	event := "processor_auto_enabled"
+	if disabled {
+		event = "processor_auto_disabled"
+	}
+	slog.Warn(event,
+		"processor", name,
+		"reason", reason,
+		"health_score", fmt.Sprintf("%.2f", score),
+	)
+}
+
+// routingDisabled reports whether an auto-disabled processor must be left out of
+// routing. Outcomes aging out of the window can close the circuit without a
+// recorded outcome, so the current health is checked before skipping. A dry run
+// reports the same without re-enabling anything.
+func (o *Orchestrator) routingDisabled(name string, h health.ProcessorHealth, dryRun bool) bool {
+	if !o.disabled.has(name) {
+		return false
+	}
+	if h.Status != health.StatusOpen {
+		if dryRun {
+			return false
+		}
+		o.setDisabled(name, false, h.HealthScore, "circuit recovered to "+string(h.Status))
+		return false
+	}
+	return true
+}
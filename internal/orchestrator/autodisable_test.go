@@ -0,0 +1,83 @@
+package orchestrator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/health"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/model"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/processor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoDisableOpenCircuits(t *testing.T) {
+	mon := health.NewMonitorWithConfig(5, 10*time.Minute)
+	procA := newDeterministicProcessor("ProcA", []string{"card"}, model.ProcessorError)
+	procB := newDeterministicProcessor("ProcB", []string{"card"}, model.Approved)
+	cfg := DefaultConfig()
+	// As last resort, ProcA would normally be tried even with an open circuit
+	cfg.LastResort = "ProcA"
+	cfg.AutoDisableOpenCircuits = true
+	orch := NewWithConfig([]processor.Processor{procA, procB}, mon, cfg)
+
+	req := model.PaymentRequest{
+		TransactionID: "tx-auto-disable",
+		Amount:        100.0,
+		Currency:      "USD",
+		PaymentMethod: "card",
+		CustomerID:    "cust-1",
+	}
+	for i := 0; i < 4; i++ {
+		mon.RecordOutcome("ProcA", model.ProcessorError)
+	}
+	require.Equal(t, health.StatusOpen, mon.GetHealth("ProcA").Status)
+	assert.Equal(t, []string{"ProcA"}, orch.DisabledProcessors())
+
+	result := orch.ProcessPayment(context.Background(), req)
+	require.Equal(t, model.StatusApproved, result.Status)
+	require.Len(t, result.Attempts, 1)
+	assert.Equal(t, "ProcB", result.Attempts[0].ProcessorName, "disabled processors are not even a last resort")
+	assert.Equal(t, 0, procA.callCount)
+
+	// Recovery out of the open state re-enables the processor
+	for i := 0; i < 3; i++ {
+		mon.RecordOutcome("ProcA", model.Approved)
+	}
+	require.NotEqual(t, health.StatusOpen, mon.GetHealth("ProcA").Status)
+	assert.Empty(t, orch.DisabledProcessors())
+
+	eligible, _ := orch.getEligibleProcessors(context.Background(), req)
+	assert.Len(t, eligible, 2)
+}
+
+func TestAutoDisableOpenCircuits_WindowExpiryReenables(t *testing.T) {
+	mon := health.NewMonitorWithConfig(10, 30*time.Millisecond)
+	cfg := DefaultConfig()
+	cfg.AutoDisableOpenCircuits = true
+	orch := NewWithConfig([]processor.Processor{newDeterministicProcessor("ProcA", []string{"card"}, model.Approved)}, mon, cfg)
+
+	mon.RecordOutcome("ProcA", model.ProcessorError)
+	require.Equal(t, []string{"ProcA"}, orch.DisabledProcessors())
+
+	// No outcome is recorded while disabled; routing notices the circuit closed
+	time.Sleep(50 * time.Millisecond)
+	result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+		TransactionID: "tx-reenabled",
+		Amount:        100.0,
+		Currency:      "USD",
+		PaymentMethod: "card",
+		CustomerID:    "cust-1",
+	})
+	assert.Equal(t, model.StatusApproved, result.Status)
+	assert.Empty(t, orch.DisabledProcessors())
+}
+
+func TestAutoDisableOpenCircuits_OptIn(t *testing.T) {
+	mon := health.NewMonitorWithConfig(10, 10*time.Minute)
+	orch := New([]processor.Processor{newDeterministicProcessor("ProcA", []string{"card"}, model.Approved)}, mon)
+
+	mon.RecordOutcome("ProcA", model.ProcessorError)
+	assert.Empty(t, orch.DisabledProcessors())
+}
@@ -0,0 +1,127 @@
+package orchestrator
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/health"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/model"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/trace"
+)
+
+// RoutingCandidate is one entry of the candidate list in a routing_decision log
+// or a routing simulation.
+type RoutingCandidate struct {
+	Processor   string        `json:"processor"`
+	HealthScore float64       `json:"health_score"`
+	Status      health.Status `json:"status"`
+	// ApprovalEstimate is the candidate's estimated approval probability: its
+	// windowed approval rate, or its approval target before any outcome is seen.
+	ApprovalEstimate float64 `json:"approval_estimate"`
+	// Role notes why the candidate sits where it does, if not by ordering alone.
+	Role string `json:"role,omitempty"`
+}
+
+func (ep eligibleProcessor) role() string {
+	switch {
+	case ep.preferred:
+		return "preferred"
+	case ep.canary:
+		return "canary"
+	case ep.lastResort:
+		return "last_resort"
+	case ep.penalized:
+		return "penalized"
+	case ep.warming:
+		return "warming_up"
+	case ep.recovering:
+		return "recovering"
+	default:
+		return ""
+	}
+}
+
+// ordering names the strategy used to rank candidates.
+func (o *Orchestrator) ordering() string {
+	switch {
+	case o.cfg.CostAwareRouting:
+		return "cost"
+	case o.cfg.LatencyWeight > 0:
+		return "health_latency_blend"
+	case o.cfg.LeastLoadedRouting:
+		return "least_loaded"
+	default:
+		return "health"
+	}
+}
+
+// logRoutingDecision writes the whole routing decision of a payment as one Debug
+// line: the ordered candidates with their scores, the filter counts and the
+// outcome. Nothing is built unless Debug is enabled.
+func (o *Orchestrator) logRoutingDecision(ctx context.Context, req model.PaymentRequest, eligible []eligibleProcessor, filter eligibilityFilter, result model.PaymentResult) {
+	if !slog.Default().Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+
+	slog.DebugContext(ctx, "routing_decision",
+		"txn_id", req.TransactionID,
+		"trace_id", trace.TraceIDFromContext(ctx),
+		"payment_method", req.PaymentMethod,
+		"ordering", o.ordering(),
+		"candidates", routingCandidates(eligible),
+		slog.Group("filters",
+			"supported", filter.supported,
+			"excluded", filter.excluded,
+			"circuit_open", filter.circuitOpen,
+			"not_allowed", filter.notAllowed,
+			"unhealthy", filter.unhealthy,
+		),
+		"attempts", len(result.Attempts),
+		"status", result.Status,
+		"winner", result.WinningProcessor,
+	)
+}
+
+func routingCandidates(eligible []eligibleProcessor) []RoutingCandidate {
+	candidates := make([]RoutingCandidate, 0, len(eligible))
+	for _, ep := range eligible {
+		candidates = append(candidates, RoutingCandidate{
+			Processor:        ep.proc.Name(),
+			HealthScore:      ep.healthScore,
+			Status:           ep.status,
+			ApprovalEstimate: ep.approval,
+			Role:             ep.role(),
+		})
+	}
+	return candidates
+}
+
+// RoutingSimulation is where a payment would route under hypothetical health scores.
+type RoutingSimulation struct {
+	Ordering   string             `json:"ordering"`
+	Candidates []RoutingCandidate `json:"candidates"`
+	// Reason explains an empty candidate list.
+	Reason string `json:"reason,omitempty"`
+}
+
+// SimulateRouting orders the candidates for req as if each processor in
+// scoreOverrides had that health score, with the status it implies. Other
+// processors keep their real health. It is a dry run: the monitor, auto-disabled
+// processors and the canary and warm-up RNG are not touched, and no routing logs
+// are written.
+func (o *Orchestrator) SimulateRouting(ctx context.Context, req model.PaymentRequest, scoreOverrides map[string]float64) RoutingSimulation {
+	healthOf := func(name string) health.ProcessorHealth {
+		h := o.monitor.GetHealth(name)
+		if score, ok := scoreOverrides[name]; ok {
+			h.HealthScore = score
+			h.Status = health.StatusForScore(score)
+		}
+		return h
+	}
+	eligible, filter := o.eligibleWithHealth(ctx, req, dryRunPass(healthOf))
+	sim := RoutingSimulation{Ordering: o.ordering(), Candidates: routingCandidates(eligible)}
+	if len(eligible) == 0 {
+		sim.Reason = filter.declineReason(req.PaymentMethod)
+	}
+	return sim
+}
@@ -0,0 +1,172 @@
+package orchestrator
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/health"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/model"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/processor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// captureJSONLogs routes the default logger to a buffer as JSON at the given level.
+func captureJSONLogs(t *testing.T, level slog.Level) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: level})))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+	return &buf
+}
+
+// logLines returns the decoded log records with the given message.
+func logLines(t *testing.T, buf *bytes.Buffer, msg string) []map[string]any {
+	t.Helper()
+	var lines []map[string]any
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var line map[string]any
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		if line["msg"] == msg {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func TestProcessPayment_LogsRoutingDecision(t *testing.T) {
+	logs := captureJSONLogs(t, slog.LevelDebug)
+
+	mon := health.NewMonitorWithConfig(50, 10*time.Minute)
+	mon.RecordOutcome("ProcB", model.Approved)
+	mon.RecordOutcome("ProcB", model.ProcessorError)
+	mon.RecordOutcome("ProcC", model.ProcessorError)
+	cfg := DefaultConfig()
+	orch := NewWithConfig([]processor.Processor{
+		newDeterministicProcessor("ProcA", []string{"card"}, model.SoftDecline),
+		newDeterministicProcessor("ProcB", []string{"card"}, model.Approved),
+		newDeterministicProcessor("ProcC", []string{"card"}, model.Approved),
+		newDeterministicProcessor("ProcD", []string{"card"}, model.Approved),
+	}, mon, cfg)
+
+	result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+		TransactionID:     "tx-decision",
+		Amount:            100.0,
+		Currency:          "USD",
+		PaymentMethod:     "card",
+		CustomerID:        "cust-1",
+		ExcludeProcessors: []string{"ProcD"},
+	})
+	require.Equal(t, model.StatusApproved, result.Status)
+
+	lines := logLines(t, logs, "routing_decision")
+	require.Len(t, lines, 1)
+	line := lines[0]
+	assert.Equal(t, "tx-decision", line["txn_id"])
+	assert.Equal(t, "health", line["ordering"])
+	assert.Equal(t, "approved", line["status"])
+	assert.Equal(t, "ProcB", line["winner"])
+	assert.Equal(t, float64(2), line["attempts"])
+	assert.Equal(t, map[string]any{"supported": float64(4), "excluded": float64(1), "circuit_open": float64(1), "not_allowed": float64(0), "unhealthy": float64(0)}, line["filters"])
+
+	candidates := line["candidates"].([]any)
+	require.Len(t, candidates, 2)
+	assert.Equal(t, map[string]any{"processor": "ProcA", "health_score": 1.0, "status": "healthy", "approval_estimate": 1.0}, candidates[0])
+	assert.Equal(t, map[string]any{"processor": "ProcB", "health_score": 0.5, "status": "healthy", "approval_estimate": 0.5}, candidates[1])
+}
+
+// targetProcessor declares an approval target for the processor it wraps.
+type targetProcessor struct {
+	processor.Processor
+	target float64
+}
+
+func (p targetProcessor) ExpectedApprovalRate() float64 { return p.target }
+
+func TestSimulateRouting_ApprovalEstimate(t *testing.T) {
+	mon := health.NewMonitorWithConfig(10, 10*time.Minute)
+	cfg := DefaultConfig()
+	orch := NewWithConfig([]processor.Processor{
+		newDeterministicProcessor("ProcA", []string{"card"}, model.Approved),
+		targetProcessor{newDeterministicProcessor("ProcB", []string{"card"}, model.Approved), 0.85},
+		newDeterministicProcessor("ProcC", []string{"card"}, model.Approved),
+	}, mon, cfg)
+	recordApprovals := func(approved int) {
+		for i := 0; i < 10; i++ {
+			code := model.SoftDecline
+			if i < approved {
+				code = model.Approved
+			}
+			mon.RecordOutcome("ProcA", code)
+		}
+	}
+	estimates := func() map[string]float64 {
+		sim := orch.SimulateRouting(context.Background(), model.PaymentRequest{PaymentMethod: "card"}, nil)
+		out := make(map[string]float64)
+		for _, c := range sim.Candidates {
+			out[c.Processor] = c.ApprovalEstimate
+		}
+		return out
+	}
+
+	recordApprovals(7)
+	got := estimates()
+	assert.InDelta(t, 0.7, got["ProcA"], 1e-9, "observed approval rate")
+	assert.InDelta(t, 0.85, got["ProcB"], 1e-9, "approval target before any outcome")
+	assert.InDelta(t, 1.0, got["ProcC"], 1e-9, "no outcomes and no target")
+
+	// The estimate follows the window as outcomes replace older ones
+	recordApprovals(4)
+	assert.InDelta(t, 0.4, estimates()["ProcA"], 1e-9)
+}
+
+func TestSimulateRouting_LeavesRealStateUntouched(t *testing.T) {
+	mon := health.NewMonitorWithConfig(5, 10*time.Minute)
+	cfg := DefaultConfig()
+	cfg.AutoDisableOpenCircuits = true
+	cfg.Canary = &CanaryConfig{ProcessorName: "ProcB", Percentage: 50}
+	cfg.Seed = 42
+	orch := NewWithConfig([]processor.Processor{
+		newDeterministicProcessor("ProcA", []string{"card"}, model.Approved),
+		newDeterministicProcessor("ProcB", []string{"card"}, model.Approved),
+	}, mon, cfg)
+	mon.RecordOutcome("ProcA", model.ProcessorError)
+	require.Equal(t, []string{"ProcA"}, orch.DisabledProcessors())
+
+	logs := captureJSONLogs(t, slog.LevelDebug)
+	sim := orch.SimulateRouting(context.Background(), model.PaymentRequest{
+		TransactionID: "tx-sim",
+		PaymentMethod: "card",
+	}, map[string]float64{"ProcA": 1.0})
+
+	require.Len(t, sim.Candidates, 2, "the simulated recovery makes ProcA routable")
+	assert.Equal(t, []string{"ProcA"}, orch.DisabledProcessors(), "the real processor stays disabled")
+	assert.Empty(t, logs.String(), "a dry run writes no routing logs")
+	// The shared RNG was not consumed: its next roll is the seed's first
+	assert.Equal(t, rand.New(rand.NewSource(42)).Float64(), orch.roll())
+}
+
+func TestProcessPayment_RoutingDecisionOnlyAtDebug(t *testing.T) {
+	logs := captureJSONLogs(t, slog.LevelInfo)
+
+	orch := New([]processor.Processor{
+		newDeterministicProcessor("ProcA", []string{"card"}, model.Approved),
+	}, health.NewMonitorWithConfig(50, 10*time.Minute))
+	orch.ProcessPayment(context.Background(), model.PaymentRequest{
+		TransactionID: "tx-decision-info",
+		Amount:        100.0,
+		Currency:      "USD",
+		PaymentMethod: "card",
+		CustomerID:    "cust-1",
+	})
+
+	assert.Empty(t, logLines(t, logs, "routing_decision"))
+}
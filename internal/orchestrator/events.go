@@ -0,0 +1,54 @@
+package orchestrator
+
+import (
+	"log/slog"
+	"sync"
+
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/model"
+)
+
+// EventBus delivers PaymentCompleted events to in-process subscribers.
+// Delivery is synchronous, but each subscriber is isolated: a panicking
+// subscriber is logged and does not affect the payment or other subscribers.
+type EventBus struct {
+	mu          sync.RWMutex
+	subscribers []func(model.PaymentResult)
+}
+
+// NewEventBus creates an event bus with no subscribers.
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+// Subscribe registers a function called with every completed payment result.
+func (b *EventBus) Subscribe(fn func(model.PaymentResult)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, fn)
+}
+
+// Publish delivers a PaymentCompleted event to all subscribers in registration order.
+func (b *EventBus) Publish(result model.PaymentResult) {
+	b.mu.RLock()
+	subscribers := make([]func(model.PaymentResult), len(b.subscribers))
+	copy(subscribers, b.subscribers)
+	b.mu.RUnlock()
+
+	for _, fn := range subscribers {
+		deliver(fn, result)
+	}
+}
+
+// deliver calls a single subscriber, recovering from any panic it raises.
+func deliver(fn func(model.PaymentResult), result model.PaymentResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("event_subscriber_panic",
+				"event", "payment_completed",
+				"txn_id", result.TransactionID,
+				"panic", r,
+			)
+		}
+	}()
+	fn(result)
+}
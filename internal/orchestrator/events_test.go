@@ -0,0 +1,62 @@
+package orchestrator
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventBus_DeliversToAllSubscribers(t *testing.T) {
+	bus := NewEventBus()
+	var got []string
+	bus.Subscribe(func(r model.PaymentResult) { got = append(got, "first:"+r.TransactionID) })
+	bus.Subscribe(func(r model.PaymentResult) { got = append(got, "second:"+r.TransactionID) })
+
+	bus.Publish(model.PaymentResult{TransactionID: "tx-1"})
+
+	assert.Equal(t, []string{"first:tx-1", "second:tx-1"}, got)
+}
+
+func TestEventBus_PanickingSubscriberIsIsolated(t *testing.T) {
+	bus := NewEventBus()
+	delivered := false
+	bus.Subscribe(func(model.PaymentResult) { panic("subscriber failure") })
+	bus.Subscribe(func(model.PaymentResult) { delivered = true })
+
+	require.NotPanics(t, func() {
+		bus.Publish(model.PaymentResult{TransactionID: "tx-panic"})
+	})
+	assert.True(t, delivered, "subscribers after a panicking one should still receive the event")
+}
+
+func TestEventBus_ConcurrentSubscribeAndPublish(t *testing.T) {
+	// Run with -race: Subscribe and Publish must be safe to call concurrently
+	bus := NewEventBus()
+	var mu sync.Mutex
+	count := 0
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			bus.Subscribe(func(model.PaymentResult) {
+				mu.Lock()
+				count++
+				mu.Unlock()
+			})
+		}()
+		go func() {
+			defer wg.Done()
+			bus.Publish(model.PaymentResult{TransactionID: "tx-conc"})
+		}()
+	}
+	wg.Wait()
+
+	bus.Publish(model.PaymentResult{TransactionID: "tx-final"})
+	mu.Lock()
+	defer mu.Unlock()
+	assert.GreaterOrEqual(t, count, 50, "final publish reaches all 50 subscribers")
+}
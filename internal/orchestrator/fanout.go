@@ -0,0 +1,201 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/health"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/model"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/processor"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/trace"
+)
+
+// fanOutLeg is one method of a fan-out payment, routed to its best processor.
+type fanOutLeg struct {
+	method string
+	ep     eligibleProcessor
+}
+
+// fanOutOutcome is the response of one leg, in completion order.
+type fanOutOutcome struct {
+	leg       fanOutLeg
+	resp      model.ProcessorResponse
+	timedOut  bool
+	throttled bool
+	// cancelled marks a leg cut short because another leg was approved.
+	cancelled bool
+}
+
+// fanOutMethods returns the request's method followed by its fan-out methods,
+// without duplicates.
+func fanOutMethods(req model.PaymentRequest) []string {
+	methods := []string{req.PaymentMethod}
+	seen := map[string]bool{req.PaymentMethod: true}
+	for _, m := range req.FanOutMethods {
+		if !seen[m] {
+			seen[m] = true
+			methods = append(methods, m)
+		}
+	}
+	return methods
+}
+
+// processFanOut tries the request's method and each of its FanOutMethods at the
+// same time, one attempt per method on that method's best processor. The first
+// approval wins and cancels the other legs; a decline on one leg leaves the
+// others running. Legs cancelled by the winner are recorded as timed-out
+// attempts but not against processor health. A pending leg decides the payment
+// only when no leg is approved. A leg approved after the winner, e.g. because
+// its processor ignored the cancellation, is a second capture for the same
+// payment: it is logged at Error and listed in DuplicateApprovals to be voided.
+func (o *Orchestrator) processFanOut(ctx context.Context, req model.PaymentRequest, result model.PaymentResult, start time.Time) model.PaymentResult {
+	traceID := trace.TraceIDFromContext(ctx)
+	var legs []fanOutLeg
+	// The routing decision lists each leg's processor, with filters summed over methods
+	var chosen []eligibleProcessor
+	var filtered eligibilityFilter
+	defer func() { o.logRoutingDecision(ctx, req, chosen, filtered, result) }()
+	for _, method := range fanOutMethods(req) {
+		legReq := req
+		legReq.PaymentMethod = method
+		eligible, filter := o.getEligibleProcessors(ctx, legReq)
+		filtered = filtered.add(filter)
+		if len(eligible) > 0 {
+			legs = append(legs, fanOutLeg{method: method, ep: eligible[0]})
+			chosen = append(chosen, eligible[0])
+		}
+	}
+	if len(legs) == 0 {
+		result.Reason = "no eligible processor for any fan-out method"
+		slog.Warn("no_eligible_processors",
+			"txn_id", req.TransactionID,
+			"trace_id", traceID,
+			"payment_methods", fanOutMethods(req),
+			"reason", result.Reason,
+		)
+		result.Status = model.StatusDeclined
+		return o.complete(result, start)
+	}
+
+	raceCtx, cancelRace := context.WithCancel(ctx)
+	defer cancelRace()
+	outcomes := make(chan fanOutOutcome, len(legs))
+	for _, leg := range legs {
+		go func() {
+			legReq := req
+			legReq.PaymentMethod = leg.method
+			attemptCtx, cancel := o.attemptContext(raceCtx, leg.ep.proc)
+			defer cancel()
+			callStart := time.Now()
+			resp, throttled := o.callLimited(attemptCtx, leg.ep.proc, legReq, traceID)
+			out := fanOutOutcome{leg: leg, resp: resp, throttled: throttled}
+			if !throttled && cutShort(attemptCtx, resp) {
+				out.resp.Code = model.Timeout
+				out.resp.Latency = time.Since(callStart)
+				out.timedOut = true
+				// Only the winner cancels the race; other deadlines are real timeouts
+				out.cancelled = raceCtx.Err() != nil && ctx.Err() == nil
+			}
+			outcomes <- out
+		}()
+	}
+
+	var winner *fanOutOutcome
+	for range legs {
+		out := <-outcomes
+		if out.cancelled {
+			out.resp.Message = "cancelled after " + winner.leg.method + " was approved"
+		}
+		healthBefore := o.monitor.GetHealth(out.leg.ep.proc.Name())
+		if out.resp.Code != model.Pending && !out.cancelled && !out.throttled {
+			o.recorder.RecordResponse(out.leg.ep.proc.Name(), o.healthResponse(req, out.resp))
+		}
+		healthAfter := o.monitor.GetHealth(out.leg.ep.proc.Name())
+
+		result.Attempts = append(result.Attempts, model.Attempt{
+			ProcessorName: out.leg.ep.proc.Name(),
+			Response:      out.resp,
+			RoutingReason: fmt.Sprintf("fan-out: best %s processor (health %.2f)",
+				out.leg.method, out.leg.ep.healthScore),
+			AttemptNumber:  len(result.Attempts) + 1,
+			TraceID:        traceID,
+			PaymentMethod:  out.leg.method,
+			Classification: out.resp.Code.Classification(),
+			HealthBefore:   healthBefore.HealthScore,
+			HealthAfter:    healthAfter.HealthScore,
+			TimedOut:       out.timedOut,
+			Timestamp:      time.Now(),
+		})
+		slog.Info("fan_out_attempt",
+			"txn_id", req.TransactionID,
+			"trace_id", traceID,
+			"processor", out.leg.ep.proc.Name(),
+			"payment_method", out.leg.method,
+			"code", out.resp.Code,
+		)
+
+		if out.resp.Code == model.Approved && winner == nil {
+			winner = &out
+			cancelRace()
+		} else if out.resp.Code == model.Approved {
+			result.DuplicateApprovals = append(result.DuplicateApprovals, out.leg.ep.proc.Name())
+			slog.Error("fan_out_duplicate_approval",
+				"txn_id", req.TransactionID,
+				"trace_id", traceID,
+				"processor", out.leg.ep.proc.Name(),
+				"payment_method", out.leg.method,
+				"winner", winner.leg.ep.proc.Name(),
+			)
+		}
+	}
+
+	if winner != nil {
+		resp := winner.resp
+		result.Status = model.StatusApproved
+		result.WinningProcessor = winner.leg.ep.proc.Name()
+		result.FinalResponse = &resp
+		result.FeeCharged = processor.Fee(winner.leg.ep.proc, req.Amount, req.Currency)
+		result.NetAmount = math.Round((req.Amount-result.FeeCharged)*100) / 100
+		if o.cfg.FlagDegradedApprovals && winner.leg.ep.status != health.StatusHealthy {
+			result.ApprovedWhileDegraded = true
+		}
+		slog.Info("payment_approved",
+			"txn_id", req.TransactionID,
+			"trace_id", traceID,
+			"processor", result.WinningProcessor,
+			"payment_method", winner.leg.method,
+			"total_attempts", len(result.Attempts),
+		)
+		return o.complete(result, start)
+	}
+
+	for _, a := range result.Attempts {
+		if a.Response.Code == model.Pending {
+			resp := a.Response
+			result.Status = model.StatusPending
+			result.FinalResponse = &resp
+			return o.complete(result, start)
+		}
+	}
+
+	// Every leg failed: a decline only when no leg failed for a retriable reason
+	result.Status = model.StatusDeclined
+	for _, a := range result.Attempts {
+		if !a.Response.Code.IsHardDecline() {
+			result.Status = model.StatusExhaustedRetries
+			break
+		}
+	}
+	finalResp := o.finalResponse(result.Attempts)
+	result.FinalResponse = &finalResp
+	slog.Warn("fan_out_failed",
+		"txn_id", req.TransactionID,
+		"trace_id", traceID,
+		"status", result.Status,
+		"total_attempts", len(result.Attempts),
+	)
+	return o.complete(result, start)
+}
@@ -0,0 +1,160 @@
+package orchestrator
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/health"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/model"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/processor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFanOutProcessor(t *testing.T, name, method string, code model.ResponseCode, latency time.Duration) *processor.ScriptedProcessor {
+	t.Helper()
+	p, err := processor.NewScriptedProcessor(processor.ScriptedConfig{
+		ProcessorName: name,
+		Methods:       []string{method},
+		Codes:         []model.ResponseCode{code},
+		Latency:       latency,
+	})
+	require.NoError(t, err)
+	return p
+}
+
+func fanOutRequest(txnID string) model.PaymentRequest {
+	return model.PaymentRequest{
+		TransactionID: txnID,
+		Amount:        100.0,
+		Currency:      "BRL",
+		PaymentMethod: "card",
+		CustomerID:    "cust-1",
+		FanOutMethods: []string{"pix"},
+	}
+}
+
+func TestProcessFanOut_HardDeclineDoesNotAbortOtherMethod(t *testing.T) {
+	mon := health.NewMonitorWithConfig(50, 10*time.Minute)
+	card := newFanOutProcessor(t, "CardProc", "card", model.DeclinedFraud, 0)
+	pix := newFanOutProcessor(t, "PixProc", "pix", model.Approved, 30*time.Millisecond)
+	orch := New([]processor.Processor{card, pix}, mon)
+
+	result := orch.ProcessPayment(context.Background(), fanOutRequest("tx-fanout"))
+
+	assert.Equal(t, model.StatusApproved, result.Status)
+	assert.Equal(t, "PixProc", result.WinningProcessor)
+	require.Len(t, result.Attempts, 2)
+	assert.Equal(t, "card", result.Attempts[0].PaymentMethod)
+	assert.Equal(t, model.DeclinedFraud, result.Attempts[0].Response.Code)
+	assert.Equal(t, "pix", result.Attempts[1].PaymentMethod)
+	assert.Equal(t, model.Approved, result.Attempts[1].Response.Code)
+	assert.Equal(t, 1, card.CallCount())
+	assert.Equal(t, 1, pix.CallCount())
+}
+
+func TestProcessFanOut_CancelsSlowerLegs(t *testing.T) {
+	mon := health.NewMonitorWithConfig(50, 10*time.Minute)
+	card := newFanOutProcessor(t, "CardProc", "card", model.Approved, 0)
+	pix := newFanOutProcessor(t, "PixProc", "pix", model.Approved, time.Second)
+	orch := New([]processor.Processor{card, pix}, mon)
+
+	start := time.Now()
+	result := orch.ProcessPayment(context.Background(), fanOutRequest("tx-fanout-cancel"))
+
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, model.StatusApproved, result.Status)
+	assert.Equal(t, "CardProc", result.WinningProcessor)
+	require.Len(t, result.Attempts, 2)
+	assert.True(t, result.Attempts[1].TimedOut)
+	assert.Contains(t, result.Attempts[1].Response.Message, "cancelled after card")
+	// The cancelled leg is not held against its processor
+	assert.Equal(t, 0, mon.GetHealth("PixProc").TotalRecent)
+}
+
+func TestProcessFanOut_AllLegsFail(t *testing.T) {
+	tests := []struct {
+		name     string
+		pixCode  model.ResponseCode
+		wantStat model.PaymentStatus
+	}{
+		{"all hard declines", model.DeclinedInsufficientFunds, model.StatusDeclined},
+		{"transient failure", model.Timeout, model.StatusExhaustedRetries},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mon := health.NewMonitorWithConfig(50, 10*time.Minute)
+			orch := New([]processor.Processor{
+				newFanOutProcessor(t, "CardProc", "card", model.DeclinedFraud, 0),
+				newFanOutProcessor(t, "PixProc", "pix", tt.pixCode, 0),
+			}, mon)
+
+			result := orch.ProcessPayment(context.Background(), fanOutRequest("tx-fanout-fail"))
+
+			assert.Equal(t, tt.wantStat, result.Status)
+			assert.Len(t, result.Attempts, 2)
+			assert.NotNil(t, result.FinalResponse)
+		})
+	}
+}
+
+func TestProcessFanOut_FlagsDuplicateApprovals(t *testing.T) {
+	logs := captureJSONLogs(t, slog.LevelDebug)
+	// Neither processor honours cancellation, so both legs approve
+	orch := New([]processor.Processor{
+		newDeterministicProcessor("CardProc", []string{"card"}, model.Approved),
+		newDeterministicProcessor("PixProc", []string{"pix"}, model.Approved),
+	}, health.NewMonitorWithConfig(50, 10*time.Minute))
+
+	result := orch.ProcessPayment(context.Background(), fanOutRequest("tx-fanout-dup"))
+
+	assert.Equal(t, model.StatusApproved, result.Status)
+	require.Len(t, result.Attempts, 2)
+	require.Len(t, result.DuplicateApprovals, 1)
+	assert.NotEqual(t, result.WinningProcessor, result.DuplicateApprovals[0])
+
+	dup := logLines(t, logs, "fan_out_duplicate_approval")
+	require.Len(t, dup, 1)
+	assert.Equal(t, "ERROR", dup[0]["level"])
+	assert.Equal(t, result.DuplicateApprovals[0], dup[0]["processor"])
+	assert.Equal(t, result.WinningProcessor, dup[0]["winner"])
+}
+
+func TestProcessFanOut_LogsRoutingDecision(t *testing.T) {
+	logs := captureJSONLogs(t, slog.LevelDebug)
+	orch := New([]processor.Processor{
+		newFanOutProcessor(t, "CardProc", "card", model.DeclinedFraud, 0),
+		newFanOutProcessor(t, "PixProc", "pix", model.Approved, 0),
+	}, health.NewMonitorWithConfig(50, 10*time.Minute))
+
+	orch.ProcessPayment(context.Background(), fanOutRequest("tx-fanout-decision"))
+
+	decisions := logLines(t, logs, "routing_decision")
+	require.Len(t, decisions, 1)
+	candidates := decisions[0]["candidates"].([]any)
+	require.Len(t, candidates, 2)
+	assert.Equal(t, "CardProc", candidates[0].(map[string]any)["processor"])
+	assert.Equal(t, "PixProc", candidates[1].(map[string]any)["processor"])
+	assert.Equal(t, float64(2), decisions[0]["filters"].(map[string]any)["supported"])
+	assert.Equal(t, "approved", decisions[0]["status"])
+	assert.Equal(t, "PixProc", decisions[0]["winner"])
+}
+
+func TestApplyCallback_FanOutKeepsPendingLegMethod(t *testing.T) {
+	orch := New([]processor.Processor{
+		newFanOutProcessor(t, "CardProc", "card", model.DeclinedFraud, 0),
+		newFanOutProcessor(t, "PixProc", "pix", model.Pending, 0),
+	}, health.NewMonitorWithConfig(50, 10*time.Minute))
+	pending := orch.ProcessPayment(context.Background(), fanOutRequest("tx-fanout-callback"))
+	require.Equal(t, model.StatusPending, pending.Status)
+
+	result, err := orch.ApplyCallback("tx-fanout-callback", model.ProcessorResponse{Code: model.Approved})
+	require.NoError(t, err)
+	require.Len(t, result.Attempts, 3)
+	callback := result.Attempts[2]
+	assert.Equal(t, "PixProc", callback.ProcessorName)
+	assert.Equal(t, "pix", callback.PaymentMethod)
+}
@@ -0,0 +1,56 @@
+package orchestrator
+
+import (
+	"sort"
+	"sync/atomic"
+
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/processor"
+)
+
+// loadTracker counts the processor calls currently in flight per processor. The
+// counters are created up front, so lookups need no lock.
+type loadTracker struct {
+	inFlight map[string]*atomic.Int64
+}
+
+func newLoadTracker(processors []processor.Processor) *loadTracker {
+	t := &loadTracker{inFlight: make(map[string]*atomic.Int64, len(processors))}
+	for _, p := range processors {
+		t.inFlight[p.Name()] = new(atomic.Int64)
+	}
+	return t
+}
+
+// begin counts a call to name as in flight until the returned func is called.
+func (t *loadTracker) begin(name string) func() {
+	counter, ok := t.inFlight[name]
+	if !ok {
+		return func() {}
+	}
+	counter.Add(1)
+	return func() { counter.Add(-1) }
+}
+
+// load returns the number of calls to name in flight.
+func (t *loadTracker) load(name string) int64 {
+	if counter, ok := t.inFlight[name]; ok {
+		return counter.Load()
+	}
+	return 0
+}
+
+// sortByLoad orders processors whose routing score is within band of the best
+// by in-flight calls ascending, then by routing score; the rest follow by routing
+// score. eligible must already be sorted by routing score descending.
+func sortByLoad(eligible []eligibleProcessor, band float64) {
+	if len(eligible) == 0 {
+		return
+	}
+	floor := eligible[0].routingScore() - band
+	inBand := sort.Search(len(eligible), func(i int) bool {
+		return eligible[i].routingScore() < floor
+	})
+	sort.SliceStable(eligible[:inBand], func(i, j int) bool {
+		return eligible[i].inFlight < eligible[j].inFlight
+	})
+}
@@ -0,0 +1,119 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/health"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/model"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/processor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortByLoad(t *testing.T) {
+	ep := func(name string, score float64, inFlight int64) eligibleProcessor {
+		return eligibleProcessor{
+			proc:        newDeterministicProcessor(name, []string{"card"}, model.Approved),
+			healthScore: score,
+			inFlight:    inFlight,
+		}
+	}
+	tests := []struct {
+		name     string
+		eligible []eligibleProcessor
+		band     float64
+		want     []string
+	}{
+		{
+			name:     "least loaded first within the band",
+			eligible: []eligibleProcessor{ep("ProcA", 1.0, 3), ep("ProcB", 0.95, 1), ep("ProcC", 0.92, 2)},
+			band:     0.1,
+			want:     []string{"ProcB", "ProcC", "ProcA"},
+		},
+		{
+			name:     "processors outside the band keep health order",
+			eligible: []eligibleProcessor{ep("ProcA", 1.0, 3), ep("ProcB", 0.95, 1), ep("ProcC", 0.5, 0)},
+			band:     0.1,
+			want:     []string{"ProcB", "ProcA", "ProcC"},
+		},
+		{
+			name:     "equal load keeps health order",
+			eligible: []eligibleProcessor{ep("ProcA", 1.0, 1), ep("ProcB", 0.95, 1)},
+			band:     0.1,
+			want:     []string{"ProcA", "ProcB"},
+		},
+		{
+			name:     "zero band only balances ties",
+			eligible: []eligibleProcessor{ep("ProcA", 1.0, 2), ep("ProcB", 1.0, 0), ep("ProcC", 0.95, 0)},
+			band:     0,
+			want:     []string{"ProcB", "ProcA", "ProcC"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sortByLoad(tt.eligible, tt.band)
+			var names []string
+			for _, ep := range tt.eligible {
+				names = append(names, ep.proc.Name())
+			}
+			assert.Equal(t, tt.want, names)
+		})
+	}
+}
+
+func TestProcessPayment_LeastLoadedRoutingSpreadsConcurrentPayments(t *testing.T) {
+	run := func(t *testing.T, leastLoaded bool) map[string]int {
+		var procs []processor.Processor
+		for _, name := range []string{"ProcA", "ProcB", "ProcC"} {
+			proc, err := processor.NewScriptedProcessor(processor.ScriptedConfig{
+				ProcessorName: name,
+				Methods:       []string{"card"},
+				Codes:         []model.ResponseCode{model.Approved},
+				Latency:       50 * time.Millisecond,
+			})
+			require.NoError(t, err)
+			procs = append(procs, proc)
+		}
+		cfg := DefaultConfig()
+		cfg.LeastLoadedRouting = leastLoaded
+		orch := NewWithConfig(procs, health.NewMonitorWithConfig(50, 10*time.Minute), cfg)
+
+		var mu sync.Mutex
+		wins := make(map[string]int)
+		var wg sync.WaitGroup
+		for i := 0; i < 30; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+					TransactionID: fmt.Sprintf("tx-load-%d", i),
+					Amount:        100.0,
+					Currency:      "USD",
+					PaymentMethod: "card",
+					CustomerID:    "cust-1",
+				})
+				mu.Lock()
+				wins[result.WinningProcessor]++
+				mu.Unlock()
+			}()
+			// Stagger arrivals so each routing decision sees the calls in flight
+			time.Sleep(2 * time.Millisecond)
+		}
+		wg.Wait()
+		return wins
+	}
+
+	concentrated := run(t, false)
+	assert.Len(t, concentrated, 1, "health ordering alone sends every payment to the same processor")
+
+	spread := run(t, true)
+	require.Len(t, spread, 3)
+	for name, n := range spread {
+		assert.GreaterOrEqual(t, n, 5, name)
+		assert.LessOrEqual(t, n, 15, name)
+	}
+}
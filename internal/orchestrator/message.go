@@ -0,0 +1,60 @@
+package orchestrator
+
+import (
+	"fmt"
+
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/model"
+)
+
+// DefaultDeclineReasons are the client-facing reasons for failed attempts used in
+// PaymentResult.Message when Config.DeclineReasons has no entry for the code.
+var DefaultDeclineReasons = map[model.ResponseCode]string{
+	model.SoftDecline:               "declined by the issuer, try again",
+	model.DeclinedInsufficientFunds: "insufficient funds",
+	model.DeclinedFraud:             "declined by risk checks",
+	model.ProcessorError:            "processor error",
+	model.Timeout:                   "processor timed out",
+	model.RateLimited:               "processor busy",
+}
+
+// declineReason returns the client-facing reason for a response code.
+func (o *Orchestrator) declineReason(code model.ResponseCode) string {
+	if reason, ok := o.cfg.DeclineReasons[code]; ok {
+		return reason
+	}
+	if reason, ok := DefaultDeclineReasons[code]; ok {
+		return reason
+	}
+	return string(code)
+}
+
+// clientMessage summarizes a completed payment for the client, e.g. "declined
+// after 3 attempts; last reason: insufficient funds".
+func (o *Orchestrator) clientMessage(result model.PaymentResult) string {
+	attempts := pluralAttempts(len(result.Attempts))
+	switch result.Status {
+	case model.StatusApproved:
+		return "approved after " + attempts
+	case model.StatusPending:
+		return "pending after " + attempts + "; awaiting the processor's final outcome"
+	}
+
+	prefix := "declined"
+	if result.Status == model.StatusExhaustedRetries {
+		prefix = "retries exhausted"
+	}
+	if result.FinalResponse == nil {
+		if result.Reason != "" {
+			return prefix + ": " + result.Reason
+		}
+		return prefix
+	}
+	return fmt.Sprintf("%s after %s; last reason: %s", prefix, attempts, o.declineReason(result.FinalResponse.Code))
+}
+
+func pluralAttempts(n int) string {
+	if n == 1 {
+		return "1 attempt"
+	}
+	return fmt.Sprintf("%d attempts", n)
+}
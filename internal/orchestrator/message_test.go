@@ -0,0 +1,73 @@
+package orchestrator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/health"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/model"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/processor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessPayment_ClientMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		codes   []model.ResponseCode
+		reasons map[model.ResponseCode]string
+		want    string
+	}{
+		{"approved on first try", []model.ResponseCode{model.Approved}, nil, "approved after 1 attempt"},
+		{"approved after fallback", []model.ResponseCode{model.SoftDecline, model.Approved}, nil, "approved after 2 attempts"},
+		{"hard decline", []model.ResponseCode{model.DeclinedInsufficientFunds, model.Approved}, nil,
+			"declined after 1 attempt; last reason: insufficient funds"},
+		{"exhausted", []model.ResponseCode{model.Timeout, model.Timeout, model.Timeout}, nil,
+			"retries exhausted after 3 attempts; last reason: processor timed out"},
+		{"configured reason", []model.ResponseCode{model.DeclinedFraud}, map[model.ResponseCode]string{model.DeclinedFraud: "do not honor"},
+			"declined after 1 attempt; last reason: do not honor"},
+		{"no eligible processor", nil, nil, "declined: no processor supports payment method card"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			procs := make([]processor.Processor, 0, len(tt.codes))
+			for i, code := range tt.codes {
+				procs = append(procs, newDeterministicProcessor(string(rune('A'+i))+"Proc", []string{"card"}, code))
+			}
+			cfg := DefaultConfig()
+			cfg.DeclineReasons = tt.reasons
+			orch := NewWithConfig(procs, health.NewMonitorWithConfig(50, 10*time.Minute), cfg)
+
+			result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+				TransactionID: "tx-message",
+				Amount:        100.0,
+				Currency:      "USD",
+				PaymentMethod: "card",
+				CustomerID:    "cust-1",
+			})
+
+			assert.Equal(t, tt.want, result.Message)
+			stored, _ := orch.GetPaymentHistory("tx-message")
+			assert.Equal(t, tt.want, stored.Message)
+		})
+	}
+}
+
+func TestResolvePending_UpdatesClientMessage(t *testing.T) {
+	orch := New([]processor.Processor{
+		newDeterministicProcessor("ProcA", []string{"card"}, model.Pending),
+	}, health.NewMonitorWithConfig(50, 10*time.Minute))
+	result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+		TransactionID: "tx-message-pending",
+		Amount:        100.0,
+		Currency:      "USD",
+		PaymentMethod: "card",
+		CustomerID:    "cust-1",
+	})
+	assert.Equal(t, "pending after 1 attempt; awaiting the processor's final outcome", result.Message)
+
+	resolved, err := orch.ResolvePending("tx-message-pending", model.ProcessorResponse{Code: model.DeclinedFraud})
+	assert.NoError(t, err)
+	assert.Equal(t, "declined after 1 attempt; last reason: declined by risk checks", resolved.Message)
+}
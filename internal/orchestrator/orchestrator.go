@@ -2,138 +2,878 @@ package orchestrator
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math"
+	"math/rand"
+	"runtime/debug"
+	"slices"
 	"sort"
 	"sync"
 	"time"
 
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/apikey"
 	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/config"
 	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/health"
 	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/model"
 	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/processor"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/trace"
 )
 
 // Orchestrator routes payments through multiple processors with retry logic.
 type Orchestrator struct {
 	processors []processor.Processor
 	monitor    *health.Monitor
+	recorder   health.Recorder
 	store      *PaymentStore
-	maxRetries int
+	events     *EventBus
+	retryDepth *RetryDepthStats
+	methods    *MethodStats
+	sampler    *LogSampler
+	disabled   *disabledSet
+	limiter    *TokenBucket
+	admission  *admission
+	load       *loadTracker
+	cfg        Config
+
+	rngMu sync.Mutex
+	rng   *rand.Rand
+}
+
+// Config holds the tunable orchestration settings.
+type Config struct {
+	// MaxRetries is the maximum number of attempts across all processors.
+	MaxRetries int
+	// MethodMaxRetries overrides MaxRetries per payment method. Methods not in
+	// the map use MaxRetries.
+	MethodMaxRetries map[string]int
+	// FailFastCurrencies lists currencies whose payments get a single attempt, e.g.
+	// where every retry costs a cross-border fee. They take precedence over
+	// MethodMaxRetries.
+	FailFastCurrencies []string
+	// MaxProcessorsPerPayment caps how many candidates a payment is routed to, keeping
+	// the healthiest. Zero means no cap.
+	MaxProcessorsPerPayment int
+	// SameProcessorRetries is how many times a processor is retried after a timeout
+	// or processor error before falling back. Each retry counts against MaxRetries.
+	SameProcessorRetries int
+	// HardDeclineRetries allows a hard decline code to fall back to a different
+	// processor up to the given number of extra times per payment (e.g. retry
+	// insufficient funds once). Codes not in the map, including fraud by default,
+	// stop the payment immediately.
+	HardDeclineRetries map[model.ResponseCode]int
+	// RetryBackoff is the wait before retrying the same processor.
+	RetryBackoff time.Duration
+	// AttemptTimeout bounds each processor call. Zero means no per-attempt limit.
+	AttemptTimeout time.Duration
+	// ProcessorTimeouts overrides the timeout a processor declares through
+	// processor.TimeoutProvider. The effective deadline of a call is the shortest
+	// of the processor timeout, AttemptTimeout and the request context's deadline.
+	ProcessorTimeouts map[string]time.Duration
+	// Canary, when set, promotes a processor to primary for a share of eligible traffic.
+	Canary *CanaryConfig
+	// ProcessorAddedAt records when processors were added. Within WarmUpRamp of that
+	// time a processor only stays primary for a share of the payments it would
+	// lead, rising linearly from 0 to 1 over the ramp, to limit the blast radius
+	// of a new integration. Processors not in the map are fully warm. The server
+	// does not set it; it is for programs embedding the orchestrator.
+	ProcessorAddedAt map[string]time.Time
+	WarmUpRamp       time.Duration
+	// RecoveryPenalty withholds a share (0-1) of a processor's health score from
+	// routing once its circuit closes again, decaying linearly to nothing over
+	// RecoveryDecay, so a just-recovered upstream isn't slammed with primary
+	// traffic. Zero RecoveryDecay disables it.
+	RecoveryPenalty float64
+	RecoveryDecay   time.Duration
+	// PrimaryMinScore is the health score a primary processor must reach to not be
+	// reported as a degraded primary. Zero disables the check.
+	PrimaryMinScore float64
+	// ExtendRetriesPastWeakPrimary raises the attempt budget, when the primary
+	// scores below PrimaryMinScore, far enough to reach the first candidate that
+	// meets it.
+	ExtendRetriesPastWeakPrimary bool
+	// Seed seeds the routing RNG. Zero seeds from the current time.
+	Seed int64
+	// OpenCircuitPolicy decides whether open-circuit processors are skipped or kept
+	// as lowest-priority candidates. Empty means OpenCircuitSkip.
+	OpenCircuitPolicy OpenCircuitPolicy
+	// AutoDisableOpenCircuits takes a processor out of routing entirely, including
+	// as last resort or penalized candidate, when its circuit opens, and restores
+	// it once the circuit leaves the open state.
+	AutoDisableOpenCircuits bool
+	// LastResort names a processor that is always tried as the final attempt,
+	// even when its circuit is open. Empty disables the safety net.
+	LastResort string
+	// CostAwareRouting orders processors within the same health status by their fee
+	// for the payment's currency (cheapest first), then by health score.
+	CostAwareRouting bool
+	// HealthWeight and LatencyWeight, when LatencyWeight is positive, rank processors
+	// by HealthWeight*healthScore - LatencyWeight*normalizedLatency instead of health
+	// alone, where normalizedLatency is the average latency in the health window
+	// relative to the slowest candidate. CostAwareRouting takes precedence.
+	HealthWeight  float64
+	LatencyWeight float64
+	// LeastLoadedRouting ranks processors whose health score is within LoadHealthBand
+	// of the healthiest by their in-flight calls, fewest first, to spread bursts
+	// across comparable processors. CostAwareRouting and LatencyWeight take precedence.
+	LeastLoadedRouting bool
+	LoadHealthBand     float64
+	// FlagDegradedApprovals marks approvals from processors that were degraded or
+	// circuit-open when routed with PaymentResult.ApprovedWhileDegraded.
+	FlagDegradedApprovals bool
+	// ApprovalLatencySLA, when positive, records approvals slower than this against
+	// the processor's health as a timeout. The payment itself stays approved.
+	ApprovalLatencySLA time.Duration
+	// SoftDeclineHealthierOnly stops a payment after a soft decline unless the next
+	// candidate's health score is at least that of the processor that declined.
+	SoftDeclineHealthierOnly bool
+	// FinalResponsePolicy selects which attempt's response becomes FinalResponse when
+	// retries are exhausted. Empty means FinalResponseLastAttempt.
+	FinalResponsePolicy FinalResponsePolicy
+	// AttemptLogSampleRate logs attempt detail at Info for one in every N payments,
+	// and at Debug for the rest. Zero or one logs every payment at Info.
+	AttemptLogSampleRate int
+	// DeclineReasons overrides the client-facing reason given for a response code in
+	// PaymentResult.Message. Codes not in the map use DefaultDeclineReasons.
+	DeclineReasons map[model.ResponseCode]string
+	// ProcessorAllowList restricts the API key a payment's context carries (see the
+	// apikey package) to the named processors. Keys not in the map, including a
+	// missing key, may use every processor unless RejectUnknownAPIKeys is set, in
+	// which case their payments are declined. An empty map disables the check.
+	ProcessorAllowList   map[string][]string
+	RejectUnknownAPIKeys bool
+	// MaxConcurrentPayments, when positive, caps how many payments are processed at
+	// once. Further payments wait in a queue of up to PaymentQueueDepth; beyond that,
+	// or if their context ends while queued, they are rejected without attempting any
+	// processor, as retries exhausted with a RateLimited final response.
+	MaxConcurrentPayments int
+	PaymentQueueDepth     int
+	// OutboundQPS, when positive, caps processor calls across all payments with a
+	// token bucket refilling at this rate, holding up to OutboundBurst tokens. A
+	// call that cannot get a token within its attempt deadline is recorded as a
+	// RateLimited attempt without calling the processor.
+	OutboundQPS   float64
+	OutboundBurst int
+	// HealthRecorder receives attempt outcomes, e.g. a health.BatchedRecorder wrapping
+	// the monitor to reduce lock contention. Nil records directly on the monitor.
+	HealthRecorder health.Recorder
+}
+
+// OpenCircuitPolicy selects how routing treats processors whose circuit is open.
+type OpenCircuitPolicy string
+
+const (
+	// OpenCircuitSkip removes open-circuit processors from routing.
+	OpenCircuitSkip OpenCircuitPolicy = "skip"
+	// OpenCircuitPenalize keeps open-circuit processors eligible but ranks them after
+	// every closed-circuit processor, so they are only tried as a last resort.
+	OpenCircuitPenalize OpenCircuitPolicy = "penalize"
+)
+
+// FinalResponsePolicy selects the final response of a payment that exhausted its retries.
+type FinalResponsePolicy string
+
+const (
+	// FinalResponseLastAttempt reports the last attempt's response.
+	FinalResponseLastAttempt FinalResponsePolicy = "last_attempt"
+	// FinalResponseMostInformative reports the most informative response across
+	// attempts: business declines over soft declines over transient errors, the
+	// latest winning ties.
+	FinalResponseMostInformative FinalResponsePolicy = "most_informative"
+)
+
+// CanaryConfig sends a percentage of eligible payments to a processor as primary.
+// Failed canary attempts fall back through the normal health ordering.
+type CanaryConfig struct {
+	ProcessorName string
+	// Percentage is the share of eligible payments (0-100) routed to the canary first.
+	Percentage float64
+}
+
+// DefaultConfig returns the orchestration settings defined in the config package.
+func DefaultConfig() Config {
+	return Config{
+		MaxRetries:               config.MaxRetries,
+		MaxProcessorsPerPayment:  config.MaxProcessorsPerPayment,
+		SameProcessorRetries:     config.SameProcessorRetries,
+		RetryBackoff:             time.Duration(config.SameProcessorBackoffMillis) * time.Millisecond,
+		AttemptTimeout:           time.Duration(config.AttemptTimeoutMillis) * time.Millisecond,
+		PrimaryMinScore:          config.PrimaryMinScore,
+		HealthWeight:             config.RoutingHealthWeight,
+		LatencyWeight:            config.RoutingLatencyWeight,
+		OpenCircuitPolicy:        OpenCircuitPolicy(config.OpenCircuitPolicy),
+		SoftDeclineHealthierOnly: config.SoftDeclineHealthierOnly,
+		LastResort:               config.LastResortProcessor,
+		FinalResponsePolicy:      FinalResponsePolicy(config.FinalResponsePolicy),
+		AttemptLogSampleRate:     config.AttemptLogSampleRate,
+		WarmUpRamp:               time.Duration(config.WarmUpRampMinutes) * time.Minute,
+		RecoveryPenalty:          config.RecoveryPenalty,
+		RecoveryDecay:            time.Duration(config.RecoveryDecayMinutes) * time.Minute,
+		MaxConcurrentPayments:    config.MaxConcurrentPayments,
+		PaymentQueueDepth:        config.PaymentQueueDepth,
+		OutboundQPS:              config.OutboundQPS,
+		OutboundBurst:            config.OutboundBurst,
+		RejectUnknownAPIKeys:     config.RejectUnknownAPIKeys,
+		LeastLoadedRouting:       config.LeastLoadedRouting,
+		LoadHealthBand:           config.LoadHealthBand,
+	}
 }
 
 // New creates a new Orchestrator with the given processors and health monitor.
 func New(processors []processor.Processor, monitor *health.Monitor) *Orchestrator {
-	return &Orchestrator{
+	return NewWithConfig(processors, monitor, DefaultConfig())
+}
+
+// NewWithConfig creates an Orchestrator with custom orchestration settings.
+func NewWithConfig(processors []processor.Processor, monitor *health.Monitor, cfg Config) *Orchestrator {
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	var recorder health.Recorder = monitor
+	if cfg.HealthRecorder != nil {
+		recorder = cfg.HealthRecorder
+	}
+	o := &Orchestrator{
 		processors: processors,
 		monitor:    monitor,
+		recorder:   recorder,
 		store:      NewPaymentStore(),
-		maxRetries: config.MaxRetries,
+		events:     NewEventBus(),
+		retryDepth: NewRetryDepthStats(),
+		methods:    NewMethodStats(),
+		sampler:    NewLogSampler(cfg.AttemptLogSampleRate),
+		disabled:   newDisabledSet(),
+		load:       newLoadTracker(processors),
+		admission:  newAdmission(cfg.MaxConcurrentPayments, cfg.PaymentQueueDepth),
+		cfg:        cfg,
+		rng:        rand.New(rand.NewSource(seed)),
+	}
+	for _, p := range processors {
+		if rate := processor.ExpectedApprovalRate(p); rate > 0 {
+			monitor.SetApprovalTarget(p.Name(), rate)
+		}
+	}
+	if cfg.OutboundQPS > 0 {
+		o.limiter = NewTokenBucket(cfg.OutboundQPS, cfg.OutboundBurst)
+	}
+	if cfg.AutoDisableOpenCircuits {
+		monitor.OnStatusChange(o.handleStatusChange)
 	}
+	if cfg.RecoveryDecay > 0 {
+		monitor.TrackRecoveries()
+	}
+	return o
 }
 
 // ProcessPayment routes a payment request through available processors with retry logic.
 func (o *Orchestrator) ProcessPayment(ctx context.Context, req model.PaymentRequest) model.PaymentResult {
+	start := time.Now()
+	traceID := trace.TraceIDFromContext(ctx)
 	result := model.PaymentResult{
 		TransactionID: req.TransactionID,
 		Attempts:      make([]model.Attempt, 0),
+		Request:       req,
+	}
+
+	release, err := o.admission.acquire(ctx)
+	if err != nil {
+		return o.rejectOverloaded(result, err, start)
+	}
+	defer release()
+
+	if len(req.FanOutMethods) > 0 {
+		return o.processFanOut(ctx, req, result, start)
 	}
 
 	// Get eligible processors sorted by health
-	eligible := o.getEligibleProcessors(req.PaymentMethod)
+	eligible, filtered := o.getEligibleProcessors(ctx, req)
+	defer func() { o.logRoutingDecision(ctx, req, eligible, filtered, result) }()
 	if len(eligible) == 0 {
+		result.Reason = filtered.declineReason(req.PaymentMethod)
 		slog.Warn("no_eligible_processors",
 			"txn_id", req.TransactionID,
+			"trace_id", traceID,
 			"payment_method", req.PaymentMethod,
+			"reason", result.Reason,
 		)
 		result.Status = model.StatusDeclined
-		o.store.Save(result)
-		return result
+		return o.complete(result, start)
 	}
 
+	// Attempt detail is sampled; failures and circuit transitions always log
+	detailLevel := slog.LevelDebug
+	if o.sampler.Sample() {
+		detailLevel = slog.LevelInfo
+	}
+
+	maxRetries := o.attemptBudget(req, eligible)
 	attemptNum := 0
-	for _, ep := range eligible {
-		if attemptNum >= o.maxRetries {
+	hardDeclineRetries := make(map[model.ResponseCode]int)
+	// softDeclinedBy is the candidate whose soft decline the next candidate falls back from
+	var softDeclinedBy *eligibleProcessor
+candidates:
+	for i, ep := range eligible {
+		if i > 0 && o.circuitOpenedSinceRouting(ep) {
+			slog.Warn("fallback_skipped_circuit_open",
+				"txn_id", req.TransactionID,
+				"trace_id", traceID,
+				"processor", ep.proc.Name(),
+				"attempt", attemptNum,
+			)
+			result.SkippedProcessors = append(result.SkippedProcessors, model.SkipInfo{
+				ProcessorName: ep.proc.Name(),
+				Reason:        "circuit opened during the payment",
+				Timestamp:     time.Now(),
+			})
+			continue
+		}
+		if o.cfg.SoftDeclineHealthierOnly && softDeclinedBy != nil && ep.healthScore < softDeclinedBy.healthScore {
+			slog.Warn("soft_decline_fallback_stopped",
+				"txn_id", req.TransactionID,
+				"trace_id", traceID,
+				"declined_by", softDeclinedBy.proc.Name(),
+				"declined_health_score", fmt.Sprintf("%.2f", softDeclinedBy.healthScore),
+				"next_processor", ep.proc.Name(),
+				"next_health_score", fmt.Sprintf("%.2f", ep.healthScore),
+			)
+			result.Reason = fmt.Sprintf("soft decline not retried: next processor %s is less healthy than %s",
+				ep.proc.Name(), softDeclinedBy.proc.Name())
 			break
 		}
-		attemptNum++
+		softDeclinedBy = nil
+		for try := 0; try <= o.cfg.SameProcessorRetries; try++ {
+			if attemptNum >= maxRetries {
+				break candidates
+			}
+			if try > 0 && !waitBackoff(ctx, o.cfg.RetryBackoff) {
+				break candidates
+			}
+			attemptNum++
 
-		reason := o.buildRoutingReason(ep, attemptNum, &result)
+			reason := o.buildRoutingReason(ep, attemptNum, try > 0, &result)
 
-		slog.Info("payment_attempt",
-			"txn_id", req.TransactionID,
-			"processor", ep.proc.Name(),
-			"attempt", attemptNum,
-			"reason", reason,
-			"health_score", fmt.Sprintf("%.2f", ep.healthScore),
-		)
+			slog.Log(ctx, detailLevel, "payment_attempt",
+				"txn_id", req.TransactionID,
+				"trace_id", traceID,
+				"processor", ep.proc.Name(),
+				"attempt", attemptNum,
+				"reason", reason,
+				"health_score", fmt.Sprintf("%.2f", ep.healthScore),
+			)
 
-		resp := ep.proc.Process(ctx, req)
+			attemptCtx, cancel := o.attemptContext(ctx, ep.proc)
+			callStart := time.Now()
+			resp, throttled := o.callLimited(attemptCtx, ep.proc, req, traceID)
+			// A call we cut short is a timeout on our side, however the processor reported it
+			timedOut := !throttled && cutShort(attemptCtx, resp)
+			if timedOut {
+				resp.Code = model.Timeout
+				resp.Latency = time.Since(callStart)
+			}
+			cancel()
 
-		attempt := model.Attempt{
-			ProcessorName: ep.proc.Name(),
-			Response:      resp,
-			RoutingReason: reason,
-			AttemptNumber: attemptNum,
-			Timestamp:     time.Now(),
-		}
-		result.Attempts = append(result.Attempts, attempt)
+			// Record outcome for health monitoring, capturing how it moved the score
+			// Pending outcomes say nothing about processor health until resolved,
+			// and throttled calls never reached the processor
+			healthBefore := o.monitor.GetHealth(ep.proc.Name())
+			if resp.Code != model.Pending && !throttled {
+				o.recorder.RecordResponse(ep.proc.Name(), o.healthResponse(req, resp))
+			}
+			healthAfter := o.monitor.GetHealth(ep.proc.Name())
+			if healthAfter.Status != healthBefore.Status {
+				slog.Warn("processor_status_changed",
+					"processor", ep.proc.Name(),
+					"from", healthBefore.Status,
+					"to", healthAfter.Status,
+					"health_score", fmt.Sprintf("%.2f", healthAfter.HealthScore),
+				)
+			}
 
-		// Record outcome for health monitoring
-		o.monitor.RecordOutcome(ep.proc.Name(), resp.Code)
+			attempt := model.Attempt{
+				ProcessorName:  ep.proc.Name(),
+				Response:       resp,
+				RoutingReason:  reason,
+				AttemptNumber:  attemptNum,
+				TraceID:        traceID,
+				PaymentMethod:  req.PaymentMethod,
+				Classification: resp.Code.Classification(),
+				HealthBefore:   healthBefore.HealthScore,
+				HealthAfter:    healthAfter.HealthScore,
+				TimedOut:       timedOut,
+				Timestamp:      time.Now(),
+			}
+			result.Attempts = append(result.Attempts, attempt)
 
-		if resp.Code == model.Approved {
-			slog.Info("payment_approved",
-				"txn_id", req.TransactionID,
-				"processor", ep.proc.Name(),
-				"total_attempts", attemptNum,
-			)
-			result.Status = model.StatusApproved
-			result.FinalResponse = &resp
-			o.store.Save(result)
-			return result
-		}
+			if resp.Code == model.Approved {
+				slog.Log(ctx, detailLevel, "payment_approved",
+					"txn_id", req.TransactionID,
+					"trace_id", traceID,
+					"processor", ep.proc.Name(),
+					"total_attempts", attemptNum,
+				)
+				result.Status = model.StatusApproved
+				result.WinningProcessor = ep.proc.Name()
+				result.FinalResponse = &resp
+				result.FeeCharged = processor.Fee(ep.proc, req.Amount, req.Currency)
+				result.NetAmount = math.Round((req.Amount-result.FeeCharged)*100) / 100
+				if o.cfg.FlagDegradedApprovals && ep.status != health.StatusHealthy {
+					result.ApprovedWhileDegraded = true
+					slog.Warn("approved_while_degraded",
+						"txn_id", req.TransactionID,
+						"trace_id", traceID,
+						"processor", ep.proc.Name(),
+						"status", ep.status,
+						"health_score", fmt.Sprintf("%.2f", ep.healthScore),
+					)
+				}
+				return o.complete(result, start)
+			}
+
+			if resp.Code == model.Pending {
+				slog.Info("payment_pending",
+					"txn_id", req.TransactionID,
+					"trace_id", traceID,
+					"processor", ep.proc.Name(),
+					"total_attempts", attemptNum,
+				)
+				result.Status = model.StatusPending
+				result.FinalResponse = &resp
+				return o.complete(result, start)
+			}
+
+			if resp.Code.IsHardDecline() && hardDeclineRetries[resp.Code] < o.cfg.HardDeclineRetries[resp.Code] {
+				hardDeclineRetries[resp.Code]++
+				slog.Warn("hard_decline_retrying",
+					"txn_id", req.TransactionID,
+					"trace_id", traceID,
+					"processor", ep.proc.Name(),
+					"code", resp.Code,
+					"attempt", attemptNum,
+					"hard_decline_retry", hardDeclineRetries[resp.Code],
+				)
+				break
+			}
 
-		if resp.Code.IsHardDecline() {
-			slog.Warn("hard_decline_stopping",
+			if resp.Code.IsHardDecline() {
+				slog.Warn("hard_decline_stopping",
+					"txn_id", req.TransactionID,
+					"trace_id", traceID,
+					"processor", ep.proc.Name(),
+					"code", resp.Code,
+					"total_attempts", attemptNum,
+				)
+				result.Status = model.StatusDeclined
+				result.FinalResponse = &resp
+				return o.complete(result, start)
+			}
+
+			// Retriable failure — log and retry this processor or continue to the next one
+			slog.Warn("retriable_failure",
 				"txn_id", req.TransactionID,
+				"trace_id", traceID,
 				"processor", ep.proc.Name(),
 				"code", resp.Code,
-				"total_attempts", attemptNum,
+				"attempt", attemptNum,
 			)
-			result.Status = model.StatusDeclined
-			result.FinalResponse = &resp
-			o.store.Save(result)
-			return result
-		}
 
-		// Retriable failure — log and continue to next processor
-		slog.Warn("retriable_failure",
-			"txn_id", req.TransactionID,
-			"processor", ep.proc.Name(),
-			"code", resp.Code,
-			"attempt", attemptNum,
-		)
+			if resp.Code == model.SoftDecline {
+				softDeclinedBy = &eligible[i]
+			}
+			if !retriesSameProcessor(resp.Code) {
+				break
+			}
+		}
 	}
 
 	slog.Warn("retries_exhausted",
 		"txn_id", req.TransactionID,
+		"trace_id", traceID,
 		"total_attempts", attemptNum,
 	)
 	result.Status = model.StatusExhaustedRetries
 	if len(result.Attempts) > 0 {
 		lastResp := result.Attempts[len(result.Attempts)-1].Response
-		result.FinalResponse = &lastResp
+		finalResp := o.finalResponse(result.Attempts)
+		result.FinalResponse = &finalResp
+		// A retried hard decline that got no further approval stays a decline
+		if lastResp.Code.IsHardDecline() {
+			result.Status = model.StatusDeclined
+		}
+	}
+	return o.complete(result, start)
+}
+
+// circuitOpenedSinceRouting reports whether a candidate routed with a closed
+// circuit has had it open since, so it should no longer be tried. Candidates kept
+// despite an open circuit, as last resort or penalized, are still tried.
+func (o *Orchestrator) circuitOpenedSinceRouting(ep eligibleProcessor) bool {
+	if ep.status == health.StatusOpen || ep.lastResort || ep.penalized {
+		return false
+	}
+	return o.monitor.GetHealth(ep.proc.Name()).Status == health.StatusOpen
+}
+
+// informativeness ranks how much a failed response tells the client about why
+// the payment failed, for FinalResponseMostInformative.
+var informativeness = map[model.Classification]int{
+	model.ClassificationTransient:       1,
+	model.ClassificationSoft:            2,
+	model.ClassificationBusinessDecline: 3,
+}
+
+// finalResponse picks the FinalResponse of an exhausted payment per the
+// configured FinalResponsePolicy. attempts must not be empty.
+func (o *Orchestrator) finalResponse(attempts []model.Attempt) model.ProcessorResponse {
+	final := attempts[len(attempts)-1].Response
+	if o.cfg.FinalResponsePolicy != FinalResponseMostInformative {
+		return final
 	}
+	for i := len(attempts) - 2; i >= 0; i-- {
+		resp := attempts[i].Response
+		if informativeness[resp.Code.Classification()] > informativeness[final.Code.Classification()] {
+			final = resp
+		}
+	}
+	return final
+}
+
+// complete records the total latency of a terminal payment result, stores it and
+// publishes its PaymentCompleted event. A pending payment is counted in the
+// retry-depth and method stats only once resolved.
+func (o *Orchestrator) complete(result model.PaymentResult, start time.Time) model.PaymentResult {
+	result.TotalLatency = time.Since(start)
+	result.Message = o.clientMessage(result)
 	o.store.Save(result)
+	if result.Status.IsFinal() {
+		o.recordStats(result)
+	}
+	o.events.Publish(result)
 	return result
 }
 
+// recordStats counts a payment that reached its final status.
+func (o *Orchestrator) recordStats(result model.PaymentResult) {
+	o.retryDepth.Record(result)
+	o.methods.Record(result)
+}
+
+// rejectOverloaded answers a payment that was not admitted under
+// MaxConcurrentPayments. Nothing was attempted, so the result is neither stored
+// nor published, and the transaction can be retried as is.
+func (o *Orchestrator) rejectOverloaded(result model.PaymentResult, err error, start time.Time) model.PaymentResult {
+	slog.Warn("payment_rejected_overloaded",
+		"txn_id", result.TransactionID,
+		"max_concurrent_payments", o.cfg.MaxConcurrentPayments,
+		"queue_depth", o.cfg.PaymentQueueDepth,
+		"error", err,
+	)
+	result.Status = model.StatusExhaustedRetries
+	result.FinalResponse = &model.ProcessorResponse{
+		Code:      model.RateLimited,
+		Message:   "too many concurrent payments: " + err.Error(),
+		Timestamp: time.Now(),
+	}
+	result.Reason = "too many concurrent payments, try again later"
+	result.Message = "rejected: " + result.Reason
+	result.TotalLatency = time.Since(start)
+	return result
+}
+
+// Subscribe registers a function called with the final result of every payment.
+func (o *Orchestrator) Subscribe(fn func(model.PaymentResult)) {
+	o.events.Subscribe(fn)
+}
+
+// RetryDepth returns the lifetime distribution of attempts per terminal payment.
+func (o *Orchestrator) RetryDepth() RetryDepthSnapshot {
+	return o.retryDepth.Snapshot()
+}
+
+// MethodApprovals returns lifetime payment totals and approval rates per payment method.
+func (o *Orchestrator) MethodApprovals() map[string]MethodApproval {
+	return o.methods.Snapshot()
+}
+
+// attemptContext derives the context for one call to p, bounded by the shorter of
+// the processor's timeout and AttemptTimeout. The parent's deadline, the
+// remaining request budget, still applies when it is sooner.
+func (o *Orchestrator) attemptContext(ctx context.Context, p processor.Processor) (context.Context, context.CancelFunc) {
+	timeout, ok := o.cfg.ProcessorTimeouts[p.Name()]
+	if !ok {
+		timeout = processor.Timeout(p)
+	}
+	if o.cfg.AttemptTimeout > 0 && (timeout <= 0 || o.cfg.AttemptTimeout < timeout) {
+		timeout = o.cfg.AttemptTimeout
+	}
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// cutShort reports whether resp is a processor giving up because ctx ended: a
+// timeout or processor error once ctx is done. Approvals, declines and pending
+// outcomes returned at the deadline stand as returned, so a hard decline is never
+// turned into a retriable timeout.
+func cutShort(ctx context.Context, resp model.ProcessorResponse) bool {
+	return ctx.Err() != nil && (resp.Code == model.Timeout || resp.Code == model.ProcessorError)
+}
+
+// callProcessor calls p.Process, converting a panic into a ProcessorError
+// response so a faulty processor fails over like any other processor error.
+func callProcessor(ctx context.Context, p processor.Processor, req model.PaymentRequest, traceID string) (resp model.ProcessorResponse) {
+	start := time.Now()
+	defer func() {
+		rec := recover()
+		if rec == nil {
+			return
+		}
+		slog.Error("processor_panic",
+			"txn_id", req.TransactionID,
+			"trace_id", traceID,
+			"processor", p.Name(),
+			"panic", fmt.Sprint(rec),
+			"stack", string(debug.Stack()),
+		)
+		resp = model.ProcessorResponse{
+			ProcessorName: p.Name(),
+			Code:          model.ProcessorError,
+			Message:       fmt.Sprintf("processor panicked: %v", rec),
+			Timestamp:     time.Now(),
+			Latency:       time.Since(start),
+		}
+	}()
+	return p.Process(ctx, req)
+}
+
+// attemptBudget returns the attempt budget for a payment routed to eligible. A
+// primary below PrimaryMinScore is logged and, with ExtendRetriesPastWeakPrimary,
+// the budget grows to reach the first candidate meeting the minimum, except for
+// fail-fast currencies.
+func (o *Orchestrator) attemptBudget(req model.PaymentRequest, eligible []eligibleProcessor) int {
+	budget := o.maxRetriesFor(req)
+	if len(eligible) == 0 || !o.belowPrimaryMin(eligible[0]) {
+		return budget
+	}
+
+	slog.Warn("degraded_primary",
+		"txn_id", req.TransactionID,
+		"processor", eligible[0].proc.Name(),
+		"health_score", fmt.Sprintf("%.2f", eligible[0].healthScore),
+		"primary_min_score", fmt.Sprintf("%.2f", o.cfg.PrimaryMinScore),
+	)
+	if !o.cfg.ExtendRetriesPastWeakPrimary || o.failFast(req) {
+		return budget
+	}
+	for i, ep := range eligible[1:] {
+		if o.belowPrimaryMin(ep) {
+			continue
+		}
+		if needed := i + 2; needed > budget {
+			slog.Info("retry_budget_extended",
+				"txn_id", req.TransactionID,
+				"processor", ep.proc.Name(),
+				"max_retries", budget,
+				"effective_max_retries", needed,
+			)
+			budget = needed
+		}
+		break
+	}
+	return budget
+}
+
+func (o *Orchestrator) belowPrimaryMin(ep eligibleProcessor) bool {
+	return ep.healthScore < o.cfg.PrimaryMinScore
+}
+
+// maxRetriesFor returns the attempt budget for a request's currency and payment method.
+func (o *Orchestrator) maxRetriesFor(req model.PaymentRequest) int {
+	if o.failFast(req) {
+		return 1
+	}
+	if n, ok := o.cfg.MethodMaxRetries[req.PaymentMethod]; ok {
+		return n
+	}
+	return o.cfg.MaxRetries
+}
+
+// failFast reports whether req's currency gets a single attempt.
+func (o *Orchestrator) failFast(req model.PaymentRequest) bool {
+	return slices.Contains(o.cfg.FailFastCurrencies, req.Currency)
+}
+
 // GetPaymentHistory returns the payment result for a given transaction ID.
 func (o *Orchestrator) GetPaymentHistory(txnID string) (model.PaymentResult, bool) {
 	return o.store.Get(txnID)
 }
 
+// Errors returned by ResolvePending and ApplyCallback.
+var (
+	ErrPaymentNotFound   = errors.New("payment not found")
+	ErrPaymentNotPending = errors.New("payment is not pending")
+	ErrInvalidResolution = errors.New("response code is not a final outcome")
+	ErrProcessorMismatch = errors.New("callback processor does not match the pending attempt")
+)
+
+// ResolvePending applies the final outcome of a pending payment, moving it to
+// approved or declined as defined by model.ResolvedStatus. Payments that are not
+// pending are left unchanged and yield ErrPaymentNotPending.
+func (o *Orchestrator) ResolvePending(txnID string, resp model.ProcessorResponse) (model.PaymentResult, error) {
+	return o.resolvePending(txnID, resp, false)
+}
+
+// ApplyCallback resolves a pending payment from an asynchronous processor
+// callback like ResolvePending, additionally appending the callback as an
+// attempt. A callback naming a processor other than the one the payment is
+// pending with yields ErrProcessorMismatch.
+func (o *Orchestrator) ApplyCallback(txnID string, resp model.ProcessorResponse) (model.PaymentResult, error) {
+	return o.resolvePending(txnID, resp, true)
+}
+
+func (o *Orchestrator) resolvePending(txnID string, resp model.ProcessorResponse, appendAttempt bool) (model.PaymentResult, error) {
+	status, ok := model.ResolvedStatus(resp.Code)
+	if !ok {
+		return model.PaymentResult{}, fmt.Errorf("resolve %s with %s: %w", txnID, resp.Code, ErrInvalidResolution)
+	}
+
+	result, err := o.store.Update(txnID, func(r *model.PaymentResult) error {
+		if r.Status != model.StatusPending {
+			return fmt.Errorf("resolve %s: status %s: %w", txnID, r.Status, ErrPaymentNotPending)
+		}
+		var pendingWith string
+		if r.FinalResponse != nil {
+			pendingWith = r.FinalResponse.ProcessorName
+		}
+		if resp.ProcessorName == "" {
+			resp.ProcessorName = pendingWith
+		} else if resp.ProcessorName != pendingWith {
+			return fmt.Errorf("resolve %s: callback from %s, pending with %s: %w",
+				txnID, resp.ProcessorName, pendingWith, ErrProcessorMismatch)
+		}
+		if resp.Timestamp.IsZero() {
+			resp.Timestamp = time.Now()
+		}
+
+		if appendAttempt {
+			var traceID string
+			if n := len(r.Attempts); n > 0 {
+				traceID = r.Attempts[n-1].TraceID
+			}
+			// The callback settles the method the pending attempt charged, which
+			// for fan-out payments may not be the request's
+			method := r.Request.PaymentMethod
+			for i := len(r.Attempts) - 1; i >= 0; i-- {
+				if a := r.Attempts[i]; a.Response.Code == model.Pending && a.ProcessorName == resp.ProcessorName {
+					method = a.PaymentMethod
+					break
+				}
+			}
+			r.Attempts = append(r.Attempts, model.Attempt{
+				ProcessorName:  resp.ProcessorName,
+				Response:       resp,
+				RoutingReason:  "async callback",
+				AttemptNumber:  len(r.Attempts) + 1,
+				TraceID:        traceID,
+				PaymentMethod:  method,
+				Classification: resp.Code.Classification(),
+				Timestamp:      time.Now(),
+			})
+		}
+
+		r.Status = status
+		r.FinalResponse = &resp
+		r.Message = o.clientMessage(*r)
+		if status == model.StatusApproved {
+			r.WinningProcessor = resp.ProcessorName
+			if p := o.processorByName(resp.ProcessorName); p != nil {
+				r.FeeCharged = processor.Fee(p, r.Request.Amount, r.Request.Currency)
+			}
+			r.NetAmount = math.Round((r.Request.Amount-r.FeeCharged)*100) / 100
+		}
+		return nil
+	})
+	if err != nil {
+		return model.PaymentResult{}, err
+	}
+
+	// Record health outside the store lock: the recorder may run status-change hooks
+	if resp.ProcessorName != "" {
+		healthBefore := o.monitor.GetHealth(resp.ProcessorName)
+		o.recorder.RecordResponse(resp.ProcessorName, resp)
+		healthAfter := o.monitor.GetHealth(resp.ProcessorName)
+		if appendAttempt {
+			callback := &result.Attempts[len(result.Attempts)-1]
+			callback.HealthBefore = healthBefore.HealthScore
+			callback.HealthAfter = healthAfter.HealthScore
+			// A store reset meanwhile leaves nothing to update
+			o.store.Update(txnID, func(r *model.PaymentResult) error {
+				if n := len(r.Attempts); n > 0 {
+					r.Attempts[n-1].HealthBefore = healthBefore.HealthScore
+					r.Attempts[n-1].HealthAfter = healthAfter.HealthScore
+				}
+				return nil
+			})
+		}
+	}
+	o.recordStats(result)
+
+	slog.Info("pending_payment_resolved",
+		"txn_id", txnID,
+		"processor", resp.ProcessorName,
+		"code", resp.Code,
+		"status", status,
+		"callback", appendAttempt,
+	)
+	o.events.Publish(result)
+	return result, nil
+}
+
+// processorByName returns the registered processor with the given name, or nil.
+func (o *Orchestrator) processorByName(name string) processor.Processor {
+	for _, p := range o.processors {
+		if p.Name() == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// ResetSummary reports how much orchestrator state was cleared by Reset.
+type ResetSummary struct {
+	PaymentsCleared      int `json:"payments_cleared"`
+	HealthWindowsCleared int `json:"health_windows_cleared"`
+}
+
+// Reset clears the payment store and all health windows. Buffered outcomes are
+// flushed first so they don't reappear after the reset.
+func (o *Orchestrator) Reset() ResetSummary {
+	if f, ok := o.recorder.(interface{ Flush() }); ok {
+		f.Flush()
+	}
+	o.retryDepth.Reset()
+	o.methods.Reset()
+	o.disabled.reset()
+	return ResetSummary{
+		PaymentsCleared:      o.store.Reset(),
+		HealthWindowsCleared: o.monitor.Reset(),
+	}
+}
+
+// CustomerPayments returns one page of a customer's payment results, most recent
+// first, and the customer's total number of payments.
+func (o *Orchestrator) CustomerPayments(customerID string, offset, limit int) ([]model.PaymentResult, int) {
+	return o.store.ByCustomer(customerID, offset, limit)
+}
+
+// EachPayment calls fn with every stored payment result and when it was first
+// saved, oldest first, stopping at the first error fn returns.
+func (o *Orchestrator) EachPayment(fn func(result model.PaymentResult, savedAt time.Time) error) error {
+	return o.store.Each(fn)
+}
+
 // HealthMonitor returns the health monitor for external access.
 func (o *Orchestrator) HealthMonitor() *health.Monitor {
 	return o.monitor
@@ -145,23 +885,226 @@ func (o *Orchestrator) Processors() []processor.Processor {
 }
 
 type eligibleProcessor struct {
-	proc        processor.Processor
-	healthScore float64
-	status      health.Status
+	proc         processor.Processor
+	healthScore  float64
+	status       health.Status
+	avgLatencyMs float64
+	inFlight     int64
+	approval     float64
+	canary       bool
+	preferred    bool
+	lastResort   bool
+	penalized    bool
+	warming      bool
+	// recovering marks a candidate ranked below its health score by the recovery
+	// penalty; recoveryDiscount is the share withheld. healthScore stays raw.
+	recovering       bool
+	recoveryDiscount float64
+}
+
+// routingScore is the score candidates are ranked by: the health score less any
+// recovery discount.
+func (ep eligibleProcessor) routingScore() float64 {
+	return ep.healthScore * (1 - ep.recoveryDiscount)
+}
+
+// approvalEstimate is the chance p approves a payment: its approval rate over the
+// health window, or its declared approval target while the window is empty.
+func approvalEstimate(p processor.Processor, h health.ProcessorHealth) float64 {
+	if h.TotalRecent == 0 {
+		if target := processor.ExpectedApprovalRate(p); target > 0 {
+			return target
+		}
+	}
+	return h.HealthScore
+}
+
+// eligibilityFilter counts the processors removed by each filter in getEligibleProcessors.
+type eligibilityFilter struct {
+	supported   int
+	excluded    int
+	circuitOpen int
+	// notAllowed counts processors supporting the method that the API key may not use.
+	notAllowed int
+	// unhealthy counts processors skipped because the request avoids degraded ones.
+	unhealthy int
+}
+
+// add returns the sum of both filters' counts.
+func (f eligibilityFilter) add(other eligibilityFilter) eligibilityFilter {
+	return eligibilityFilter{
+		supported:   f.supported + other.supported,
+		excluded:    f.excluded + other.excluded,
+		circuitOpen: f.circuitOpen + other.circuitOpen,
+		notAllowed:  f.notAllowed + other.notAllowed,
+		unhealthy:   f.unhealthy + other.unhealthy,
+	}
+}
+
+// declineReason explains why no processor was left to attempt.
+func (f eligibilityFilter) declineReason(paymentMethod string) string {
+	switch {
+	case f.supported == 0 && f.notAllowed > 0:
+		return fmt.Sprintf("no processor allowed for this API key supports payment method %s", paymentMethod)
+	case f.supported == 0:
+		return fmt.Sprintf("no processor supports payment method %s", paymentMethod)
+	case f.excluded == f.supported:
+		return "all eligible processors were excluded by the request"
+	case f.excluded+f.circuitOpen == f.supported && f.excluded > 0:
+		return "remaining processors were excluded by the request or have open circuits"
+	case f.unhealthy > 0:
+		return "no healthy processor available and the request avoids degraded processors"
+	default:
+		return "all eligible processors have open circuits"
+	}
+}
+
+// routingPass is what one eligibility pass reads and affects. A live pass reads
+// the monitor, logs to the default logger, rolls the shared RNG and may re-enable
+// auto-disabled processors; a dry run, as used by SimulateRouting, does none of
+// that beyond reading its health.
+type routingPass struct {
+	healthOf func(string) health.ProcessorHealth
+	log      *slog.Logger
+	roll     func() float64
+	dryRun   bool
+}
+
+func (o *Orchestrator) livePass() routingPass {
+	return routingPass{healthOf: o.monitor.GetHealth, log: slog.Default(), roll: o.roll}
+}
+
+// dryRunPass reads health from healthOf, discards its logs and rolls a scratch RNG.
+func dryRunPass(healthOf func(string) health.ProcessorHealth) routingPass {
+	return routingPass{
+		healthOf: healthOf,
+		log:      slog.New(slog.DiscardHandler),
+		roll:     rand.New(rand.NewSource(time.Now().UnixNano())).Float64,
+		dryRun:   true,
+	}
+}
+
+// roll returns a number in [0, 1) from the shared RNG.
+func (o *Orchestrator) roll() float64 {
+	o.rngMu.Lock()
+	defer o.rngMu.Unlock()
+	return o.rng.Float64()
+}
+
+func (o *Orchestrator) getEligibleProcessors(ctx context.Context, req model.PaymentRequest) ([]eligibleProcessor, eligibilityFilter) {
+	return o.eligibleWithHealth(ctx, req, o.livePass())
 }
 
-func (o *Orchestrator) getEligibleProcessors(paymentMethod string) []eligibleProcessor {
+// eligibleWithHealth filters and orders the processors for req, reading each
+// processor's health from the pass.
+func (o *Orchestrator) eligibleWithHealth(ctx context.Context, req model.PaymentRequest, pass routingPass) ([]eligibleProcessor, eligibilityFilter) {
 	var eligible []eligibleProcessor
+	var filter eligibilityFilter
+	allowed, restricted := o.allowedProcessors(ctx)
 
 	for _, p := range o.processors {
-		if !processor.SupportsMethod(p, paymentMethod) {
+		if !processor.SupportsMethod(p, req.PaymentMethod) {
+			continue
+		}
+		if restricted && !allowed[p.Name()] {
+			filter.notAllowed++
+			continue
+		}
+		filter.supported++
+
+		if isExcluded(req, p.Name()) {
+			filter.excluded++
+			pass.log.Info("processor_skipped_excluded",
+				"txn_id", req.TransactionID,
+				"processor", p.Name(),
+			)
+			continue
+		}
+
+		h := pass.healthOf(p.Name())
+
+		// Verifications move no money, so they reach every processor supporting
+		// the method whatever its health
+		if req.IsVerification() {
+			if h.Status != health.StatusHealthy {
+				pass.log.Info("verification_health_override",
+					"txn_id", req.TransactionID,
+					"processor", p.Name(),
+					"status", h.Status,
+					"health_score", fmt.Sprintf("%.2f", h.HealthScore),
+				)
+			}
+			eligible = append(eligible, eligibleProcessor{
+				proc:         p,
+				healthScore:  h.HealthScore,
+				status:       h.Status,
+				avgLatencyMs: h.AvgLatencyMs,
+				inFlight:     o.load.load(p.Name()),
+				approval:     approvalEstimate(p, h),
+			})
+			continue
+		}
+
+		if o.routingDisabled(p.Name(), h, pass.dryRun) {
+			filter.circuitOpen++
+			pass.log.Info("processor_skipped_auto_disabled",
+				"txn_id", req.TransactionID,
+				"processor", p.Name(),
+				"health_score", fmt.Sprintf("%.2f", h.HealthScore),
+			)
+			continue
+		}
+
+		if req.AvoidDegraded && h.Status != health.StatusHealthy {
+			filter.unhealthy++
+			pass.log.Info("processor_skipped_degraded",
+				"txn_id", req.TransactionID,
+				"processor", p.Name(),
+				"status", h.Status,
+				"health_score", fmt.Sprintf("%.2f", h.HealthScore),
+			)
 			continue
 		}
 
-		h := o.monitor.GetHealth(p.Name())
+		if h.Status == health.StatusOpen && p.Name() == o.cfg.LastResort {
+			pass.log.Warn("last_resort_circuit_override",
+				"txn_id", req.TransactionID,
+				"processor", p.Name(),
+				"health_score", fmt.Sprintf("%.2f", h.HealthScore),
+			)
+			eligible = append(eligible, eligibleProcessor{
+				proc:         p,
+				healthScore:  h.HealthScore,
+				status:       h.Status,
+				avgLatencyMs: h.AvgLatencyMs,
+				inFlight:     o.load.load(p.Name()),
+				approval:     approvalEstimate(p, h),
+				lastResort:   true,
+			})
+			continue
+		}
+
+		if h.Status == health.StatusOpen && o.cfg.OpenCircuitPolicy == OpenCircuitPenalize {
+			pass.log.Info("processor_penalized_circuit_open",
+				"txn_id", req.TransactionID,
+				"processor", p.Name(),
+				"health_score", fmt.Sprintf("%.2f", h.HealthScore),
+			)
+			eligible = append(eligible, eligibleProcessor{
+				proc:         p,
+				healthScore:  h.HealthScore,
+				status:       h.Status,
+				avgLatencyMs: h.AvgLatencyMs,
+				inFlight:     o.load.load(p.Name()),
+				approval:     approvalEstimate(p, h),
+				penalized:    true,
+			})
+			continue
+		}
 
 		if h.Status == health.StatusOpen {
-			slog.Info("processor_skipped_circuit_open",
+			filter.circuitOpen++
+			pass.log.Info("processor_skipped_circuit_open",
 				"processor", p.Name(),
 				"health_score", fmt.Sprintf("%.2f", h.HealthScore),
 			)
@@ -169,55 +1112,418 @@ func (o *Orchestrator) getEligibleProcessors(paymentMethod string) []eligiblePro
 		}
 
 		eligible = append(eligible, eligibleProcessor{
-			proc:        p,
-			healthScore: h.HealthScore,
-			status:      h.Status,
+			proc:         p,
+			healthScore:  h.HealthScore,
+			status:       h.Status,
+			avgLatencyMs: h.AvgLatencyMs,
+			inFlight:     o.load.load(p.Name()),
+			approval:     approvalEstimate(p, h),
 		})
 	}
 
-	// Sort by health score descending (healthiest first)
-	sort.Slice(eligible, func(i, j int) bool {
-		return eligible[i].healthScore > eligible[j].healthScore
+	o.applyRecoveryPenalty(eligible)
+	if o.cfg.CostAwareRouting {
+		sortByCost(eligible, req.Amount, req.Currency)
+	} else if o.cfg.LatencyWeight > 0 {
+		sortByBlend(eligible, o.cfg.HealthWeight, o.cfg.LatencyWeight)
+	} else if o.cfg.LeastLoadedRouting {
+		sort.SliceStable(eligible, func(i, j int) bool {
+			return eligible[i].routingScore() > eligible[j].routingScore()
+		})
+		sortByLoad(eligible, o.cfg.LoadHealthBand)
+	} else {
+		// Sort by health score descending (healthiest first)
+		sort.Slice(eligible, func(i, j int) bool {
+			return eligible[i].routingScore() > eligible[j].routingScore()
+		})
+	}
+	// Penalized open circuits rank after every closed circuit
+	sort.SliceStable(eligible, func(i, j int) bool {
+		return !eligible[i].penalized && eligible[j].penalized
 	})
 
+	eligible = o.applyWarmUp(eligible, pass.roll)
+	eligible = o.applyCanary(eligible, pass.roll)
+	eligible = o.applyPreferred(req, eligible, pass.log)
+
+	// Cap the candidate list; the last resort still claims a slot within the cap
+	budget := o.maxRetriesFor(req)
+	limit := o.cfg.MaxProcessorsPerPayment
+	if limit > 0 && limit < budget {
+		budget = limit
+	}
+	eligible = o.placeLastResort(eligible, budget)
+	if limit > 0 && len(eligible) > limit {
+		eligible = eligible[:limit]
+	}
+	return eligible, filter
+}
+
+// allowedProcessors returns the processors the API key in ctx may use, and
+// whether it is restricted at all. An unknown key is restricted to nothing when
+// RejectUnknownAPIKeys is set.
+func (o *Orchestrator) allowedProcessors(ctx context.Context) (map[string]bool, bool) {
+	if len(o.cfg.ProcessorAllowList) == 0 {
+		return nil, false
+	}
+	names, ok := o.cfg.ProcessorAllowList[apikey.FromContext(ctx)]
+	if !ok {
+		return map[string]bool{}, o.cfg.RejectUnknownAPIKeys
+	}
+	allowed := make(map[string]bool, len(names))
+	for _, name := range names {
+		allowed[name] = true
+	}
+	return allowed, true
+}
+
+// sortByCost orders processors by health status (healthy, then degraded, then open),
+// then by fee for the payment's amount and currency ascending, then by health
+// score descending.
+func sortByCost(eligible []eligibleProcessor, amount float64, currency string) {
+	statusRank := map[health.Status]int{
+		health.StatusHealthy:  0,
+		health.StatusDegraded: 1,
+		health.StatusOpen:     2,
+	}
+	sort.SliceStable(eligible, func(i, j int) bool {
+		a, b := eligible[i], eligible[j]
+		if statusRank[a.status] != statusRank[b.status] {
+			return statusRank[a.status] < statusRank[b.status]
+		}
+		feeA, feeB := processor.FeeBpsForAmount(a.proc, amount, currency), processor.FeeBpsForAmount(b.proc, amount, currency)
+		if feeA != feeB {
+			return feeA < feeB
+		}
+		return a.routingScore() > b.routingScore()
+	})
+}
+
+// sortByBlend orders processors by healthWeight*routingScore minus
+// latencyWeight*normalized average latency, highest first. Latency is normalized
+// against the slowest candidate, so it ranges from 0 to 1; processors without
+// latency data count as fastest.
+func sortByBlend(eligible []eligibleProcessor, healthWeight, latencyWeight float64) {
+	var slowest float64
+	for _, ep := range eligible {
+		slowest = math.Max(slowest, ep.avgLatencyMs)
+	}
+	blend := func(ep eligibleProcessor) float64 {
+		var normalized float64
+		if slowest > 0 {
+			normalized = ep.avgLatencyMs / slowest
+		}
+		return healthWeight*ep.routingScore() - latencyWeight*normalized
+	}
+	sort.SliceStable(eligible, func(i, j int) bool {
+		return blend(eligible[i]) > blend(eligible[j])
+	})
+}
+
+// placeLastResort guarantees the last-resort processor is reachable within the
+// attempt budget: it keeps its position if already reachable, otherwise it takes
+// the final slot. A last resort with an open circuit always goes last. A budget of
+// one attempt, as for fail-fast currencies, is left to the best-ranked processor.
+func (o *Orchestrator) placeLastResort(eligible []eligibleProcessor, budget int) []eligibleProcessor {
+	if o.cfg.LastResort == "" {
+		return eligible
+	}
+
+	idx := -1
+	for i, ep := range eligible {
+		if ep.proc.Name() == o.cfg.LastResort {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 || (idx < budget && !eligible[idx].lastResort) {
+		return eligible
+	}
+
+	ep := eligible[idx]
+	rest := append(eligible[:idx:idx], eligible[idx+1:]...)
+	slot := len(rest)
+	if budget > 1 && budget-1 < slot {
+		slot = budget - 1
+	}
+
+	placed := make([]eligibleProcessor, 0, len(eligible))
+	placed = append(placed, rest[:slot]...)
+	placed = append(placed, ep)
+	return append(placed, rest[slot:]...)
+}
+
+// applyCanary moves the canary processor to the front for its configured share of payments.
+func (o *Orchestrator) applyCanary(eligible []eligibleProcessor, roll func() float64) []eligibleProcessor {
+	canary := o.cfg.Canary
+	if canary == nil || canary.Percentage <= 0 {
+		return eligible
+	}
+
+	idx := -1
+	for i, ep := range eligible {
+		if ep.proc.Name() == canary.ProcessorName && !ep.penalized {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return eligible
+	}
+
+	if roll()*100 >= canary.Percentage {
+		return eligible
+	}
+
+	ep := eligible[idx]
+	ep.canary = true
+	copy(eligible[1:idx+1], eligible[:idx])
+	eligible[0] = ep
+	return eligible
+}
+
+// applyPreferred moves the request's preferred processor to the front when it is
+// eligible with a closed circuit. Otherwise the hint is ignored and logged.
+func (o *Orchestrator) applyPreferred(req model.PaymentRequest, eligible []eligibleProcessor, log *slog.Logger) []eligibleProcessor {
+	name := req.PreferredProcessor
+	if name == "" {
+		return eligible
+	}
+
+	for i, ep := range eligible {
+		if ep.proc.Name() != name || ep.lastResort || ep.penalized {
+			continue
+		}
+		ep.preferred = true
+		copy(eligible[1:i+1], eligible[:i])
+		eligible[0] = ep
+		return eligible
+	}
+
+	log.Info("preferred_processor_ignored",
+		"txn_id", req.TransactionID,
+		"processor", name,
+		"reason", o.preferredIneligibility(req, name),
+	)
 	return eligible
 }
 
-func (o *Orchestrator) buildRoutingReason(ep eligibleProcessor, attemptNum int, result *model.PaymentResult) string {
+// preferredIneligibility explains why a preferred processor could not be promoted.
+func (o *Orchestrator) preferredIneligibility(req model.PaymentRequest, name string) string {
+	for _, p := range o.processors {
+		if p.Name() != name {
+			continue
+		}
+		switch {
+		case !processor.SupportsMethod(p, req.PaymentMethod):
+			return "unsupported payment method"
+		case isExcluded(req, name):
+			return "excluded by request"
+		default:
+			return "circuit open"
+		}
+	}
+	return "unknown processor"
+}
+
+// isExcluded reports whether the request asked to exclude the named processor.
+func isExcluded(req model.PaymentRequest, name string) bool {
+	for _, excluded := range req.ExcludeProcessors {
+		if excluded == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *Orchestrator) buildRoutingReason(ep eligibleProcessor, attemptNum int, sameProcessor bool, result *model.PaymentResult) string {
+	if sameProcessor {
+		prevAttempt := result.Attempts[len(result.Attempts)-1]
+		return fmt.Sprintf("retry: %s returned %s, retrying same processor",
+			prevAttempt.ProcessorName, prevAttempt.Response.Code)
+	}
 	if attemptNum == 1 {
-		if ep.status == health.StatusDegraded {
-			return fmt.Sprintf("primary (degraded): health score %.2f", ep.healthScore)
+		if ep.lastResort {
+			return fmt.Sprintf("last resort: no other processor available, trying %s despite open circuit (health %.2f)",
+				ep.proc.Name(), ep.healthScore)
+		}
+		if ep.penalized {
+			return fmt.Sprintf("penalized: circuit open, trying %s as no closed circuit is available (health %.2f)",
+				ep.proc.Name(), ep.healthScore)
+		}
+		if ep.preferred {
+			return fmt.Sprintf("preferred: requested by merchant, health score %.2f", ep.healthScore)
+		}
+		if ep.canary {
+			return fmt.Sprintf("canary: %.0f%% traffic share, health score %.2f",
+				o.cfg.Canary.Percentage, ep.healthScore)
+		}
+		if ep.warming {
+			return fmt.Sprintf("primary (warming up): %.0f%% of its primary share, health score %.2f",
+				o.warmUpWeight(ep.proc.Name(), time.Now())*100, ep.healthScore)
+		}
+		if ep.recovering {
+			return fmt.Sprintf("primary (recovering): circuit recently closed, health score %.2f discounted to %.2f",
+				ep.healthScore, ep.routingScore())
+		}
+		if o.belowPrimaryMin(ep) {
+			return fmt.Sprintf("primary (degraded): health score %.2f below primary minimum %.2f",
+				ep.healthScore, o.cfg.PrimaryMinScore)
 		}
 		return fmt.Sprintf("primary: highest health score %.2f", ep.healthScore)
 	}
 
 	prevAttempt := result.Attempts[len(result.Attempts)-1]
+	if ep.lastResort {
+		return fmt.Sprintf("last resort: %s returned %s, trying %s despite open circuit (health %.2f)",
+			prevAttempt.ProcessorName, prevAttempt.Response.Code, ep.proc.Name(), ep.healthScore)
+	}
 	reason := fmt.Sprintf("fallback: %s returned %s",
 		prevAttempt.ProcessorName, prevAttempt.Response.Code)
+	if ep.penalized {
+		return reason + fmt.Sprintf(" (penalized: circuit open, health %.2f)", ep.healthScore)
+	}
 	if ep.status == health.StatusDegraded {
 		reason += fmt.Sprintf(" (degraded: health %.2f)", ep.healthScore)
 	}
+	if ep.recovering {
+		reason += fmt.Sprintf(" (recovering: health %.2f discounted to %.2f)", ep.healthScore, ep.routingScore())
+	}
 	return reason
 }
 
-// PaymentStore provides thread-safe storage for payment results.
+// healthResponse returns the response to record for health. An approval slower
+// than the latency SLA counts as a timeout, so slow processors lose routing priority.
+func (o *Orchestrator) healthResponse(req model.PaymentRequest, resp model.ProcessorResponse) model.ProcessorResponse {
+	sla := o.cfg.ApprovalLatencySLA
+	if sla <= 0 || resp.Code != model.Approved || resp.Latency <= sla {
+		return resp
+	}
+	slog.Warn("approval_exceeded_latency_sla",
+		"txn_id", req.TransactionID,
+		"processor", resp.ProcessorName,
+		"latency_ms", resp.Latency.Milliseconds(),
+		"sla_ms", sla.Milliseconds(),
+	)
+	slow := resp
+	slow.Code = model.Timeout
+	slow.Message = fmt.Sprintf("approved in %s, over the %s latency SLA", resp.Latency, sla)
+	return slow
+}
+
+// retriesSameProcessor reports whether a failure is transient enough to retry on
+// the same processor before falling back.
+func retriesSameProcessor(code model.ResponseCode) bool {
+	return code == model.Timeout || code == model.ProcessorError
+}
+
+// waitBackoff sleeps for the backoff duration, returning false if the context ends first.
+func waitBackoff(ctx context.Context, backoff time.Duration) bool {
+	if backoff <= 0 {
+		return ctx.Err() == nil
+	}
+	select {
+	case <-time.After(backoff):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// PaymentStore provides thread-safe storage for payment results, indexed by
+// customer ID.
 type PaymentStore struct {
 	mu      sync.RWMutex
 	results map[string]model.PaymentResult
+	// byCustomer lists each customer's transaction IDs in the order first saved.
+	byCustomer map[string][]string
+	// order lists every transaction ID in the order first saved, at savedAt.
+	order   []string
+	savedAt map[string]time.Time
 }
 
 // NewPaymentStore creates a new empty payment store.
 func NewPaymentStore() *PaymentStore {
 	return &PaymentStore{
-		results: make(map[string]model.PaymentResult),
+		results:    make(map[string]model.PaymentResult),
+		byCustomer: make(map[string][]string),
+		savedAt:    make(map[string]time.Time),
 	}
 }
 
-// Save stores a payment result.
+// Save stores a payment result, indexing it under its request's customer ID.
 func (s *PaymentStore) Save(result model.PaymentResult) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	customerID := result.Request.CustomerID
+	if prev, ok := s.results[result.TransactionID]; ok {
+		if prev.Request.CustomerID == customerID {
+			s.results[result.TransactionID] = result
+			return
+		}
+		s.unindex(prev.Request.CustomerID, result.TransactionID)
+	} else {
+		s.order = append(s.order, result.TransactionID)
+		s.savedAt[result.TransactionID] = time.Now()
+	}
 	s.results[result.TransactionID] = result
+	s.byCustomer[customerID] = append(s.byCustomer[customerID], result.TransactionID)
+}
+
+// unindex removes a transaction from a customer's index, called under write lock.
+func (s *PaymentStore) unindex(customerID, txnID string) {
+	ids := s.byCustomer[customerID]
+	for i, id := range ids {
+		if id == txnID {
+			s.byCustomer[customerID] = append(ids[:i:i], ids[i+1:]...)
+			break
+		}
+	}
+	if len(s.byCustomer[customerID]) == 0 {
+		delete(s.byCustomer, customerID)
+	}
+}
+
+// ByCustomer returns one page of a customer's payment results, most recent first,
+// along with the customer's total number of payments. A non-positive limit
+// returns every result from offset on.
+func (s *PaymentStore) ByCustomer(customerID string, offset, limit int) ([]model.PaymentResult, int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ids := s.byCustomer[customerID]
+	total := len(ids)
+	page := make([]model.PaymentResult, 0)
+	for i := total - 1 - offset; i >= 0; i-- {
+		if limit > 0 && len(page) == limit {
+			break
+		}
+		page = append(page, s.results[ids[i]])
+	}
+	return page, total
+}
+
+// Each calls fn with every stored result and when it was first saved, oldest
+// first, stopping at the first error. Only the transaction IDs are copied up
+// front; each result is read under a short lock, so fn may be slow without
+// blocking writers, and results reset meanwhile are skipped.
+func (s *PaymentStore) Each(fn func(result model.PaymentResult, savedAt time.Time) error) error {
+	s.mu.RLock()
+	ids := append([]string(nil), s.order...)
+	s.mu.RUnlock()
+
+	for _, id := range ids {
+		s.mu.RLock()
+		r, ok := s.results[id]
+		savedAt := s.savedAt[id]
+		s.mu.RUnlock()
+		if !ok {
+			continue
+		}
+		if err := fn(r, savedAt); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Get retrieves a payment result by transaction ID.
@@ -227,3 +1533,31 @@ func (s *PaymentStore) Get(txnID string) (model.PaymentResult, bool) {
 	r, ok := s.results[txnID]
 	return r, ok
 }
+
+// Update applies fn to a stored result under the store lock, saving the change
+// unless fn returns an error. A missing result yields ErrPaymentNotFound.
+func (s *PaymentStore) Update(txnID string, fn func(*model.PaymentResult) error) (model.PaymentResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.results[txnID]
+	if !ok {
+		return model.PaymentResult{}, fmt.Errorf("update %s: %w", txnID, ErrPaymentNotFound)
+	}
+	if err := fn(&r); err != nil {
+		return model.PaymentResult{}, err
+	}
+	s.results[txnID] = r
+	return r, nil
+}
+
+// Reset removes all stored results and returns how many were cleared.
+func (s *PaymentStore) Reset() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cleared := len(s.results)
+	s.results = make(map[string]model.PaymentResult)
+	s.byCustomer = make(map[string][]string)
+	s.order = nil
+	s.savedAt = make(map[string]time.Time)
+	return cleared
+}
@@ -7,9 +7,12 @@ import (
 	"testing"
 	"time"
 
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/apikey"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/config"
 	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/health"
 	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/model"
 	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/processor"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/trace"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -27,7 +30,7 @@ func newDeterministicProcessor(name string, methods []string, code model.Respons
 	return &deterministicProcessor{name: name, methods: methods, code: code}
 }
 
-func (p *deterministicProcessor) Name() string             { return p.name }
+func (p *deterministicProcessor) Name() string               { return p.name }
 func (p *deterministicProcessor) SupportedMethods() []string { return p.methods }
 func (p *deterministicProcessor) Process(ctx context.Context, req model.PaymentRequest) model.ProcessorResponse {
 	p.mu.Lock()
@@ -48,35 +51,6 @@ func (p *deterministicProcessor) CallCount() int {
 	return p.callCount
 }
 
-// sequenceProcessor returns different codes on successive calls.
-type sequenceProcessor struct {
-	name    string
-	methods []string
-	codes   []model.ResponseCode
-	idx     int
-	mu      sync.Mutex
-}
-
-func newSequenceProcessor(name string, methods []string, codes ...model.ResponseCode) *sequenceProcessor {
-	return &sequenceProcessor{name: name, methods: methods, codes: codes}
-}
-
-func (p *sequenceProcessor) Name() string             { return p.name }
-func (p *sequenceProcessor) SupportedMethods() []string { return p.methods }
-func (p *sequenceProcessor) Process(ctx context.Context, req model.PaymentRequest) model.ProcessorResponse {
-	p.mu.Lock()
-	code := p.codes[p.idx%len(p.codes)]
-	p.idx++
-	p.mu.Unlock()
-	return model.ProcessorResponse{
-		ProcessorName: p.name,
-		Code:          code,
-		Message:       "test response",
-		Timestamp:     time.Now(),
-		Latency:       10 * time.Millisecond,
-	}
-}
-
 func TestProcessPayment_ApprovedOnFirstTry(t *testing.T) {
 	mon := health.NewMonitorWithConfig(50, 10*time.Minute)
 	procs := []processor.Processor{
@@ -128,6 +102,54 @@ func TestProcessPayment_RetryOnSoftDecline(t *testing.T) {
 	assert.Contains(t, result.Attempts[1].RoutingReason, "fallback")
 }
 
+func TestProcessPayment_SoftDeclineHealthierOnly(t *testing.T) {
+	tests := []struct {
+		name          string
+		healthierOnly bool
+		procBOutcomes []model.ResponseCode
+		wantStatus    model.PaymentStatus
+		wantAttempts  int
+	}{
+		{"disabled falls back to less healthy", false, []model.ResponseCode{model.Approved, model.ProcessorError}, model.StatusApproved, 2},
+		{"stops before less healthy fallback", true, []model.ResponseCode{model.Approved, model.ProcessorError}, model.StatusExhaustedRetries, 1},
+		{"equally healthy fallback still tried", true, []model.ResponseCode{model.Approved}, model.StatusApproved, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mon := health.NewMonitorWithConfig(50, 10*time.Minute)
+			mon.RecordOutcome("ProcA", model.Approved)
+			for _, code := range tt.procBOutcomes {
+				mon.RecordOutcome("ProcB", code)
+			}
+			procB := newDeterministicProcessor("ProcB", []string{"card"}, model.Approved)
+			cfg := DefaultConfig()
+			cfg.SoftDeclineHealthierOnly = tt.healthierOnly
+			orch := NewWithConfig([]processor.Processor{
+				newDeterministicProcessor("ProcA", []string{"card"}, model.SoftDecline),
+				procB,
+			}, mon, cfg)
+
+			result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+				TransactionID: "tx-soft-healthier",
+				Amount:        100.0,
+				Currency:      "USD",
+				PaymentMethod: "card",
+				CustomerID:    "cust-1",
+			})
+
+			assert.Equal(t, tt.wantStatus, result.Status)
+			assert.Len(t, result.Attempts, tt.wantAttempts)
+			if tt.wantAttempts == 1 {
+				assert.Equal(t, 0, procB.CallCount())
+				assert.Contains(t, result.Reason, "less healthy")
+				require.NotNil(t, result.FinalResponse)
+				assert.Equal(t, model.SoftDecline, result.FinalResponse.Code)
+			}
+		})
+	}
+}
+
 func TestProcessPayment_HardDeclineStopsImmediately(t *testing.T) {
 	mon := health.NewMonitorWithConfig(50, 10*time.Minute)
 	procB := newDeterministicProcessor("ProcB", []string{"card"}, model.Approved)
@@ -216,6 +238,7 @@ func TestProcessPayment_NoCompatibleProcessors(t *testing.T) {
 
 	assert.Equal(t, model.StatusDeclined, result.Status)
 	assert.Len(t, result.Attempts, 0)
+	assert.Contains(t, result.Reason, "no processor supports payment method pix")
 }
 
 func TestProcessPayment_SkipsCircuitOpenProcessors(t *testing.T) {
@@ -246,6 +269,62 @@ func TestProcessPayment_SkipsCircuitOpenProcessors(t *testing.T) {
 	assert.Equal(t, "ProcB", result.Attempts[0].ProcessorName, "should skip ProcA (circuit open)")
 }
 
+// circuitTrippingProcessor runs trip during each call, e.g. to open another
+// processor's circuit while a payment is in progress.
+type circuitTrippingProcessor struct {
+	*deterministicProcessor
+	trip func()
+}
+
+func (p *circuitTrippingProcessor) Process(ctx context.Context, req model.PaymentRequest) model.ProcessorResponse {
+	p.trip()
+	return p.deterministicProcessor.Process(ctx, req)
+}
+
+func TestProcessPayment_RecordsFallbackSkippedForCircuitOpen(t *testing.T) {
+	mon := health.NewMonitorWithConfig(50, 10*time.Minute)
+	// Rank ProcA, then ProcB, then ProcC
+	for name, failures := range map[string]int{"ProcA": 0, "ProcB": 1, "ProcC": 2} {
+		for i := 0; i < 10; i++ {
+			code := model.Approved
+			if i < failures {
+				code = model.ProcessorError
+			}
+			mon.RecordOutcome(name, code)
+		}
+	}
+
+	procA := &circuitTrippingProcessor{
+		deterministicProcessor: newDeterministicProcessor("ProcA", []string{"card"}, model.ProcessorError),
+		trip: func() {
+			for i := 0; i < 50; i++ {
+				mon.RecordOutcome("ProcB", model.ProcessorError)
+			}
+		},
+	}
+	procB := newDeterministicProcessor("ProcB", []string{"card"}, model.Approved)
+	procC := newDeterministicProcessor("ProcC", []string{"card"}, model.Approved)
+	orch := New([]processor.Processor{procA, procB, procC}, mon)
+
+	result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+		TransactionID: "tx-skip-opened",
+		Amount:        100.0,
+		Currency:      "USD",
+		PaymentMethod: "card",
+		CustomerID:    "cust-skip",
+	})
+
+	assert.Equal(t, model.StatusApproved, result.Status)
+	require.Len(t, result.Attempts, 2)
+	assert.Equal(t, "ProcA", result.Attempts[0].ProcessorName)
+	assert.Equal(t, "ProcC", result.Attempts[1].ProcessorName)
+	assert.Equal(t, 0, procB.CallCount())
+	require.Len(t, result.SkippedProcessors, 1)
+	assert.Equal(t, "ProcB", result.SkippedProcessors[0].ProcessorName)
+	assert.Equal(t, "circuit opened during the payment", result.SkippedProcessors[0].Reason)
+	assert.False(t, result.SkippedProcessors[0].Timestamp.IsZero())
+}
+
 func TestProcessPayment_HealthBasedRouting(t *testing.T) {
 	mon := health.NewMonitorWithConfig(50, 10*time.Minute)
 
@@ -377,30 +456,45 @@ func TestProcessPayment_PaymentStoreIntegration(t *testing.T) {
 }
 
 func TestProcessPayment_ConcurrentPayments(t *testing.T) {
-	mon := health.NewMonitorWithConfig(50, 10*time.Minute)
-	procs := []processor.Processor{
-		newDeterministicProcessor("ProcA", []string{"card", "pix"}, model.Approved),
-		newDeterministicProcessor("ProcB", []string{"card", "pix"}, model.Approved),
+	tests := []struct {
+		name          string
+		maxConcurrent int
+		queueDepth    int
+	}{
+		{"unlimited", 0, 0},
+		{"limited with room to queue", 8, 50},
 	}
-	orch := New(procs, mon)
-
-	var wg sync.WaitGroup
-	for i := 0; i < 50; i++ {
-		wg.Add(1)
-		go func(i int) {
-			defer wg.Done()
-			req := model.PaymentRequest{
-				TransactionID: fmt.Sprintf("tx-conc-%d", i),
-				Amount:        float64(i * 10),
-				Currency:      "USD",
-				PaymentMethod: "card",
-				CustomerID:    fmt.Sprintf("cust-%d", i),
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mon := health.NewMonitorWithConfig(50, 10*time.Minute)
+			procs := []processor.Processor{
+				newDeterministicProcessor("ProcA", []string{"card", "pix"}, model.Approved),
+				newDeterministicProcessor("ProcB", []string{"card", "pix"}, model.Approved),
 			}
-			result := orch.ProcessPayment(context.Background(), req)
-			assert.Equal(t, model.StatusApproved, result.Status)
-		}(i)
+			cfg := DefaultConfig()
+			cfg.MaxConcurrentPayments = tt.maxConcurrent
+			cfg.PaymentQueueDepth = tt.queueDepth
+			orch := NewWithConfig(procs, mon, cfg)
+
+			var wg sync.WaitGroup
+			for i := 0; i < 50; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					req := model.PaymentRequest{
+						TransactionID: fmt.Sprintf("tx-conc-%d", i),
+						Amount:        float64(i * 10),
+						Currency:      "USD",
+						PaymentMethod: "card",
+						CustomerID:    fmt.Sprintf("cust-%d", i),
+					}
+					result := orch.ProcessPayment(context.Background(), req)
+					assert.Equal(t, model.StatusApproved, result.Status)
+				}(i)
+			}
+			wg.Wait()
+		})
 	}
-	wg.Wait()
 }
 
 func TestPaymentStore_ConcurrentAccess(t *testing.T) {
@@ -572,3 +666,2030 @@ func TestProcessPayment_RoutingReasonContainsInfo(t *testing.T) {
 	assert.Contains(t, result.Attempts[1].RoutingReason, "soft_decline")
 }
 
+// feeProcessor is a deterministicProcessor that charges a fixed fee in basis points.
+type feeProcessor struct {
+	*deterministicProcessor
+	bps int
+}
+
+func (p *feeProcessor) FeeBps(string) int { return p.bps }
+
+func TestProcessPayment_FeeFromWinningProcessor(t *testing.T) {
+	mon := health.NewMonitorWithConfig(50, 10*time.Minute)
+	procs := []processor.Processor{
+		&feeProcessor{newDeterministicProcessor("ProcA", []string{"card"}, model.SoftDecline), 100},
+		&feeProcessor{newDeterministicProcessor("ProcB", []string{"card"}, model.Approved), 300},
+	}
+	orch := New(procs, mon)
+
+	req := model.PaymentRequest{
+		TransactionID: "tx-fee",
+		Amount:        200.0,
+		Currency:      "USD",
+		PaymentMethod: "card",
+		CustomerID:    "cust-1",
+	}
+	result := orch.ProcessPayment(context.Background(), req)
+
+	require.Equal(t, model.StatusApproved, result.Status)
+	assert.Equal(t, "ProcB", result.FinalResponse.ProcessorName)
+	assert.InDelta(t, 6.00, result.FeeCharged, 0.001, "fee should use the winning processor's 300 bps")
+	assert.InDelta(t, 194.00, result.NetAmount, 0.001)
+}
+
+func TestProcessPayment_DeclinedPaymentHasNoFee(t *testing.T) {
+	tests := []struct {
+		name   string
+		code   model.ResponseCode
+		status model.PaymentStatus
+	}{
+		{"hard decline", model.DeclinedFraud, model.StatusDeclined},
+		{"exhausted retries", model.ProcessorError, model.StatusExhaustedRetries},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mon := health.NewMonitorWithConfig(50, 10*time.Minute)
+			procs := []processor.Processor{
+				&feeProcessor{newDeterministicProcessor("ProcA", []string{"card"}, tt.code), 250},
+			}
+			orch := New(procs, mon)
+
+			result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+				TransactionID: "tx-no-fee",
+				Amount:        100.0,
+				Currency:      "USD",
+				PaymentMethod: "card",
+				CustomerID:    "cust-1",
+			})
+
+			assert.Equal(t, tt.status, result.Status)
+			assert.Zero(t, result.FeeCharged)
+			assert.Zero(t, result.NetAmount)
+		})
+	}
+}
+
+func TestProcessPayment_WinningProcessor(t *testing.T) {
+	tests := []struct {
+		name       string
+		codes      []model.ResponseCode
+		method     string
+		wantStatus model.PaymentStatus
+		wantWinner string
+	}{
+		{"approved after fallback", []model.ResponseCode{model.SoftDecline, model.Approved}, "card", model.StatusApproved, "Proc1"},
+		{"hard decline", []model.ResponseCode{model.DeclinedFraud}, "card", model.StatusDeclined, ""},
+		{"exhausted retries", []model.ResponseCode{model.ProcessorError}, "card", model.StatusExhaustedRetries, ""},
+		{"no eligible processor", []model.ResponseCode{model.Approved}, "pix", model.StatusDeclined, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			procs := make([]processor.Processor, 0, len(tt.codes))
+			for i, code := range tt.codes {
+				procs = append(procs, newDeterministicProcessor(fmt.Sprintf("Proc%d", i), []string{"card"}, code))
+			}
+			orch := New(procs, health.NewMonitorWithConfig(50, 10*time.Minute))
+
+			result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+				TransactionID: "tx-winner",
+				Amount:        100.0,
+				Currency:      "USD",
+				PaymentMethod: tt.method,
+				CustomerID:    "cust-1",
+			})
+
+			assert.Equal(t, tt.wantStatus, result.Status)
+			assert.Equal(t, tt.wantWinner, result.WinningProcessor)
+		})
+	}
+}
+
+func TestProcessPayment_ExcludedProcessorNeverAttempted(t *testing.T) {
+	mon := health.NewMonitorWithConfig(50, 10*time.Minute)
+	cardMax := newDeterministicProcessor("CardMax", []string{"card"}, model.Approved)
+	procs := []processor.Processor{
+		cardMax,
+		newDeterministicProcessor("PayFlow", []string{"card"}, model.SoftDecline),
+		newDeterministicProcessor("GlobalPay", []string{"card"}, model.Approved),
+	}
+	orch := New(procs, mon)
+
+	req := model.PaymentRequest{
+		TransactionID:     "tx-exclude",
+		Amount:            100.0,
+		Currency:          "USD",
+		PaymentMethod:     "card",
+		CustomerID:        "cust-1",
+		ExcludeProcessors: []string{"CardMax"},
+	}
+	result := orch.ProcessPayment(context.Background(), req)
+
+	assert.Equal(t, model.StatusApproved, result.Status)
+	assert.Equal(t, 0, cardMax.CallCount(), "excluded processor should never be called")
+	for _, a := range result.Attempts {
+		assert.NotEqual(t, "CardMax", a.ProcessorName)
+	}
+}
+
+func TestProcessPayment_ExcludeAllProcessorsDeclines(t *testing.T) {
+	mon := health.NewMonitorWithConfig(50, 10*time.Minute)
+	procs := []processor.Processor{
+		newDeterministicProcessor("ProcA", []string{"card"}, model.Approved),
+		newDeterministicProcessor("ProcB", []string{"card"}, model.Approved),
+	}
+	orch := New(procs, mon)
+
+	req := model.PaymentRequest{
+		TransactionID:     "tx-exclude-all",
+		Amount:            100.0,
+		Currency:          "USD",
+		PaymentMethod:     "card",
+		CustomerID:        "cust-1",
+		ExcludeProcessors: []string{"ProcA", "ProcB"},
+	}
+	result := orch.ProcessPayment(context.Background(), req)
+
+	assert.Equal(t, model.StatusDeclined, result.Status)
+	assert.Len(t, result.Attempts, 0)
+	assert.Contains(t, result.Reason, "excluded")
+}
+
+func TestProcessPayment_ExcludeUnknownProcessorIgnored(t *testing.T) {
+	mon := health.NewMonitorWithConfig(50, 10*time.Minute)
+	procs := []processor.Processor{
+		newDeterministicProcessor("ProcA", []string{"card"}, model.Approved),
+	}
+	orch := New(procs, mon)
+
+	req := model.PaymentRequest{
+		TransactionID:     "tx-exclude-unknown",
+		Amount:            100.0,
+		Currency:          "USD",
+		PaymentMethod:     "card",
+		CustomerID:        "cust-1",
+		ExcludeProcessors: []string{"DoesNotExist"},
+	}
+	result := orch.ProcessPayment(context.Background(), req)
+
+	assert.Equal(t, model.StatusApproved, result.Status)
+	require.Len(t, result.Attempts, 1)
+	assert.Equal(t, "ProcA", result.Attempts[0].ProcessorName)
+}
+
+func TestProcessPayment_AvoidDegraded(t *testing.T) {
+	tests := []struct {
+		name         string
+		avoid        bool
+		wantStatus   model.PaymentStatus
+		wantAttempts int
+	}{
+		{"flag declines when only degraded processors remain", true, model.StatusDeclined, 0},
+		{"without the flag degraded processors are attempted", false, model.StatusApproved, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mon := health.NewMonitorWithConfig(10, 10*time.Minute)
+			// Score 0.3: degraded but above the circuit breaker threshold
+			for _, name := range []string{"ProcA", "ProcB"} {
+				for i := 0; i < 10; i++ {
+					code := model.ProcessorError
+					if i < 3 {
+						code = model.Approved
+					}
+					mon.RecordOutcome(name, code)
+				}
+				require.Equal(t, health.StatusDegraded, mon.GetHealth(name).Status)
+			}
+			procs := []processor.Processor{
+				newDeterministicProcessor("ProcA", []string{"card"}, model.Approved),
+				newDeterministicProcessor("ProcB", []string{"card"}, model.Approved),
+			}
+			orch := New(procs, mon)
+
+			result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+				TransactionID: "tx-avoid-degraded",
+				Amount:        100.0,
+				Currency:      "USD",
+				PaymentMethod: "card",
+				CustomerID:    "cust-1",
+				AvoidDegraded: tt.avoid,
+			})
+
+			assert.Equal(t, tt.wantStatus, result.Status)
+			assert.Len(t, result.Attempts, tt.wantAttempts)
+			if tt.avoid {
+				assert.Contains(t, result.Reason, "avoids degraded processors")
+			}
+		})
+	}
+}
+
+func TestGetEligibleProcessors_AvoidDegradedKeepsHealthy(t *testing.T) {
+	mon := health.NewMonitorWithConfig(10, 10*time.Minute)
+	for i := 0; i < 10; i++ {
+		code := model.ProcessorError
+		if i < 3 {
+			code = model.Approved
+		}
+		mon.RecordOutcome("ProcA", code)
+	}
+	procs := []processor.Processor{
+		newDeterministicProcessor("ProcA", []string{"card"}, model.Approved),
+		newDeterministicProcessor("ProcB", []string{"card"}, model.Approved),
+	}
+	orch := New(procs, mon)
+
+	eligible, filter := orch.getEligibleProcessors(context.Background(), model.PaymentRequest{
+		TransactionID: "tx-avoid-degraded",
+		PaymentMethod: "card",
+		AvoidDegraded: true,
+	})
+
+	require.Len(t, eligible, 1)
+	assert.Equal(t, "ProcB", eligible[0].proc.Name())
+	assert.Equal(t, 1, filter.unhealthy)
+}
+
+func TestProcessPayment_SameProcessorRetryOnTimeout(t *testing.T) {
+	mon := health.NewMonitorWithConfig(50, 10*time.Minute)
+	procA, err := processor.NewScriptedProcessor(processor.ScriptedConfig{
+		ProcessorName: "ProcA",
+		Methods:       []string{"card"},
+		Codes:         []model.ResponseCode{model.Timeout, model.Approved},
+	})
+	require.NoError(t, err)
+	procB := newDeterministicProcessor("ProcB", []string{"card"}, model.Approved)
+	cfg := DefaultConfig()
+	cfg.SameProcessorRetries = 1
+	cfg.RetryBackoff = time.Millisecond
+	orch := NewWithConfig([]processor.Processor{procA, procB}, mon, cfg)
+
+	req := model.PaymentRequest{
+		TransactionID: "tx-same-retry",
+		Amount:        100.0,
+		Currency:      "USD",
+		PaymentMethod: "card",
+		CustomerID:    "cust-1",
+	}
+	result := orch.ProcessPayment(context.Background(), req)
+
+	assert.Equal(t, model.StatusApproved, result.Status)
+	require.Len(t, result.Attempts, 2)
+	assert.Equal(t, "ProcA", result.Attempts[0].ProcessorName)
+	assert.Equal(t, model.Timeout, result.Attempts[0].Response.Code)
+	assert.Equal(t, "ProcA", result.Attempts[1].ProcessorName, "should retry the same processor")
+	assert.Contains(t, result.Attempts[1].RoutingReason, "retrying same processor")
+	assert.Equal(t, 0, procB.CallCount())
+}
+
+func TestProcessPayment_SameProcessorRetryCountsAgainstBudget(t *testing.T) {
+	mon := health.NewMonitorWithConfig(50, 10*time.Minute)
+	procC := newDeterministicProcessor("ProcC", []string{"card"}, model.Approved)
+	cfg := DefaultConfig()
+	cfg.SameProcessorRetries = 1
+	cfg.RetryBackoff = time.Millisecond
+	orch := NewWithConfig([]processor.Processor{
+		newDeterministicProcessor("ProcA", []string{"card"}, model.ProcessorError),
+		newDeterministicProcessor("ProcB", []string{"card"}, model.Timeout),
+		procC,
+	}, mon, cfg)
+
+	req := model.PaymentRequest{
+		TransactionID: "tx-same-budget",
+		Amount:        100.0,
+		Currency:      "USD",
+		PaymentMethod: "card",
+		CustomerID:    "cust-1",
+	}
+	result := orch.ProcessPayment(context.Background(), req)
+
+	assert.Equal(t, model.StatusExhaustedRetries, result.Status)
+	require.Len(t, result.Attempts, 3)
+	assert.Equal(t, "ProcA", result.Attempts[0].ProcessorName)
+	assert.Equal(t, "ProcA", result.Attempts[1].ProcessorName)
+	assert.Equal(t, "ProcB", result.Attempts[2].ProcessorName)
+	assert.Equal(t, 0, procC.CallCount(), "same-processor retries consume the retry budget")
+}
+
+func TestProcessPayment_SameProcessorRetrySkipsSoftDecline(t *testing.T) {
+	mon := health.NewMonitorWithConfig(50, 10*time.Minute)
+	cfg := DefaultConfig()
+	cfg.SameProcessorRetries = 1
+	cfg.RetryBackoff = time.Millisecond
+	orch := NewWithConfig([]processor.Processor{
+		newDeterministicProcessor("ProcA", []string{"card"}, model.SoftDecline),
+		newDeterministicProcessor("ProcB", []string{"card"}, model.Approved),
+	}, mon, cfg)
+
+	result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+		TransactionID: "tx-same-soft",
+		Amount:        100.0,
+		Currency:      "USD",
+		PaymentMethod: "card",
+		CustomerID:    "cust-1",
+	})
+
+	require.Len(t, result.Attempts, 2)
+	assert.Equal(t, "ProcB", result.Attempts[1].ProcessorName, "soft declines fall back immediately")
+}
+
+func TestProcessPayment_CanaryGetsConfiguredShare(t *testing.T) {
+	mon := health.NewMonitorWithConfig(50, 10*time.Minute)
+	cfg := DefaultConfig()
+	cfg.Canary = &CanaryConfig{ProcessorName: "NewGateway", Percentage: 10}
+	cfg.Seed = 42
+	orch := NewWithConfig([]processor.Processor{
+		newDeterministicProcessor("ProcA", []string{"card"}, model.Approved),
+		newDeterministicProcessor("ProcB", []string{"card"}, model.Approved),
+		newDeterministicProcessor("NewGateway", []string{"card"}, model.Approved),
+	}, mon, cfg)
+
+	total := 2000
+	canaryPrimaries := 0
+	for i := 0; i < total; i++ {
+		result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+			TransactionID: fmt.Sprintf("tx-canary-%d", i),
+			Amount:        10.0,
+			Currency:      "USD",
+			PaymentMethod: "card",
+			CustomerID:    "cust-1",
+		})
+		require.NotEmpty(t, result.Attempts)
+		if result.Attempts[0].ProcessorName == "NewGateway" {
+			canaryPrimaries++
+			assert.Contains(t, result.Attempts[0].RoutingReason, "canary")
+		}
+	}
+
+	share := float64(canaryPrimaries) / float64(total)
+	assert.InDelta(t, 0.10, share, 0.03, "canary should be primary for ~10%% of payments, got %.2f%%", share*100)
+}
+
+func TestProcessPayment_CanaryFailureFallsBack(t *testing.T) {
+	mon := health.NewMonitorWithConfig(50, 10*time.Minute)
+	cfg := DefaultConfig()
+	cfg.Canary = &CanaryConfig{ProcessorName: "NewGateway", Percentage: 100}
+	orch := NewWithConfig([]processor.Processor{
+		newDeterministicProcessor("ProcA", []string{"card"}, model.Approved),
+		newDeterministicProcessor("NewGateway", []string{"card"}, model.ProcessorError),
+	}, mon, cfg)
+
+	result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+		TransactionID: "tx-canary-fallback",
+		Amount:        10.0,
+		Currency:      "USD",
+		PaymentMethod: "card",
+		CustomerID:    "cust-1",
+	})
+
+	assert.Equal(t, model.StatusApproved, result.Status)
+	require.Len(t, result.Attempts, 2)
+	assert.Equal(t, "NewGateway", result.Attempts[0].ProcessorName)
+	assert.Equal(t, "ProcA", result.Attempts[1].ProcessorName)
+	assert.Contains(t, result.Attempts[1].RoutingReason, "fallback")
+}
+
+func TestProcessPayment_PerMethodMaxRetries(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxRetries = 2
+	cfg.MethodMaxRetries = map[string]int{"pix": 4, "card": 1}
+
+	tests := []struct {
+		method           string
+		expectedAttempts int
+	}{
+		{"pix", 4},
+		{"card", 1},
+		{"oxxo", 2}, // not in the map: global default
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			methods := []string{"card", "pix", "oxxo"}
+			orch := NewWithConfig([]processor.Processor{
+				newDeterministicProcessor("ProcA", methods, model.SoftDecline),
+				newDeterministicProcessor("ProcB", methods, model.SoftDecline),
+				newDeterministicProcessor("ProcC", methods, model.SoftDecline),
+				newDeterministicProcessor("ProcD", methods, model.SoftDecline),
+			}, health.NewMonitorWithConfig(50, 10*time.Minute), cfg)
+
+			result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+				TransactionID: "tx-method-retries-" + tt.method,
+				Amount:        100.0,
+				Currency:      "BRL",
+				PaymentMethod: tt.method,
+				CustomerID:    "cust-1",
+			})
+
+			assert.Equal(t, model.StatusExhaustedRetries, result.Status)
+			assert.Len(t, result.Attempts, tt.expectedAttempts)
+		})
+	}
+}
+
+func TestProcessPayment_FailFastCurrencies(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxRetries = 3
+	cfg.MethodMaxRetries = map[string]int{"card": 4}
+	cfg.FailFastCurrencies = []string{"COP"}
+
+	tests := []struct {
+		currency         string
+		expectedAttempts int
+	}{
+		{"COP", 1}, // fail fast wins over the method's budget
+		{"USD", 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.currency, func(t *testing.T) {
+			orch := NewWithConfig([]processor.Processor{
+				newDeterministicProcessor("ProcA", []string{"card"}, model.ProcessorError),
+				newDeterministicProcessor("ProcB", []string{"card"}, model.ProcessorError),
+				newDeterministicProcessor("ProcC", []string{"card"}, model.ProcessorError),
+				newDeterministicProcessor("ProcD", []string{"card"}, model.ProcessorError),
+			}, health.NewMonitorWithConfig(50, 10*time.Minute), cfg)
+
+			result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+				TransactionID: "tx-fail-fast-" + tt.currency,
+				Amount:        100.0,
+				Currency:      tt.currency,
+				PaymentMethod: "card",
+				CustomerID:    "cust-1",
+			})
+
+			assert.Equal(t, model.StatusExhaustedRetries, result.Status)
+			assert.Len(t, result.Attempts, tt.expectedAttempts)
+		})
+	}
+}
+
+func TestProcessPayment_FailFastKeepsBestProcessorOverLastResort(t *testing.T) {
+	mon := health.NewMonitorWithConfig(50, 10*time.Minute)
+	for i := 0; i < 10; i++ {
+		mon.RecordOutcome("ProcA", model.Approved)
+		code := model.Approved
+		if i < 3 {
+			code = model.ProcessorError
+		}
+		mon.RecordOutcome("GlobalPay", code)
+	}
+	cfg := DefaultConfig()
+	cfg.LastResort = "GlobalPay"
+	cfg.FailFastCurrencies = []string{"COP"}
+	orch := NewWithConfig([]processor.Processor{
+		newDeterministicProcessor("ProcA", []string{"card"}, model.ProcessorError),
+		newDeterministicProcessor("GlobalPay", []string{"card"}, model.Approved),
+	}, mon, cfg)
+
+	result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+		TransactionID: "tx-fail-fast-last-resort",
+		Amount:        100.0,
+		Currency:      "COP",
+		PaymentMethod: "card",
+		CustomerID:    "cust-1",
+	})
+
+	require.Len(t, result.Attempts, 1)
+	assert.Equal(t, "ProcA", result.Attempts[0].ProcessorName, "the only attempt goes to the healthiest processor")
+}
+
+func TestProcessPayment_PublishesCompletionEvent(t *testing.T) {
+	mon := health.NewMonitorWithConfig(50, 10*time.Minute)
+	orch := New([]processor.Processor{
+		newDeterministicProcessor("ProcA", []string{"card"}, model.SoftDecline),
+		newDeterministicProcessor("ProcB", []string{"card"}, model.Approved),
+	}, mon)
+
+	var events []model.PaymentResult
+	orch.Subscribe(func(r model.PaymentResult) { events = append(events, r) })
+	orch.Subscribe(func(model.PaymentResult) { panic("broken subscriber") })
+
+	result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+		TransactionID: "tx-event",
+		Amount:        100.0,
+		Currency:      "USD",
+		PaymentMethod: "card",
+		CustomerID:    "cust-1",
+	})
+
+	assert.Equal(t, model.StatusApproved, result.Status, "a panicking subscriber must not affect the payment")
+	require.Len(t, events, 1)
+	assert.Equal(t, result.TransactionID, events[0].TransactionID)
+	assert.Equal(t, model.StatusApproved, events[0].Status)
+	assert.Len(t, events[0].Attempts, 2)
+}
+
+func TestProcessPayment_LastResortTriedDespiteOpenCircuit(t *testing.T) {
+	mon := health.NewMonitorWithConfig(50, 10*time.Minute)
+	for i := 0; i < 20; i++ {
+		mon.RecordOutcome("GlobalPay", model.ProcessorError)
+	}
+	require.True(t, mon.IsCircuitOpen("GlobalPay"))
+
+	globalPay := newDeterministicProcessor("GlobalPay", []string{"card"}, model.Approved)
+	procC := newDeterministicProcessor("ProcC", []string{"card"}, model.SoftDecline)
+	cfg := DefaultConfig()
+	cfg.LastResort = "GlobalPay"
+	orch := NewWithConfig([]processor.Processor{
+		globalPay,
+		newDeterministicProcessor("ProcA", []string{"card"}, model.SoftDecline),
+		newDeterministicProcessor("ProcB", []string{"card"}, model.ProcessorError),
+		procC,
+	}, mon, cfg)
+
+	result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+		TransactionID: "tx-last-resort",
+		Amount:        100.0,
+		Currency:      "USD",
+		PaymentMethod: "card",
+		CustomerID:    "cust-1",
+	})
+
+	assert.Equal(t, model.StatusApproved, result.Status)
+	require.Len(t, result.Attempts, 3)
+	assert.Equal(t, "GlobalPay", result.Attempts[2].ProcessorName, "last resort takes the final attempt")
+	assert.Contains(t, result.Attempts[2].RoutingReason, "last resort")
+	assert.Equal(t, 0, procC.CallCount(), "last resort displaces the lowest-ranked fallback")
+}
+
+func TestProcessPayment_LastResortOnlyCandidate(t *testing.T) {
+	mon := health.NewMonitorWithConfig(50, 10*time.Minute)
+	for i := 0; i < 20; i++ {
+		mon.RecordOutcome("GlobalPay", model.ProcessorError)
+		mon.RecordOutcome("ProcA", model.ProcessorError)
+	}
+
+	cfg := DefaultConfig()
+	cfg.LastResort = "GlobalPay"
+	orch := NewWithConfig([]processor.Processor{
+		newDeterministicProcessor("ProcA", []string{"card"}, model.Approved),
+		newDeterministicProcessor("GlobalPay", []string{"card"}, model.Approved),
+	}, mon, cfg)
+
+	result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+		TransactionID: "tx-last-resort-only",
+		Amount:        100.0,
+		Currency:      "USD",
+		PaymentMethod: "card",
+		CustomerID:    "cust-1",
+	})
+
+	assert.Equal(t, model.StatusApproved, result.Status)
+	require.Len(t, result.Attempts, 1)
+	assert.Equal(t, "GlobalPay", result.Attempts[0].ProcessorName)
+	assert.Contains(t, result.Attempts[0].RoutingReason, "last resort")
+}
+
+func TestProcessPayment_VerificationRoutesToOpenCircuit(t *testing.T) {
+	mon := health.NewMonitorWithConfig(50, 10*time.Minute)
+	for i := 0; i < 20; i++ {
+		mon.RecordOutcome("ProcA", model.ProcessorError)
+	}
+	require.Equal(t, health.StatusOpen, mon.GetHealth("ProcA").Status)
+	orch := New([]processor.Processor{
+		newDeterministicProcessor("ProcA", []string{"card"}, model.Approved),
+	}, mon)
+
+	tests := []struct {
+		name         string
+		amount       float64
+		avoid        bool
+		wantStatus   model.PaymentStatus
+		wantAttempts int
+	}{
+		{"zero-amount verification", 0, false, model.StatusApproved, 1},
+		{"verification avoiding degraded processors", 0, true, model.StatusApproved, 1},
+		{"regular payment skips the open circuit", 10, false, model.StatusDeclined, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+				TransactionID:   "tx-verify-" + tt.name,
+				Amount:          tt.amount,
+				Currency:        "USD",
+				PaymentMethod:   "card",
+				CustomerID:      "cust-1",
+				AllowZeroAmount: true,
+				AvoidDegraded:   tt.avoid,
+			})
+			assert.Equal(t, tt.wantStatus, result.Status)
+			assert.Len(t, result.Attempts, tt.wantAttempts)
+		})
+	}
+}
+
+func TestProcessPayment_LastResortDisabled(t *testing.T) {
+	mon := health.NewMonitorWithConfig(50, 10*time.Minute)
+	for i := 0; i < 20; i++ {
+		mon.RecordOutcome("GlobalPay", model.ProcessorError)
+	}
+
+	cfg := DefaultConfig()
+	cfg.LastResort = ""
+	globalPay := newDeterministicProcessor("GlobalPay", []string{"card"}, model.Approved)
+	orch := NewWithConfig([]processor.Processor{
+		newDeterministicProcessor("ProcA", []string{"card"}, model.SoftDecline),
+		globalPay,
+	}, mon, cfg)
+
+	result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+		TransactionID: "tx-no-last-resort",
+		Amount:        100.0,
+		Currency:      "USD",
+		PaymentMethod: "card",
+		CustomerID:    "cust-1",
+	})
+
+	assert.Equal(t, model.StatusExhaustedRetries, result.Status)
+	assert.Equal(t, 0, globalPay.CallCount(), "open circuit is skipped without a last resort")
+}
+
+func TestProcessPayment_RecordsTotalLatency(t *testing.T) {
+	orch := New([]processor.Processor{
+		newDeterministicProcessor("ProcA", []string{"card"}, model.Approved),
+	}, health.NewMonitorWithConfig(50, 10*time.Minute))
+
+	result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+		TransactionID: "tx-latency",
+		Amount:        100.0,
+		Currency:      "USD",
+		PaymentMethod: "card",
+		CustomerID:    "cust-1",
+	})
+
+	assert.Greater(t, result.TotalLatency, time.Duration(0))
+	stored, ok := orch.GetPaymentHistory("tx-latency")
+	require.True(t, ok)
+	assert.Equal(t, result.TotalLatency, stored.TotalLatency)
+}
+
+// traceCapturingProcessor records the trace ID it receives on each call.
+type traceCapturingProcessor struct {
+	*deterministicProcessor
+	mu       sync.Mutex
+	traceIDs []string
+}
+
+func (p *traceCapturingProcessor) Process(ctx context.Context, req model.PaymentRequest) model.ProcessorResponse {
+	p.mu.Lock()
+	p.traceIDs = append(p.traceIDs, trace.TraceIDFromContext(ctx))
+	p.mu.Unlock()
+	return p.deterministicProcessor.Process(ctx, req)
+}
+
+func TestProcessPayment_TraceIDPropagation(t *testing.T) {
+	procA := &traceCapturingProcessor{deterministicProcessor: newDeterministicProcessor("ProcA", []string{"card"}, model.SoftDecline)}
+	procB := &traceCapturingProcessor{deterministicProcessor: newDeterministicProcessor("ProcB", []string{"card"}, model.Approved)}
+	orch := New([]processor.Processor{procA, procB}, health.NewMonitorWithConfig(50, 10*time.Minute))
+
+	ctx := trace.WithTraceID(context.Background(), "trace-abc")
+	result := orch.ProcessPayment(ctx, model.PaymentRequest{
+		TransactionID: "tx-trace",
+		Amount:        100.0,
+		Currency:      "USD",
+		PaymentMethod: "card",
+		CustomerID:    "cust-1",
+	})
+
+	assert.Equal(t, []string{"trace-abc"}, procA.traceIDs)
+	assert.Equal(t, []string{"trace-abc"}, procB.traceIDs)
+	require.Len(t, result.Attempts, 2)
+	for _, a := range result.Attempts {
+		assert.Equal(t, "trace-abc", a.TraceID)
+	}
+}
+
+func TestProcessPayment_MaxProcessorsPerPayment(t *testing.T) {
+	mon := health.NewMonitorWithConfig(50, 10*time.Minute)
+	// Seed distinct health scores: ProcB > ProcC > ProcD > ProcA
+	seed := map[string]int{"ProcA": 6, "ProcB": 10, "ProcC": 9, "ProcD": 7}
+	for name, approvals := range seed {
+		for i := 0; i < 10; i++ {
+			code := model.ProcessorError
+			if i < approvals {
+				code = model.Approved
+			}
+			mon.RecordOutcome(name, code)
+		}
+	}
+
+	procs := map[string]*deterministicProcessor{}
+	var all []processor.Processor
+	for _, name := range []string{"ProcA", "ProcB", "ProcC", "ProcD"} {
+		procs[name] = newDeterministicProcessor(name, []string{"card"}, model.SoftDecline)
+		all = append(all, procs[name])
+	}
+
+	cfg := DefaultConfig()
+	cfg.MaxRetries = 4
+	cfg.MaxProcessorsPerPayment = 2
+	orch := NewWithConfig(all, mon, cfg)
+
+	result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+		TransactionID: "tx-cap",
+		Amount:        1.0,
+		Currency:      "USD",
+		PaymentMethod: "card",
+		CustomerID:    "cust-1",
+	})
+
+	assert.Equal(t, model.StatusExhaustedRetries, result.Status)
+	require.Len(t, result.Attempts, 2, "cap should stop routing before the retry budget is used")
+	assert.Equal(t, "ProcB", result.Attempts[0].ProcessorName)
+	assert.Equal(t, "ProcC", result.Attempts[1].ProcessorName)
+	assert.Equal(t, 0, procs["ProcA"].CallCount())
+	assert.Equal(t, 0, procs["ProcD"].CallCount())
+}
+
+func TestProcessPayment_AttemptClassification(t *testing.T) {
+	mon := health.NewMonitorWithConfig(50, 10*time.Minute)
+	procs := []processor.Processor{
+		newDeterministicProcessor("ProcA", []string{"card"}, model.SoftDecline),
+		newDeterministicProcessor("ProcB", []string{"card"}, model.Timeout),
+		newDeterministicProcessor("ProcC", []string{"card"}, model.DeclinedFraud),
+	}
+	cfg := DefaultConfig()
+	orch := NewWithConfig(procs, mon, cfg)
+
+	result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+		TransactionID: "tx-classify",
+		Amount:        100.0,
+		Currency:      "USD",
+		PaymentMethod: "card",
+		CustomerID:    "cust-1",
+	})
+
+	require.Len(t, result.Attempts, 3)
+	assert.Equal(t, model.ClassificationSoft, result.Attempts[0].Classification)
+	assert.Equal(t, model.ClassificationTransient, result.Attempts[1].Classification)
+	assert.Equal(t, model.ClassificationBusinessDecline, result.Attempts[2].Classification)
+}
+
+func TestProcessPayment_BatchedHealthRecorder(t *testing.T) {
+	mon := health.NewMonitorWithConfig(50, 10*time.Minute)
+	rec := health.NewBatchedRecorder(mon, 100, time.Hour)
+	defer rec.Close()
+	cfg := DefaultConfig()
+	cfg.HealthRecorder = rec
+	orch := NewWithConfig([]processor.Processor{
+		newDeterministicProcessor("ProcA", []string{"card"}, model.Approved),
+	}, mon, cfg)
+
+	orch.ProcessPayment(context.Background(), model.PaymentRequest{
+		TransactionID: "tx-batched",
+		Amount:        100.0,
+		Currency:      "USD",
+		PaymentMethod: "card",
+		CustomerID:    "cust-1",
+	})
+	rec.Flush()
+	assert.Equal(t, 1, mon.GetHealth("ProcA").TotalRecent, "outcome should reach the monitor through the recorder")
+
+	orch.ProcessPayment(context.Background(), model.PaymentRequest{
+		TransactionID: "tx-batched-2",
+		Amount:        100.0,
+		Currency:      "USD",
+		PaymentMethod: "card",
+		CustomerID:    "cust-1",
+	})
+	orch.Reset()
+	rec.Flush()
+	assert.Equal(t, 0, mon.GetHealth("ProcA").TotalRecent, "Reset should flush pending outcomes before clearing")
+}
+
+func TestProcessPayment_PreferredProcessor(t *testing.T) {
+	tests := []struct {
+		name      string
+		preferred string
+		openProcC bool
+		wantOrder []string
+	}{
+		{"preferred tried first, fallbacks by health", "ProcC", false, []string{"ProcC", "ProcA", "ProcB"}},
+		{"ignored when circuit open", "ProcC", true, []string{"ProcA", "ProcB"}},
+		{"ignored when unknown", "NoSuchPay", false, []string{"ProcA", "ProcB", "ProcC"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mon := health.NewMonitorWithConfig(50, 10*time.Minute)
+			// Health ordering: ProcA (1.0) > ProcB (0.9) > ProcC (0.6 or open)
+			for i := 0; i < 10; i++ {
+				mon.RecordOutcome("ProcA", model.Approved)
+				codeB := model.Approved
+				if i == 0 {
+					codeB = model.ProcessorError
+				}
+				mon.RecordOutcome("ProcB", codeB)
+				codeC := model.Approved
+				if i < 4 || tt.openProcC {
+					codeC = model.ProcessorError
+				}
+				mon.RecordOutcome("ProcC", codeC)
+			}
+
+			cfg := DefaultConfig()
+			orch := NewWithConfig([]processor.Processor{
+				newDeterministicProcessor("ProcA", []string{"card"}, model.SoftDecline),
+				newDeterministicProcessor("ProcB", []string{"card"}, model.SoftDecline),
+				newDeterministicProcessor("ProcC", []string{"card"}, model.SoftDecline),
+			}, mon, cfg)
+
+			result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+				TransactionID:      "tx-preferred",
+				Amount:             100.0,
+				Currency:           "USD",
+				PaymentMethod:      "card",
+				CustomerID:         "cust-1",
+				PreferredProcessor: tt.preferred,
+			})
+
+			var order []string
+			for _, a := range result.Attempts {
+				order = append(order, a.ProcessorName)
+			}
+			assert.Equal(t, tt.wantOrder, order)
+			if tt.wantOrder[0] == tt.preferred {
+				assert.Contains(t, result.Attempts[0].RoutingReason, "preferred")
+			}
+		})
+	}
+}
+
+func TestProcessPayment_AttemptHealthDeltas(t *testing.T) {
+	mon := health.NewMonitorWithConfig(50, 10*time.Minute)
+	for i := 0; i < 3; i++ {
+		mon.RecordOutcome("ProcA", model.Approved)
+	}
+	cfg := DefaultConfig()
+	orch := NewWithConfig([]processor.Processor{
+		newDeterministicProcessor("ProcA", []string{"card"}, model.ProcessorError),
+		newDeterministicProcessor("ProcB", []string{"card"}, model.Approved),
+	}, mon, cfg)
+
+	result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+		TransactionID: "tx-deltas",
+		Amount:        100.0,
+		Currency:      "USD",
+		PaymentMethod: "card",
+		CustomerID:    "cust-1",
+	})
+
+	require.Len(t, result.Attempts, 2)
+	assert.InDelta(t, 1.0, result.Attempts[0].HealthBefore, 0.001)
+	assert.InDelta(t, 0.75, result.Attempts[0].HealthAfter, 0.001, "3 approvals + the recorded error")
+	assert.InDelta(t, 1.0, result.Attempts[1].HealthBefore, 0.001)
+	assert.InDelta(t, 1.0, result.Attempts[1].HealthAfter, 0.001)
+	assert.InDelta(t, mon.GetHealth("ProcA").HealthScore, result.Attempts[0].HealthAfter, 0.001)
+}
+
+// currencyFeeProcessor charges a per-currency fee table.
+type currencyFeeProcessor struct {
+	*deterministicProcessor
+	fees processor.FeeTable
+}
+
+func (p *currencyFeeProcessor) FeeBps(currency string) int { return p.fees.BpsFor(currency) }
+func (p *currencyFeeProcessor) FeeBpsForAmount(amount float64, currency string) int {
+	return p.fees.BpsForAmount(amount, currency)
+}
+
+func TestProcessPayment_CostAwareRoutingByCurrency(t *testing.T) {
+	tests := []struct {
+		name       string
+		currency   string
+		wantWinner string
+		wantFee    float64
+		wantSecond string
+	}{
+		{"USD prefers the USD-discounted processor", "USD", "ProcB", 2.00, "ProcA"},
+		{"BRL uses default rates", "BRL", "ProcA", 2.50, "ProcB"},
+		{"unknown currency falls back to defaults", "XYZ", "ProcA", 2.50, "ProcB"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mon := health.NewMonitorWithConfig(50, 10*time.Minute)
+			procs := []processor.Processor{
+				&currencyFeeProcessor{
+					newDeterministicProcessor("ProcA", []string{"card"}, model.Approved),
+					processor.FeeTable{DefaultBps: 250, CurrencyBps: map[string]int{"USD": 400}},
+				},
+				&currencyFeeProcessor{
+					newDeterministicProcessor("ProcB", []string{"card"}, model.Approved),
+					processor.FeeTable{DefaultBps: 300, CurrencyBps: map[string]int{"USD": 200}},
+				},
+			}
+			cfg := DefaultConfig()
+			cfg.CostAwareRouting = true
+			orch := NewWithConfig(procs, mon, cfg)
+
+			req := model.PaymentRequest{
+				TransactionID: "tx-cost-" + tt.currency,
+				Amount:        100.0,
+				Currency:      tt.currency,
+				PaymentMethod: "card",
+				CustomerID:    "cust-1",
+			}
+			eligible, _ := orch.getEligibleProcessors(context.Background(), req)
+			require.Len(t, eligible, 2)
+			assert.Equal(t, tt.wantSecond, eligible[1].proc.Name())
+
+			result := orch.ProcessPayment(context.Background(), req)
+			require.Equal(t, model.StatusApproved, result.Status)
+			assert.Equal(t, tt.wantWinner, result.WinningProcessor)
+			assert.InDelta(t, tt.wantFee, result.FeeCharged, 0.001)
+			assert.InDelta(t, 100.0-tt.wantFee, result.NetAmount, 0.001)
+		})
+	}
+}
+
+func TestProcessPayment_CostAwareRoutingByAmountTier(t *testing.T) {
+	tests := []struct {
+		name       string
+		amount     float64
+		wantWinner string
+		wantFee    float64
+	}{
+		{"below the tier the flat rate is cheaper", 500.0, "ProcA", 12.50},
+		{"at the tier the tiered rate is cheaper", 1000.0, "ProcB", 20.00},
+		{"above the tier", 2000.0, "ProcB", 40.00},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			procs := []processor.Processor{
+				&currencyFeeProcessor{
+					newDeterministicProcessor("ProcA", []string{"card"}, model.Approved),
+					processor.FeeTable{DefaultBps: 250},
+				},
+				&currencyFeeProcessor{
+					newDeterministicProcessor("ProcB", []string{"card"}, model.Approved),
+					processor.FeeTable{DefaultBps: 300, Tiers: []processor.FeeTier{{MinAmount: 1000, DiscountBps: 100}}},
+				},
+			}
+			cfg := DefaultConfig()
+			cfg.CostAwareRouting = true
+			orch := NewWithConfig(procs, health.NewMonitorWithConfig(50, 10*time.Minute), cfg)
+
+			result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+				TransactionID: "tx-cost-tier",
+				Amount:        tt.amount,
+				Currency:      "BRL",
+				PaymentMethod: "card",
+				CustomerID:    "cust-1",
+			})
+			require.Equal(t, model.StatusApproved, result.Status)
+			assert.Equal(t, tt.wantWinner, result.WinningProcessor)
+			assert.InDelta(t, tt.wantFee, result.FeeCharged, 0.001)
+		})
+	}
+}
+
+func TestProcessPayment_CostAwareRoutingPrefersHealthyOverCheap(t *testing.T) {
+	mon := health.NewMonitorWithConfig(10, 10*time.Minute)
+	// ProcA is cheaper but degraded (score 0.4)
+	for i := 0; i < 10; i++ {
+		code := model.Approved
+		if i >= 4 {
+			code = model.ProcessorError
+		}
+		mon.RecordOutcome("ProcA", code)
+	}
+	procs := []processor.Processor{
+		&feeProcessor{newDeterministicProcessor("ProcA", []string{"card"}, model.Approved), 100},
+		&feeProcessor{newDeterministicProcessor("ProcB", []string{"card"}, model.Approved), 300},
+	}
+	cfg := DefaultConfig()
+	cfg.CostAwareRouting = true
+	orch := NewWithConfig(procs, mon, cfg)
+
+	eligible, _ := orch.getEligibleProcessors(context.Background(), model.PaymentRequest{
+		TransactionID: "tx-cost-health", Amount: 100, Currency: "USD", PaymentMethod: "card",
+	})
+	require.Len(t, eligible, 2)
+	assert.Equal(t, "ProcB", eligible[0].proc.Name(), "healthy processors rank ahead of cheaper degraded ones")
+}
+
+func TestProcessPayment_OpenCircuitPolicyAllOpen(t *testing.T) {
+	tests := []struct {
+		name         string
+		policy       OpenCircuitPolicy
+		wantStatus   model.PaymentStatus
+		wantAttempts int
+	}{
+		{"default skips open circuits", "", model.StatusDeclined, 0},
+		{"skip declines without attempting", OpenCircuitSkip, model.StatusDeclined, 0},
+		{"penalize still tries open circuits", OpenCircuitPenalize, model.StatusExhaustedRetries, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mon := health.NewMonitorWithConfig(50, 10*time.Minute)
+			for i := 0; i < 20; i++ {
+				mon.RecordOutcome("ProcA", model.ProcessorError)
+				mon.RecordOutcome("ProcB", model.ProcessorError)
+			}
+			procs := []processor.Processor{
+				newDeterministicProcessor("ProcA", []string{"card"}, model.SoftDecline),
+				newDeterministicProcessor("ProcB", []string{"card"}, model.SoftDecline),
+			}
+			cfg := DefaultConfig()
+			cfg.OpenCircuitPolicy = tt.policy
+			orch := NewWithConfig(procs, mon, cfg)
+
+			result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+				TransactionID: "tx-open-policy",
+				Amount:        100.0,
+				Currency:      "USD",
+				PaymentMethod: "card",
+				CustomerID:    "cust-1",
+			})
+
+			assert.Equal(t, tt.wantStatus, result.Status)
+			require.Len(t, result.Attempts, tt.wantAttempts)
+			for _, a := range result.Attempts {
+				assert.Contains(t, a.RoutingReason, "penalized")
+			}
+		})
+	}
+}
+
+func TestProcessPayment_OpenCircuitPenalizedRanksLast(t *testing.T) {
+	mon := health.NewMonitorWithConfig(10, 10*time.Minute)
+	for i := 0; i < 10; i++ {
+		mon.RecordOutcome("ProcA", model.ProcessorError)
+	}
+	// ProcB is degraded but closed, so it still outranks the open ProcA
+	for i := 0; i < 10; i++ {
+		code := model.Approved
+		if i >= 3 {
+			code = model.ProcessorError
+		}
+		mon.RecordOutcome("ProcB", code)
+	}
+	procA := newDeterministicProcessor("ProcA", []string{"card"}, model.Approved)
+	procB := newDeterministicProcessor("ProcB", []string{"card"}, model.SoftDecline)
+	cfg := DefaultConfig()
+	cfg.OpenCircuitPolicy = OpenCircuitPenalize
+	orch := NewWithConfig([]processor.Processor{procA, procB}, mon, cfg)
+
+	result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+		TransactionID:      "tx-penalized",
+		Amount:             100.0,
+		Currency:           "USD",
+		PaymentMethod:      "card",
+		CustomerID:         "cust-1",
+		PreferredProcessor: "ProcA",
+	})
+
+	require.Equal(t, model.StatusApproved, result.Status)
+	require.Len(t, result.Attempts, 2)
+	assert.Equal(t, "ProcB", result.Attempts[0].ProcessorName)
+	assert.Equal(t, "ProcA", result.Attempts[1].ProcessorName, "open circuit is tried only after closed ones")
+	assert.Contains(t, result.Attempts[1].RoutingReason, "penalized: circuit open")
+}
+
+func TestProcessPayment_StoresOriginalRequest(t *testing.T) {
+	mon := health.NewMonitorWithConfig(50, 10*time.Minute)
+	procs := []processor.Processor{
+		newDeterministicProcessor("ProcA", []string{"pix"}, model.Approved),
+		newDeterministicProcessor("ProcB", []string{"pix"}, model.Approved),
+	}
+	orch := New(procs, mon)
+
+	req := model.PaymentRequest{
+		TransactionID:      "tx-stored-req",
+		Amount:             321.09,
+		Currency:           "BRL",
+		PaymentMethod:      "pix",
+		CustomerID:         "cust-7",
+		ExcludeProcessors:  []string{"ProcA"},
+		PreferredProcessor: "ProcB",
+	}
+	result := orch.ProcessPayment(context.Background(), req)
+	assert.Equal(t, req, result.Request)
+
+	stored, ok := orch.GetPaymentHistory("tx-stored-req")
+	require.True(t, ok)
+	assert.Equal(t, req, stored.Request)
+}
+
+func TestProcessPayment_HardDeclineRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		code         model.ResponseCode
+		wantStatus   model.PaymentStatus
+		wantAttempts []string
+	}{
+		{"insufficient funds falls back once", model.DeclinedInsufficientFunds, model.StatusDeclined, []string{"ProcA", "ProcB"}},
+		{"fraud stops immediately", model.DeclinedFraud, model.StatusDeclined, []string{"ProcA"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mon := health.NewMonitorWithConfig(50, 10*time.Minute)
+			procs := []processor.Processor{
+				newDeterministicProcessor("ProcA", []string{"card"}, tt.code),
+				newDeterministicProcessor("ProcB", []string{"card"}, tt.code),
+				newDeterministicProcessor("ProcC", []string{"card"}, model.Approved),
+			}
+			cfg := DefaultConfig()
+			cfg.HardDeclineRetries = map[model.ResponseCode]int{model.DeclinedInsufficientFunds: 1}
+			orch := NewWithConfig(procs, mon, cfg)
+
+			result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+				TransactionID: "tx-hard-retry",
+				Amount:        100.0,
+				Currency:      "USD",
+				PaymentMethod: "card",
+				CustomerID:    "cust-1",
+			})
+
+			assert.Equal(t, tt.wantStatus, result.Status)
+			var attempted []string
+			for _, a := range result.Attempts {
+				attempted = append(attempted, a.ProcessorName)
+			}
+			assert.Equal(t, tt.wantAttempts, attempted)
+			require.NotNil(t, result.FinalResponse)
+			assert.Equal(t, tt.code, result.FinalResponse.Code)
+			assert.Equal(t, 0, procs[2].(*deterministicProcessor).CallCount())
+		})
+	}
+}
+
+func TestProcessPayment_HardDeclineRetryCanApprove(t *testing.T) {
+	mon := health.NewMonitorWithConfig(50, 10*time.Minute)
+	procs := []processor.Processor{
+		newDeterministicProcessor("ProcA", []string{"card"}, model.DeclinedInsufficientFunds),
+		newDeterministicProcessor("ProcB", []string{"card"}, model.Approved),
+	}
+	cfg := DefaultConfig()
+	cfg.HardDeclineRetries = map[model.ResponseCode]int{model.DeclinedInsufficientFunds: 1}
+	orch := NewWithConfig(procs, mon, cfg)
+
+	result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+		TransactionID: "tx-hard-retry-ok",
+		Amount:        100.0,
+		Currency:      "USD",
+		PaymentMethod: "card",
+		CustomerID:    "cust-1",
+	})
+
+	assert.Equal(t, model.StatusApproved, result.Status)
+	require.Len(t, result.Attempts, 2)
+	assert.Contains(t, result.Attempts[1].RoutingReason, "declined_insufficient_funds")
+}
+
+func TestProcessPayment_ApprovalLatencySLA(t *testing.T) {
+	tests := []struct {
+		name       string
+		sla        time.Duration
+		wantHealth float64
+	}{
+		{"slow approval counts against health", 5 * time.Millisecond, 0.5},
+		{"approval within SLA", 50 * time.Millisecond, 1.0},
+		{"SLA disabled", 0, 1.0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mon := health.NewMonitorWithConfig(50, 10*time.Minute)
+			mon.RecordOutcome("ProcA", model.Approved)
+			// deterministicProcessor reports a 10ms latency
+			procs := []processor.Processor{newDeterministicProcessor("ProcA", []string{"card"}, model.Approved)}
+			cfg := DefaultConfig()
+			cfg.ApprovalLatencySLA = tt.sla
+			orch := NewWithConfig(procs, mon, cfg)
+
+			result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+				TransactionID: "tx-sla",
+				Amount:        100.0,
+				Currency:      "USD",
+				PaymentMethod: "card",
+				CustomerID:    "cust-1",
+			})
+
+			assert.Equal(t, model.StatusApproved, result.Status, "the payment is approved either way")
+			assert.Equal(t, model.Approved, result.FinalResponse.Code)
+			h := mon.GetHealth("ProcA")
+			assert.Equal(t, tt.wantHealth, h.HealthScore)
+			assert.Equal(t, tt.wantHealth, result.Attempts[0].HealthAfter)
+			if tt.wantHealth < 1 {
+				assert.Equal(t, model.Timeout, h.LastFailureCode)
+				assert.Contains(t, h.LastFailureMessage, "latency SLA")
+			}
+		})
+	}
+}
+
+func TestProcessPayment_ApprovedWhileDegraded(t *testing.T) {
+	tests := []struct {
+		name     string
+		degraded bool
+		flag     bool
+		want     bool
+	}{
+		{"degraded approval is flagged", true, true, true},
+		{"healthy approval is not flagged", false, true, false},
+		{"flagging disabled", true, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mon := health.NewMonitorWithConfig(10, 10*time.Minute)
+			if tt.degraded {
+				// Score 0.3: degraded but above the circuit breaker threshold
+				for i := 0; i < 10; i++ {
+					code := model.ProcessorError
+					if i < 3 {
+						code = model.Approved
+					}
+					mon.RecordOutcome("ProcA", code)
+				}
+				require.Equal(t, health.StatusDegraded, mon.GetHealth("ProcA").Status)
+			}
+			procs := []processor.Processor{newDeterministicProcessor("ProcA", []string{"card"}, model.Approved)}
+			cfg := DefaultConfig()
+			cfg.FlagDegradedApprovals = tt.flag
+			orch := NewWithConfig(procs, mon, cfg)
+
+			result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+				TransactionID: "tx-degraded-approval",
+				Amount:        100.0,
+				Currency:      "USD",
+				PaymentMethod: "card",
+				CustomerID:    "cust-1",
+			})
+
+			require.Equal(t, model.StatusApproved, result.Status)
+			assert.Equal(t, tt.want, result.ApprovedWhileDegraded)
+		})
+	}
+}
+
+func TestProcessPayment_PendingIsTerminal(t *testing.T) {
+	mon := health.NewMonitorWithConfig(10, 10*time.Minute)
+	procA, err := processor.NewScriptedProcessor(processor.ScriptedConfig{
+		ProcessorName: "ProcA",
+		Methods:       []string{"card"},
+		Codes:         []model.ResponseCode{model.Pending},
+	})
+	require.NoError(t, err)
+	procB := newDeterministicProcessor("ProcB", []string{"card"}, model.Approved)
+	orch := New([]processor.Processor{procA, procB}, mon)
+
+	result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+		TransactionID: "tx-pending",
+		Amount:        100.0,
+		Currency:      "USD",
+		PaymentMethod: "card",
+		CustomerID:    "cust-1",
+	})
+
+	assert.Equal(t, model.StatusPending, result.Status)
+	require.Len(t, result.Attempts, 1, "pending must not fall back")
+	assert.Equal(t, "ProcA", result.Attempts[0].ProcessorName)
+	require.NotNil(t, result.FinalResponse)
+	assert.Equal(t, model.Pending, result.FinalResponse.Code)
+	assert.Empty(t, result.WinningProcessor)
+	assert.Equal(t, 0, mon.GetHealth("ProcA").TotalRecent, "pending is not a health outcome")
+
+	stored, ok := orch.GetPaymentHistory("tx-pending")
+	require.True(t, ok)
+	assert.Equal(t, model.StatusPending, stored.Status)
+}
+
+func TestResolvePending(t *testing.T) {
+	newPending := func(t *testing.T) *Orchestrator {
+		procA, err := processor.NewScriptedProcessor(processor.ScriptedConfig{
+			ProcessorName: "ProcA",
+			Methods:       []string{"card"},
+			Codes:         []model.ResponseCode{model.Pending},
+		})
+		require.NoError(t, err)
+		orch := New([]processor.Processor{procA}, health.NewMonitorWithConfig(10, 10*time.Minute))
+		orch.ProcessPayment(context.Background(), model.PaymentRequest{
+			TransactionID: "tx-pending",
+			Amount:        100.0,
+			Currency:      "USD",
+			PaymentMethod: "card",
+			CustomerID:    "cust-1",
+		})
+		return orch
+	}
+
+	tests := []struct {
+		name       string
+		code       model.ResponseCode
+		wantStatus model.PaymentStatus
+		wantWinner string
+	}{
+		{"approved", model.Approved, model.StatusApproved, "ProcA"},
+		{"soft decline", model.SoftDecline, model.StatusDeclined, ""},
+		{"hard decline", model.DeclinedInsufficientFunds, model.StatusDeclined, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orch := newPending(t)
+			result, err := orch.ResolvePending("tx-pending", model.ProcessorResponse{Code: tt.code})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, result.Status)
+			assert.Equal(t, tt.wantWinner, result.WinningProcessor)
+			require.NotNil(t, result.FinalResponse)
+			assert.Equal(t, tt.code, result.FinalResponse.Code)
+			assert.Equal(t, "ProcA", result.FinalResponse.ProcessorName)
+			assert.Equal(t, 1, orch.HealthMonitor().GetHealth("ProcA").TotalRecent, "the resolution counts toward health")
+
+			stored, _ := orch.GetPaymentHistory("tx-pending")
+			assert.Equal(t, tt.wantStatus, stored.Status)
+
+			_, err = orch.ResolvePending("tx-pending", model.ProcessorResponse{Code: model.Approved})
+			assert.ErrorIs(t, err, ErrPaymentNotPending, "final states cannot transition")
+		})
+	}
+
+	t.Run("approval charges the processor fee", func(t *testing.T) {
+		orch := newPending(t)
+		result, err := orch.ResolvePending("tx-pending", model.ProcessorResponse{Code: model.Approved})
+		require.NoError(t, err)
+		assert.Equal(t, result.Request.Amount-result.FeeCharged, result.NetAmount)
+	})
+
+	t.Run("stats count the payment once resolved", func(t *testing.T) {
+		orch := newPending(t)
+		assert.Empty(t, orch.RetryDepth().Failed, "a pending payment is not counted yet")
+		assert.Empty(t, orch.MethodApprovals())
+
+		_, err := orch.ResolvePending("tx-pending", model.ProcessorResponse{Code: model.Approved})
+		require.NoError(t, err)
+		depth := orch.RetryDepth()
+		assert.Equal(t, int64(1), depth.Succeeded[1])
+		assert.Empty(t, depth.Failed)
+		assert.Equal(t, MethodApproval{Total: 1, Approved: 1, ApprovalRate: 1}, orch.MethodApprovals()["card"])
+	})
+
+	t.Run("unknown transaction", func(t *testing.T) {
+		orch := newPending(t)
+		_, err := orch.ResolvePending("tx-missing", model.ProcessorResponse{Code: model.Approved})
+		assert.ErrorIs(t, err, ErrPaymentNotFound)
+	})
+
+	t.Run("non-final code", func(t *testing.T) {
+		orch := newPending(t)
+		for _, code := range []model.ResponseCode{model.Pending, model.Timeout, model.ProcessorError} {
+			_, err := orch.ResolvePending("tx-pending", model.ProcessorResponse{Code: code})
+			assert.ErrorIs(t, err, ErrInvalidResolution, code)
+		}
+		stored, _ := orch.GetPaymentHistory("tx-pending")
+		assert.Equal(t, model.StatusPending, stored.Status)
+	})
+}
+
+func TestApplyCallback(t *testing.T) {
+	procA, err := processor.NewScriptedProcessor(processor.ScriptedConfig{
+		ProcessorName: "ProcA",
+		Methods:       []string{"card"},
+		Codes:         []model.ResponseCode{model.Pending},
+	})
+	require.NoError(t, err)
+	orch := New([]processor.Processor{procA}, health.NewMonitorWithConfig(10, 10*time.Minute))
+	ctx := trace.WithTraceID(context.Background(), "trace-callback")
+	orch.ProcessPayment(ctx, model.PaymentRequest{
+		TransactionID: "tx-callback",
+		Amount:        100.0,
+		Currency:      "USD",
+		PaymentMethod: "card",
+		CustomerID:    "cust-1",
+	})
+
+	_, err = orch.ApplyCallback("tx-callback", model.ProcessorResponse{ProcessorName: "ProcB", Code: model.Approved})
+	assert.ErrorIs(t, err, ErrProcessorMismatch)
+
+	result, err := orch.ApplyCallback("tx-callback", model.ProcessorResponse{ProcessorName: "ProcA", Code: model.Approved})
+	require.NoError(t, err)
+	assert.Equal(t, model.StatusApproved, result.Status)
+	assert.Equal(t, "ProcA", result.WinningProcessor)
+	require.Len(t, result.Attempts, 2)
+	callback := result.Attempts[1]
+	assert.Equal(t, "ProcA", callback.ProcessorName)
+	assert.Equal(t, 2, callback.AttemptNumber)
+	assert.Equal(t, "async callback", callback.RoutingReason)
+	assert.Equal(t, "trace-callback", callback.TraceID)
+	assert.Equal(t, "card", callback.PaymentMethod)
+	assert.Equal(t, model.ClassificationApproved, callback.Classification)
+	assert.Equal(t, 1.0, callback.HealthAfter)
+
+	_, err = orch.ApplyCallback("tx-callback", model.ProcessorResponse{Code: model.SoftDecline})
+	assert.ErrorIs(t, err, ErrPaymentNotPending)
+	stored, _ := orch.GetPaymentHistory("tx-callback")
+	assert.Len(t, stored.Attempts, 2, "rejected callbacks append nothing")
+}
+
+func TestProcessPayment_PrimaryMinScoreRoutingReason(t *testing.T) {
+	tests := []struct {
+		name       string
+		minScore   float64
+		wantReason string
+	}{
+		{"below configured minimum", 0.8, "primary (degraded): health score 0.70 below primary minimum 0.80"},
+		{"meets configured minimum", 0.7, "primary: highest health score 0.70"},
+		{"default threshold", config.PrimaryMinScore, "primary: highest health score 0.70"},
+		{"disabled", 0, "primary: highest health score 0.70"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mon := health.NewMonitorWithConfig(10, 10*time.Minute)
+			for i := 0; i < 10; i++ {
+				code := model.Approved
+				if i < 3 {
+					code = model.ProcessorError
+				}
+				mon.RecordOutcome("ProcA", code)
+			}
+			cfg := DefaultConfig()
+			cfg.PrimaryMinScore = tt.minScore
+			orch := NewWithConfig([]processor.Processor{newDeterministicProcessor("ProcA", []string{"card"}, model.Approved)}, mon, cfg)
+
+			result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+				TransactionID: "tx-primary-min",
+				Amount:        100.0,
+				Currency:      "USD",
+				PaymentMethod: "card",
+				CustomerID:    "cust-1",
+			})
+
+			require.Len(t, result.Attempts, 1)
+			assert.Equal(t, tt.wantReason, result.Attempts[0].RoutingReason)
+		})
+	}
+}
+
+func TestProcessPayment_ExtendRetriesPastWeakPrimary(t *testing.T) {
+	tests := []struct {
+		name         string
+		extend       bool
+		wantStatus   model.PaymentStatus
+		wantAttempts int
+	}{
+		{"budget extended to reach a strong processor", true, model.StatusApproved, 3},
+		{"budget unchanged", false, model.StatusExhaustedRetries, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mon := health.NewMonitorWithConfig(10, 10*time.Minute)
+			// Cost-aware routing tries the cheap, weaker processors first
+			for name, approved := range map[string]int{"ProcA": 6, "ProcB": 7, "ProcC": 10} {
+				for i := 0; i < 10; i++ {
+					code := model.ProcessorError
+					if i < approved {
+						code = model.Approved
+					}
+					mon.RecordOutcome(name, code)
+				}
+			}
+			procs := []processor.Processor{
+				&currencyFeeProcessor{
+					newDeterministicProcessor("ProcA", []string{"card"}, model.ProcessorError),
+					processor.FeeTable{DefaultBps: 100},
+				},
+				&currencyFeeProcessor{
+					newDeterministicProcessor("ProcB", []string{"card"}, model.ProcessorError),
+					processor.FeeTable{DefaultBps: 200},
+				},
+				&currencyFeeProcessor{
+					newDeterministicProcessor("ProcC", []string{"card"}, model.Approved),
+					processor.FeeTable{DefaultBps: 300},
+				},
+			}
+			cfg := DefaultConfig()
+			cfg.MaxRetries = 2
+			cfg.CostAwareRouting = true
+			cfg.PrimaryMinScore = 0.9
+			cfg.ExtendRetriesPastWeakPrimary = tt.extend
+			orch := NewWithConfig(procs, mon, cfg)
+
+			result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+				TransactionID: "tx-weak-primary",
+				Amount:        100.0,
+				Currency:      "USD",
+				PaymentMethod: "card",
+				CustomerID:    "cust-1",
+			})
+
+			assert.Equal(t, tt.wantStatus, result.Status)
+			assert.Len(t, result.Attempts, tt.wantAttempts)
+			assert.Equal(t, "ProcA", result.Attempts[0].ProcessorName)
+		})
+	}
+}
+
+// panickingProcessor panics on every Process call.
+type panickingProcessor struct {
+	name string
+}
+
+func (p panickingProcessor) Name() string               { return p.name }
+func (p panickingProcessor) SupportedMethods() []string { return []string{"card"} }
+func (p panickingProcessor) Process(ctx context.Context, req model.PaymentRequest) model.ProcessorResponse {
+	panic("bad response mapping")
+}
+
+func TestProcessPayment_ProcessorPanicFallsThrough(t *testing.T) {
+	mon := health.NewMonitorWithConfig(10, 10*time.Minute)
+	procB := newDeterministicProcessor("ProcB", []string{"card"}, model.Approved)
+	mon.RecordOutcome("ProcB", model.Approved)
+	mon.RecordOutcome("ProcB", model.ProcessorError)
+	orch := New([]processor.Processor{panickingProcessor{"ProcA"}, procB}, mon)
+
+	result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+		TransactionID: "tx-panic",
+		Amount:        100.0,
+		Currency:      "USD",
+		PaymentMethod: "card",
+		CustomerID:    "cust-1",
+	})
+
+	require.Equal(t, model.StatusApproved, result.Status)
+	require.Len(t, result.Attempts, 2)
+	panicked := result.Attempts[0]
+	assert.Equal(t, "ProcA", panicked.ProcessorName)
+	assert.Equal(t, model.ProcessorError, panicked.Response.Code)
+	assert.Contains(t, panicked.Response.Message, "bad response mapping")
+	assert.Equal(t, "ProcB", result.WinningProcessor)
+	assert.Equal(t, 1, mon.GetHealth("ProcA").TotalRecent, "the panic counts against health")
+}
+
+func TestProcessPayment_FinalResponsePolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   FinalResponsePolicy
+		wantCode model.ResponseCode
+		wantProc string
+	}{
+		{"last attempt", FinalResponseLastAttempt, model.Timeout, "ProcB"},
+		{"default is last attempt", "", model.Timeout, "ProcB"},
+		{"most informative", FinalResponseMostInformative, model.SoftDecline, "ProcA"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mon := health.NewMonitorWithConfig(10, 10*time.Minute)
+			mon.RecordOutcome("ProcA", model.Approved)
+			procs := []processor.Processor{
+				newDeterministicProcessor("ProcA", []string{"card"}, model.SoftDecline),
+				newDeterministicProcessor("ProcB", []string{"card"}, model.Timeout),
+			}
+			cfg := DefaultConfig()
+			cfg.FinalResponsePolicy = tt.policy
+			orch := NewWithConfig(procs, mon, cfg)
+
+			result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+				TransactionID: "tx-final-response",
+				Amount:        100.0,
+				Currency:      "USD",
+				PaymentMethod: "card",
+				CustomerID:    "cust-1",
+			})
+
+			assert.Equal(t, model.StatusExhaustedRetries, result.Status)
+			require.Len(t, result.Attempts, 2)
+			assert.Equal(t, model.Timeout, result.Attempts[1].Response.Code)
+			require.NotNil(t, result.FinalResponse)
+			assert.Equal(t, tt.wantCode, result.FinalResponse.Code)
+			assert.Equal(t, tt.wantProc, result.FinalResponse.ProcessorName)
+		})
+	}
+}
+
+func newLatencyProcessor(t *testing.T, name string, latency, timeout time.Duration) *processor.MockProcessor {
+	t.Helper()
+	p, err := processor.NewMockProcessor(processor.MockConfig{
+		ProcessorName:   name,
+		Methods:         []string{"card"},
+		DefaultOutcomes: processor.OutcomeDistribution{ApprovalRate: 1.0},
+		MinLatency:      latency,
+		MaxLatency:      latency,
+		Timeout:         timeout,
+	})
+	require.NoError(t, err)
+	return p
+}
+
+func TestProcessPayment_ProcessorTimeouts(t *testing.T) {
+	mon := health.NewMonitorWithConfig(10, 10*time.Minute)
+	// ProcA is tried first but its tight SLA expires before it answers
+	mon.RecordOutcome("ProcA", model.Approved)
+	procs := []processor.Processor{
+		newLatencyProcessor(t, "ProcA", 100*time.Millisecond, 10*time.Millisecond),
+		newLatencyProcessor(t, "ProcB", 20*time.Millisecond, time.Second),
+	}
+	orch := New(procs, mon)
+
+	result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+		TransactionID: "tx-processor-timeout",
+		Amount:        100.0,
+		Currency:      "USD",
+		PaymentMethod: "card",
+		CustomerID:    "cust-1",
+	})
+
+	require.Equal(t, model.StatusApproved, result.Status)
+	require.Len(t, result.Attempts, 2)
+	assert.Equal(t, "ProcA", result.Attempts[0].ProcessorName)
+	assert.Equal(t, model.Timeout, result.Attempts[0].Response.Code)
+	assert.Less(t, result.Attempts[0].Response.Latency, 100*time.Millisecond)
+	assert.Equal(t, "ProcB", result.WinningProcessor)
+}
+
+func TestAttemptContext_EffectiveDeadline(t *testing.T) {
+	tests := []struct {
+		name             string
+		processorTimeout time.Duration
+		override         time.Duration
+		attemptTimeout   time.Duration
+		parentTimeout    time.Duration
+		want             time.Duration
+	}{
+		{"no limits", 0, 0, 0, 0, 0},
+		{"processor timeout", 50 * time.Millisecond, 0, 0, 0, 50 * time.Millisecond},
+		{"attempt timeout shorter", 50 * time.Millisecond, 0, 20 * time.Millisecond, 0, 20 * time.Millisecond},
+		{"processor timeout shorter", 10 * time.Millisecond, 0, 20 * time.Millisecond, 0, 10 * time.Millisecond},
+		{"config override", 10 * time.Millisecond, 40 * time.Millisecond, 0, 0, 40 * time.Millisecond},
+		{"remaining budget shorter", 50 * time.Millisecond, 0, 40 * time.Millisecond, 5 * time.Millisecond, 5 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.AttemptTimeout = tt.attemptTimeout
+			if tt.override > 0 {
+				cfg.ProcessorTimeouts = map[string]time.Duration{"ProcA": tt.override}
+			}
+			orch := NewWithConfig(nil, health.NewMonitor(), cfg)
+
+			parent := context.Background()
+			if tt.parentTimeout > 0 {
+				var cancel context.CancelFunc
+				parent, cancel = context.WithTimeout(parent, tt.parentTimeout)
+				defer cancel()
+			}
+			ctx, cancel := orch.attemptContext(parent, newLatencyProcessor(t, "ProcA", 0, tt.processorTimeout))
+			defer cancel()
+
+			deadline, ok := ctx.Deadline()
+			if tt.want == 0 {
+				assert.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			assert.InDelta(t, float64(tt.want), float64(time.Until(deadline)), float64(2*time.Millisecond))
+		})
+	}
+}
+
+func TestPaymentStore_ByCustomer(t *testing.T) {
+	store := NewPaymentStore()
+	save := func(txnID, customerID string) {
+		store.Save(model.PaymentResult{
+			TransactionID: txnID,
+			Request:       model.PaymentRequest{TransactionID: txnID, CustomerID: customerID},
+		})
+	}
+	save("tx-1", "cust-a")
+	save("tx-2", "cust-b")
+	save("tx-3", "cust-a")
+	save("tx-4", "cust-a")
+	save("tx-3", "cust-a") // re-saving keeps a single index entry
+	save("tx-2", "cust-a") // moving to another customer re-indexes
+
+	ids := func(results []model.PaymentResult) []string {
+		out := make([]string, 0, len(results))
+		for _, r := range results {
+			out = append(out, r.TransactionID)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name      string
+		customer  string
+		offset    int
+		limit     int
+		wantIDs   []string
+		wantTotal int
+	}{
+		{"all, most recent first", "cust-a", 0, 0, []string{"tx-2", "tx-4", "tx-3", "tx-1"}, 4},
+		{"first page", "cust-a", 0, 2, []string{"tx-2", "tx-4"}, 4},
+		{"second page", "cust-a", 2, 2, []string{"tx-3", "tx-1"}, 4},
+		{"offset past the end", "cust-a", 10, 2, []string{}, 4},
+		{"moved customer is empty", "cust-b", 0, 10, []string{}, 0},
+		{"unknown customer", "cust-z", 0, 10, []string{}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, total := store.ByCustomer(tt.customer, tt.offset, tt.limit)
+			assert.Equal(t, tt.wantIDs, ids(page))
+			assert.Equal(t, tt.wantTotal, total)
+		})
+	}
+
+	store.Reset()
+	page, total := store.ByCustomer("cust-a", 0, 10)
+	assert.Empty(t, page)
+	assert.Zero(t, total)
+}
+
+func TestProcessPayment_TimedOutAttempts(t *testing.T) {
+	mon := health.NewMonitorWithConfig(10, 10*time.Minute)
+	mon.RecordOutcome("ProcA", model.Approved)
+	// ProcB reports its own upstream timeout immediately
+	procB, err := processor.NewScriptedProcessor(processor.ScriptedConfig{
+		ProcessorName: "ProcB",
+		Methods:       []string{"card"},
+		Codes:         []model.ResponseCode{model.Timeout},
+	})
+	require.NoError(t, err)
+	procs := []processor.Processor{
+		newLatencyProcessor(t, "ProcA", 200*time.Millisecond, 0),
+		procB,
+	}
+	cfg := DefaultConfig()
+	cfg.AttemptTimeout = 30 * time.Millisecond
+	orch := NewWithConfig(procs, mon, cfg)
+
+	result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+		TransactionID: "tx-timed-out",
+		Amount:        100.0,
+		Currency:      "USD",
+		PaymentMethod: "card",
+		CustomerID:    "cust-1",
+	})
+
+	require.Len(t, result.Attempts, 2)
+	cancelled := result.Attempts[0]
+	assert.Equal(t, "ProcA", cancelled.ProcessorName)
+	assert.True(t, cancelled.TimedOut, "cut short by the attempt timeout")
+	assert.Equal(t, model.Timeout, cancelled.Response.Code)
+	assert.GreaterOrEqual(t, cancelled.Response.Latency, 30*time.Millisecond)
+	assert.Less(t, cancelled.Response.Latency, 200*time.Millisecond)
+
+	upstream := result.Attempts[1]
+	assert.Equal(t, "ProcB", upstream.ProcessorName)
+	assert.Equal(t, model.Timeout, upstream.Response.Code)
+	assert.False(t, upstream.TimedOut, "the processor's own timeout response")
+}
+
+// lateProcessor answers only after delay, ignoring cancellation.
+type lateProcessor struct {
+	*deterministicProcessor
+	delay time.Duration
+}
+
+func (p *lateProcessor) Process(ctx context.Context, req model.PaymentRequest) model.ProcessorResponse {
+	time.Sleep(p.delay)
+	return p.deterministicProcessor.Process(ctx, req)
+}
+
+func TestProcessPayment_OutcomeAtDeadlineStands(t *testing.T) {
+	tests := []struct {
+		name       string
+		code       model.ResponseCode
+		wantStatus model.PaymentStatus
+	}{
+		{"hard decline is not retried", model.DeclinedFraud, model.StatusDeclined},
+		{"pending stays pending", model.Pending, model.StatusPending},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mon := health.NewMonitorWithConfig(10, 10*time.Minute)
+			mon.RecordOutcome("ProcA", model.Approved)
+			fallback := newDeterministicProcessor("ProcB", []string{"card"}, model.Approved)
+			cfg := DefaultConfig()
+			cfg.AttemptTimeout = 10 * time.Millisecond
+			orch := NewWithConfig([]processor.Processor{
+				&lateProcessor{newDeterministicProcessor("ProcA", []string{"card"}, tt.code), 30 * time.Millisecond},
+				fallback,
+			}, mon, cfg)
+
+			result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+				TransactionID: "tx-at-deadline",
+				Amount:        100.0,
+				Currency:      "USD",
+				PaymentMethod: "card",
+				CustomerID:    "cust-1",
+			})
+
+			assert.Equal(t, tt.wantStatus, result.Status)
+			require.Len(t, result.Attempts, 1)
+			assert.Equal(t, tt.code, result.Attempts[0].Response.Code)
+			assert.False(t, result.Attempts[0].TimedOut)
+			assert.Equal(t, 0, fallback.CallCount())
+		})
+	}
+}
+
+func TestGetEligibleProcessors_LatencyWeightedRouting(t *testing.T) {
+	tests := []struct {
+		name          string
+		latencyWeight float64
+		wantFirst     string
+	}{
+		{"health only prefers the healthier", 0, "ProcSlow"},
+		{"latency-weighted blend prefers the faster", 0.5, "ProcFast"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mon := health.NewMonitorWithConfig(10, 10*time.Minute)
+			// ProcSlow is slightly healthier (1.0 vs 0.8) but three times slower
+			for i := 0; i < 5; i++ {
+				code := model.Approved
+				if i == 0 {
+					code = model.ProcessorError
+				}
+				mon.RecordResponse("ProcSlow", model.ProcessorResponse{Code: model.Approved, Latency: 300 * time.Millisecond})
+				mon.RecordResponse("ProcFast", model.ProcessorResponse{Code: code, Latency: 100 * time.Millisecond})
+			}
+			procs := []processor.Processor{
+				newDeterministicProcessor("ProcSlow", []string{"card"}, model.Approved),
+				newDeterministicProcessor("ProcFast", []string{"card"}, model.Approved),
+			}
+			cfg := DefaultConfig()
+			cfg.LatencyWeight = tt.latencyWeight
+			orch := NewWithConfig(procs, mon, cfg)
+
+			eligible, _ := orch.getEligibleProcessors(context.Background(), model.PaymentRequest{
+				TransactionID: "tx-latency-blend",
+				PaymentMethod: "card",
+			})
+			require.Len(t, eligible, 2)
+			assert.Equal(t, tt.wantFirst, eligible[0].proc.Name())
+		})
+	}
+}
+
+func TestSortByBlend_HealthOutweighsLatency(t *testing.T) {
+	eligible := []eligibleProcessor{
+		{proc: newDeterministicProcessor("Fast", nil, model.Approved), healthScore: 0.5, avgLatencyMs: 50},
+		{proc: newDeterministicProcessor("Healthy", nil, model.Approved), healthScore: 1.0, avgLatencyMs: 100},
+		{proc: newDeterministicProcessor("Unknown", nil, model.Approved), healthScore: 0.9},
+	}
+	// Healthy: 1.0-0.2*1.0 = 0.8; Unknown: 0.9-0 = 0.9; Fast: 0.5-0.2*0.5 = 0.4
+	sortByBlend(eligible, 1.0, 0.2)
+	names := []string{eligible[0].proc.Name(), eligible[1].proc.Name(), eligible[2].proc.Name()}
+	assert.Equal(t, []string{"Unknown", "Healthy", "Fast"}, names)
+}
+
+func TestGetEligibleProcessors_LatencyBreaksHealthTie(t *testing.T) {
+	mon := health.NewMonitorWithConfig(10, 10*time.Minute)
+	for i := 0; i < 5; i++ {
+		mon.RecordResponse("ProcSlow", model.ProcessorResponse{Code: model.Approved, Latency: 300 * time.Millisecond})
+		mon.RecordResponse("ProcFast", model.ProcessorResponse{Code: model.Approved, Latency: 100 * time.Millisecond})
+	}
+	procs := []processor.Processor{
+		newDeterministicProcessor("ProcSlow", []string{"card"}, model.Approved),
+		newDeterministicProcessor("ProcFast", []string{"card"}, model.Approved),
+	}
+	cfg := DefaultConfig()
+	cfg.LatencyWeight = 0.1
+	orch := NewWithConfig(procs, mon, cfg)
+
+	result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+		TransactionID: "tx-latency-tie",
+		Amount:        100.0,
+		Currency:      "USD",
+		PaymentMethod: "card",
+		CustomerID:    "cust-1",
+	})
+	assert.Equal(t, "ProcFast", result.WinningProcessor, "equal health, the faster processor is primary")
+}
+
+func TestPaymentStore_Each(t *testing.T) {
+	store := NewPaymentStore()
+	for _, id := range []string{"tx-1", "tx-2", "tx-3"} {
+		store.Save(model.PaymentResult{TransactionID: id, Status: model.StatusDeclined})
+	}
+	store.Save(model.PaymentResult{TransactionID: "tx-1", Status: model.StatusApproved}) // re-saving keeps its position
+
+	var ids []string
+	var statuses []model.PaymentStatus
+	var last time.Time
+	err := store.Each(func(r model.PaymentResult, savedAt time.Time) error {
+		ids = append(ids, r.TransactionID)
+		statuses = append(statuses, r.Status)
+		assert.False(t, savedAt.Before(last))
+		last = savedAt
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tx-1", "tx-2", "tx-3"}, ids)
+	assert.Equal(t, model.StatusApproved, statuses[0])
+
+	stop := fmt.Errorf("stop")
+	calls := 0
+	assert.ErrorIs(t, store.Each(func(model.PaymentResult, time.Time) error {
+		calls++
+		return stop
+	}), stop)
+	assert.Equal(t, 1, calls)
+
+	store.Reset()
+	require.NoError(t, store.Each(func(model.PaymentResult, time.Time) error {
+		t.Fatal("reset store should be empty")
+		return nil
+	}))
+}
+
+func TestNew_RegistersProcessorApprovalTargets(t *testing.T) {
+	target, err := processor.NewMockProcessor(processor.MockConfig{
+		ProcessorName:        "TargetPay",
+		Methods:              []string{"card"},
+		DefaultOutcomes:      processor.OutcomeDistribution{ApprovalRate: 1.0},
+		ExpectedApprovalRate: 0.85,
+	})
+	require.NoError(t, err)
+	mon := health.NewMonitorWithConfig(50, 10*time.Minute)
+	New([]processor.Processor{target, newDeterministicProcessor("ProcB", []string{"card"}, model.Approved)}, mon)
+
+	assert.Equal(t, 0.85, mon.GetHealth("TargetPay").ExpectedScore)
+	assert.Zero(t, mon.GetHealth("ProcB").ExpectedScore)
+}
+
+func TestGetEligibleProcessors_APIKeyAllowList(t *testing.T) {
+	procs := []processor.Processor{
+		newDeterministicProcessor("ProcA", []string{"card"}, model.Approved),
+		newDeterministicProcessor("ProcB", []string{"card"}, model.Approved),
+		newDeterministicProcessor("ProcC", []string{"card"}, model.Approved),
+	}
+	req := model.PaymentRequest{
+		TransactionID: "tx-allow-list", Amount: 100, Currency: "USD", PaymentMethod: "card",
+	}
+
+	tests := []struct {
+		name          string
+		key           string
+		rejectUnknown bool
+		wantEligible  []string
+	}{
+		{"key restricted to one processor", "key-a", false, []string{"ProcA"}},
+		{"key restricted to two processors", "key-bc", false, []string{"ProcB", "ProcC"}},
+		{"unknown key gets every processor", "key-unknown", false, []string{"ProcA", "ProcB", "ProcC"}},
+		{"missing key gets every processor", "", false, []string{"ProcA", "ProcB", "ProcC"}},
+		{"unknown key rejected", "key-unknown", true, nil},
+		{"missing key rejected", "", true, nil},
+		{"known key unaffected by rejection", "key-a", true, []string{"ProcA"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.ProcessorAllowList = map[string][]string{
+				"key-a":  {"ProcA"},
+				"key-bc": {"ProcB", "ProcC"},
+			}
+			cfg.RejectUnknownAPIKeys = tt.rejectUnknown
+			orch := NewWithConfig(procs, health.NewMonitorWithConfig(50, 10*time.Minute), cfg)
+
+			ctx := context.Background()
+			if tt.key != "" {
+				ctx = apikey.WithAPIKey(ctx, tt.key)
+			}
+			eligible, _ := orch.getEligibleProcessors(ctx, req)
+			var names []string
+			for _, ep := range eligible {
+				names = append(names, ep.proc.Name())
+			}
+			assert.ElementsMatch(t, tt.wantEligible, names)
+		})
+	}
+}
+
+func TestProcessPayment_APIKeyAllowListDeclinesWithoutAllowedProcessor(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ProcessorAllowList = map[string][]string{"key-pix": {"PixOnly"}}
+	orch := NewWithConfig([]processor.Processor{
+		newDeterministicProcessor("PixOnly", []string{"pix"}, model.Approved),
+		newDeterministicProcessor("GlobalPay", []string{"card"}, model.Approved),
+	}, health.NewMonitorWithConfig(50, 10*time.Minute), cfg)
+
+	result := orch.ProcessPayment(apikey.WithAPIKey(context.Background(), "key-pix"), model.PaymentRequest{
+		TransactionID: "tx-allow-list-decline", Amount: 100, Currency: "USD", PaymentMethod: "card",
+	})
+
+	assert.Equal(t, model.StatusDeclined, result.Status)
+	assert.Empty(t, result.Attempts, "the last resort is subject to the allow-list too")
+	assert.Equal(t, "no processor allowed for this API key supports payment method card", result.Reason)
+}
+
+func TestProcessPayment_RetryingProcessorIsOneAttempt(t *testing.T) {
+	inner, err := processor.NewScriptedProcessor(processor.ScriptedConfig{
+		ProcessorName: "Flaky",
+		Methods:       []string{"card"},
+		Codes:         []model.ResponseCode{model.ProcessorError, model.ProcessorError, model.Approved},
+	})
+	require.NoError(t, err)
+	mon := health.NewMonitorWithConfig(50, 10*time.Minute)
+	orch := New([]processor.Processor{
+		processor.NewRetryingProcessor(inner, processor.RetryingConfig{Retries: 2, Backoff: time.Millisecond}),
+	}, mon)
+
+	result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+		TransactionID: "tx-internal-retry", Amount: 100, Currency: "USD", PaymentMethod: "card", CustomerID: "cust-1",
+	})
+
+	assert.Equal(t, model.StatusApproved, result.Status)
+	require.Len(t, result.Attempts, 1)
+	assert.Equal(t, "Flaky", result.WinningProcessor)
+	assert.Equal(t, 3, inner.CallCount())
+	assert.Equal(t, 1.0, mon.GetHealth("Flaky").HealthScore, "internal retries are invisible to health")
+}
@@ -0,0 +1,100 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/model"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/processor"
+)
+
+// errRateLimitDeadline is returned by TokenBucket.Wait when the wait for a token
+// would outlast the context deadline.
+var errRateLimitDeadline = errors.New("rate limit wait exceeds deadline")
+
+// TokenBucket is a token-bucket rate limiter: it refills at qps tokens per second
+// up to burst, and each call takes one token. It is safe for concurrent use.
+type TokenBucket struct {
+	qps   float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket creates a full bucket allowing qps calls per second with bursts
+// of up to burst calls. A burst below 1 is treated as 1.
+func NewTokenBucket(qps float64, burst int) *TokenBucket {
+	b := float64(max(burst, 1))
+	return &TokenBucket{qps: qps, burst: b, tokens: b, last: time.Now()}
+}
+
+// Wait blocks until a token is available. It fails immediately, without taking a
+// token, when the wait would outlast ctx's deadline, and returns ctx's error if
+// ctx is done while waiting.
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.qps)
+	b.last = now
+
+	// Reserve the token now; a negative balance queues callers in arrival order
+	b.tokens--
+	var wait time.Duration
+	if b.tokens < 0 {
+		wait = time.Duration(-b.tokens / b.qps * float64(time.Second))
+	}
+	if deadline, ok := ctx.Deadline(); ok && now.Add(wait).After(deadline) {
+		b.tokens = min(b.tokens+1, b.burst)
+		b.mu.Unlock()
+		return errRateLimitDeadline
+	}
+	b.mu.Unlock()
+
+	if wait == 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		b.mu.Lock()
+		b.tokens = min(b.tokens+1, b.burst)
+		b.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// callLimited calls p once the global outbound limiter admits it. A call the
+// limiter cannot admit before ctx's deadline, or before ctx is done, is not made:
+// it gets a RateLimited response and throttled is true. A call made counts as in
+// flight for least-loaded routing until it returns.
+func (o *Orchestrator) callLimited(ctx context.Context, p processor.Processor, req model.PaymentRequest, traceID string) (resp model.ProcessorResponse, throttled bool) {
+	if o.limiter != nil {
+		start := time.Now()
+		if err := o.limiter.Wait(ctx); err != nil {
+			slog.Warn("outbound_rate_limited",
+				"txn_id", req.TransactionID,
+				"trace_id", traceID,
+				"processor", p.Name(),
+				"error", err,
+			)
+			return model.ProcessorResponse{
+				ProcessorName: p.Name(),
+				Code:          model.RateLimited,
+				Message:       "global outbound rate limit: " + err.Error(),
+				Timestamp:     time.Now(),
+				Latency:       time.Since(start),
+			}, true
+		}
+	}
+	done := o.load.begin(p.Name())
+	defer done()
+	return callProcessor(ctx, p, req, traceID), false
+}
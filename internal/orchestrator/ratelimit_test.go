@@ -0,0 +1,132 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/health"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/model"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/processor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBucket_Wait(t *testing.T) {
+	b := NewTokenBucket(100, 2)
+	tokens := func() float64 {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		return b.tokens
+	}
+
+	// The burst is available immediately, the next token takes 1/qps
+	start := time.Now()
+	require.NoError(t, b.Wait(context.Background()))
+	require.NoError(t, b.Wait(context.Background()))
+	assert.Less(t, tokens(), 1.0, "the burst is spent")
+	require.NoError(t, b.Wait(context.Background()))
+	assert.GreaterOrEqual(t, time.Since(start), 8*time.Millisecond)
+
+	// A wait past the deadline fails without consuming a token: at one token every
+	// 1000s, the drained bucket stays at zero instead of going into debt
+	b = NewTokenBucket(0.001, 1)
+	require.NoError(t, b.Wait(context.Background()))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.ErrorIs(t, b.Wait(ctx), errRateLimitDeadline)
+	assert.InDelta(t, 0, tokens(), 0.01)
+}
+
+func TestTokenBucket_CancelledWaitRefundCappedAtBurst(t *testing.T) {
+	b := NewTokenBucket(1, 1)
+	require.NoError(t, b.Wait(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- b.Wait(ctx) }()
+
+	// Once the waiter holds its reservation, let the bucket refill to burst before it gives up
+	require.Eventually(t, func() bool {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if b.tokens >= 0 {
+			return false
+		}
+		b.tokens = b.burst
+		return true
+	}, time.Second, time.Millisecond)
+	cancel()
+
+	assert.ErrorIs(t, <-errc, context.Canceled)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	assert.Equal(t, b.burst, b.tokens)
+}
+
+func TestProcessPayment_OutboundQPSThrottlesBurst(t *testing.T) {
+	proc := newDeterministicProcessor("ProcA", []string{"card"}, model.Approved)
+	cfg := DefaultConfig()
+	cfg.OutboundQPS = 50
+	cfg.OutboundBurst = 1
+	orch := NewWithConfig([]processor.Processor{proc}, health.NewMonitorWithConfig(50, 10*time.Minute), cfg)
+
+	const payments = 11
+	start := time.Now()
+	var wg sync.WaitGroup
+	results := make([]model.PaymentResult, payments)
+	for i := 0; i < payments; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = orch.ProcessPayment(context.Background(), model.PaymentRequest{
+				TransactionID: fmt.Sprintf("tx-qps-%d", i),
+				Amount:        100.0,
+				Currency:      "USD",
+				PaymentMethod: "card",
+				CustomerID:    "cust-1",
+			})
+		}()
+	}
+	wg.Wait()
+
+	// One call rides the burst; the other ten wait 20ms apart
+	assert.GreaterOrEqual(t, time.Since(start), 190*time.Millisecond)
+	assert.Equal(t, payments, proc.CallCount())
+	for _, r := range results {
+		assert.Equal(t, model.StatusApproved, r.Status)
+	}
+}
+
+func TestProcessPayment_OutboundQPSPastDeadlineIsRateLimited(t *testing.T) {
+	mon := health.NewMonitorWithConfig(50, 10*time.Minute)
+	proc := newDeterministicProcessor("ProcA", []string{"card"}, model.Approved)
+	cfg := DefaultConfig()
+	cfg.OutboundQPS = 1
+	cfg.OutboundBurst = 1
+	cfg.AttemptTimeout = 50 * time.Millisecond
+	orch := NewWithConfig([]processor.Processor{proc}, mon, cfg)
+
+	pay := func(txnID string) model.PaymentResult {
+		return orch.ProcessPayment(context.Background(), model.PaymentRequest{
+			TransactionID: txnID,
+			Amount:        100.0,
+			Currency:      "USD",
+			PaymentMethod: "card",
+			CustomerID:    "cust-1",
+		})
+	}
+
+	assert.Equal(t, model.StatusApproved, pay("tx-qps-first").Status)
+	result := pay("tx-qps-second")
+
+	assert.Equal(t, model.StatusExhaustedRetries, result.Status)
+	require.Len(t, result.Attempts, 1)
+	assert.Equal(t, model.RateLimited, result.Attempts[0].Response.Code)
+	assert.False(t, result.Attempts[0].TimedOut)
+	assert.Equal(t, 1, proc.CallCount())
+	// The processor was never called, so its health is untouched
+	assert.Equal(t, 1, mon.GetHealth("ProcA").TotalRecent)
+}
@@ -0,0 +1,37 @@
+package orchestrator
+
+import (
+	"time"
+)
+
+// recoveryDiscount returns the share of a processor's health score withheld from
+// routing after its circuit closed again: RecoveryPenalty right after recovery,
+// decaying linearly to 0 over RecoveryDecay.
+func (o *Orchestrator) recoveryDiscount(name string, now time.Time) float64 {
+	if o.cfg.RecoveryDecay <= 0 || o.cfg.RecoveryPenalty <= 0 {
+		return 0
+	}
+	recoveredAt, ok := o.monitor.RecoveredAt(name)
+	if !ok {
+		return 0
+	}
+	remaining := 1 - float64(now.Sub(recoveredAt))/float64(o.cfg.RecoveryDecay)
+	return o.cfg.RecoveryPenalty * min(max(remaining, 0), 1)
+}
+
+// applyRecoveryPenalty discounts the routing score of recently recovered
+// candidates before they are ordered, so a just-recovered upstream ramps back up
+// to primary instead of taking its full share of traffic at once. The health
+// score itself is left as reported by the monitor.
+func (o *Orchestrator) applyRecoveryPenalty(eligible []eligibleProcessor) {
+	if o.cfg.RecoveryDecay <= 0 {
+		return
+	}
+	now := time.Now()
+	for i := range eligible {
+		if discount := o.recoveryDiscount(eligible[i].proc.Name(), now); discount > 0 {
+			eligible[i].recoveryDiscount = discount
+			eligible[i].recovering = true
+		}
+	}
+}
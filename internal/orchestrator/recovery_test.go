@@ -0,0 +1,117 @@
+package orchestrator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/health"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/model"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/processor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecoveryDiscount(t *testing.T) {
+	mon := health.NewMonitorWithConfig(10, 10*time.Minute)
+	cfg := DefaultConfig()
+	cfg.RecoveryPenalty = 0.6
+	cfg.RecoveryDecay = time.Hour
+	orch := NewWithConfig(nil, mon, cfg)
+	for i := 0; i < 10; i++ {
+		mon.RecordOutcome("ProcA", model.ProcessorError)
+	}
+	mon.RecordOutcome("ProcA", model.Approved)
+	mon.RecordOutcome("ProcA", model.Approved)
+	recoveredAt, ok := mon.RecoveredAt("ProcA")
+	require.True(t, ok)
+
+	tests := []struct {
+		name  string
+		proc  string
+		after time.Duration
+		want  float64
+	}{
+		{"just recovered", "ProcA", 0, 0.6},
+		{"halfway through the decay", "ProcA", 30 * time.Minute, 0.3},
+		{"after the decay", "ProcA", 2 * time.Hour, 0},
+		{"never recovered", "ProcB", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, orch.recoveryDiscount(tt.proc, recoveredAt.Add(tt.after)), 0.001)
+		})
+	}
+}
+
+func TestGetEligibleProcessors_RecoveredProcessorRampsBack(t *testing.T) {
+	mon := health.NewMonitorWithConfig(10, 10*time.Minute)
+	cfg := DefaultConfig()
+	cfg.RecoveryPenalty = 0.5
+	cfg.RecoveryDecay = 100 * time.Millisecond
+	orch := NewWithConfig([]processor.Processor{
+		newDeterministicProcessor("ProcA", []string{"card"}, model.Approved),
+		newDeterministicProcessor("ProcB", []string{"card"}, model.Approved),
+	}, mon, cfg)
+
+	// ProcA's circuit opens, then closes again with a perfect window
+	for i := 0; i < 10; i++ {
+		mon.RecordOutcome("ProcA", model.ProcessorError)
+	}
+	for i := 0; i < 10; i++ {
+		mon.RecordOutcome("ProcA", model.Approved)
+	}
+	for i := 0; i < 10; i++ {
+		code := model.Approved
+		if i >= 8 {
+			code = model.ProcessorError
+		}
+		mon.RecordOutcome("ProcB", code)
+	}
+	require.Equal(t, 1.0, mon.GetHealth("ProcA").HealthScore)
+	require.Equal(t, 0.8, mon.GetHealth("ProcB").HealthScore)
+	req := model.PaymentRequest{TransactionID: "tx-recovery", PaymentMethod: "card"}
+
+	eligible, _ := orch.getEligibleProcessors(context.Background(), req)
+	require.Len(t, eligible, 2)
+	assert.Equal(t, "ProcB", eligible[0].proc.Name(), "just recovered processor is deprioritized")
+	assert.True(t, eligible[1].recovering)
+	assert.Equal(t, "recovering", eligible[1].role())
+
+	time.Sleep(150 * time.Millisecond)
+	eligible, _ = orch.getEligibleProcessors(context.Background(), req)
+	require.Len(t, eligible, 2)
+	assert.Equal(t, "ProcA", eligible[0].proc.Name(), "back to normal priority after the decay")
+	assert.False(t, eligible[0].recovering)
+	assert.Equal(t, 1.0, eligible[0].healthScore)
+}
+
+func TestProcessPayment_RecoveringPrimaryKeepsRawHealthScore(t *testing.T) {
+	mon := health.NewMonitorWithConfig(10, 10*time.Minute)
+	cfg := DefaultConfig()
+	cfg.RecoveryPenalty = 0.9
+	cfg.RecoveryDecay = time.Minute
+	cfg.ExtendRetriesPastWeakPrimary = true
+	orch := NewWithConfig([]processor.Processor{
+		newDeterministicProcessor("ProcA", []string{"card"}, model.Approved),
+	}, mon, cfg)
+
+	for i := 0; i < 10; i++ {
+		mon.RecordOutcome("ProcA", model.ProcessorError)
+	}
+	for i := 0; i < 10; i++ {
+		mon.RecordOutcome("ProcA", model.Approved)
+	}
+	req := model.PaymentRequest{TransactionID: "tx-recovering", Amount: 10, Currency: "USD", PaymentMethod: "card", CustomerID: "cust-1"}
+
+	eligible, _ := orch.getEligibleProcessors(context.Background(), req)
+	require.Len(t, eligible, 1)
+	assert.True(t, eligible[0].recovering)
+	assert.Equal(t, 1.0, eligible[0].healthScore, "the discount only affects ranking")
+	assert.InDelta(t, 0.1, eligible[0].routingScore(), 0.01)
+	assert.Equal(t, orch.maxRetriesFor(req), orch.attemptBudget(req, eligible), "no extra retries for a healthy recovering primary")
+
+	result := orch.ProcessPayment(context.Background(), req)
+	require.Len(t, result.Attempts, 1)
+	assert.Equal(t, "primary (recovering): circuit recently closed, health score 1.00 discounted to 0.10", result.Attempts[0].RoutingReason)
+}
@@ -0,0 +1,27 @@
+package orchestrator
+
+import "sync/atomic"
+
+// LogSampler picks which payments log their attempt detail at Info: one in every
+// N payments, counted in arrival order. The rest log it at Debug. A rate of 0 or 1
+// samples every payment.
+type LogSampler struct {
+	every uint64
+	count atomic.Uint64
+}
+
+// NewLogSampler creates a sampler that selects one in every n payments.
+func NewLogSampler(n int) *LogSampler {
+	if n < 1 {
+		n = 1
+	}
+	return &LogSampler{every: uint64(n)}
+}
+
+// Sample reports whether the next payment's attempt detail should log at Info.
+func (s *LogSampler) Sample() bool {
+	if s.every == 1 {
+		return true
+	}
+	return (s.count.Add(1)-1)%s.every == 0
+}
@@ -0,0 +1,106 @@
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/health"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/model"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/processor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogSampler(t *testing.T) {
+	tests := []struct {
+		name string
+		rate int
+		want int
+	}{
+		{"zero samples everything", 0, 100},
+		{"one samples everything", 1, 100},
+		{"one in ten", 10, 10},
+		{"one in three", 3, 34},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewLogSampler(tt.rate)
+			sampled := 0
+			for i := 0; i < 100; i++ {
+				if s.Sample() {
+					sampled++
+				}
+			}
+			assert.Equal(t, tt.want, sampled)
+		})
+	}
+}
+
+// captureLogs routes the default logger to a buffer at Info level for the test.
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo})))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+	return &buf
+}
+
+func TestProcessPayment_AttemptLogSampling(t *testing.T) {
+	logs := captureLogs(t)
+
+	mon := health.NewMonitorWithConfig(50, 10*time.Minute)
+	procs := []processor.Processor{
+		newDeterministicProcessor("ProcA", []string{"card"}, model.Approved),
+	}
+	cfg := DefaultConfig()
+	cfg.AttemptLogSampleRate = 10
+	orch := NewWithConfig(procs, mon, cfg)
+
+	const payments = 100
+	for i := 0; i < payments; i++ {
+		orch.ProcessPayment(context.Background(), model.PaymentRequest{
+			TransactionID: fmt.Sprintf("tx-sample-%d", i),
+			Amount:        10,
+			Currency:      "USD",
+			PaymentMethod: "card",
+			CustomerID:    "cust-1",
+		})
+	}
+
+	attemptLogs := strings.Count(logs.String(), "msg=payment_attempt")
+	assert.InDelta(t, payments/10, attemptLogs, 1, "roughly one in ten payments logs attempt detail at Info")
+	assert.InDelta(t, payments/10, strings.Count(logs.String(), "msg=payment_approved"), 1)
+}
+
+func TestProcessPayment_FailuresLoggedRegardlessOfSampling(t *testing.T) {
+	logs := captureLogs(t)
+
+	mon := health.NewMonitorWithConfig(50, 10*time.Minute)
+	procs := []processor.Processor{
+		newDeterministicProcessor("ProcA", []string{"card"}, model.DeclinedFraud),
+	}
+	cfg := DefaultConfig()
+	cfg.AttemptLogSampleRate = 1000
+	orch := NewWithConfig(procs, mon, cfg)
+
+	for i := 0; i < 5; i++ {
+		orch.ProcessPayment(context.Background(), model.PaymentRequest{
+			TransactionID: fmt.Sprintf("tx-fail-%d", i),
+			Amount:        10,
+			Currency:      "USD",
+			PaymentMethod: "card",
+			CustomerID:    "cust-1",
+		})
+	}
+
+	out := logs.String()
+	assert.Equal(t, 1, strings.Count(out, "msg=payment_attempt"), "only the first payment is sampled")
+	assert.Equal(t, 1, strings.Count(out, "msg=hard_decline_stopping"), "later payments hit the open circuit")
+	assert.Contains(t, out, "msg=processor_status_changed", "circuit transitions are always logged")
+	assert.Equal(t, 4, strings.Count(out, "msg=no_eligible_processors"))
+}
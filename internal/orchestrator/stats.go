@@ -0,0 +1,125 @@
+package orchestrator
+
+import (
+	"sync"
+
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/model"
+)
+
+// RetryDepthStats counts terminal payments by how many attempts they took, split
+// into approved and not approved, for the lifetime of the orchestrator.
+type RetryDepthStats struct {
+	mu        sync.Mutex
+	succeeded map[int]int64
+	failed    map[int]int64
+}
+
+// RetryDepthSnapshot is a point-in-time copy of RetryDepthStats, keyed by attempt
+// count. Payments declined before any attempt appear at depth 0.
+type RetryDepthSnapshot struct {
+	Succeeded map[int]int64 `json:"succeeded"`
+	Failed    map[int]int64 `json:"failed"`
+}
+
+// NewRetryDepthStats creates empty retry-depth counters.
+func NewRetryDepthStats() *RetryDepthStats {
+	return &RetryDepthStats{
+		succeeded: make(map[int]int64),
+		failed:    make(map[int]int64),
+	}
+}
+
+// Record counts a terminal result at its attempt depth.
+func (s *RetryDepthStats) Record(result model.PaymentResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	depth := len(result.Attempts)
+	if result.Status == model.StatusApproved {
+		s.succeeded[depth]++
+	} else {
+		s.failed[depth]++
+	}
+}
+
+// Snapshot returns a copy of the current counters.
+func (s *RetryDepthStats) Snapshot() RetryDepthSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snap := RetryDepthSnapshot{
+		Succeeded: make(map[int]int64, len(s.succeeded)),
+		Failed:    make(map[int]int64, len(s.failed)),
+	}
+	for depth, n := range s.succeeded {
+		snap.Succeeded[depth] = n
+	}
+	for depth, n := range s.failed {
+		snap.Failed[depth] = n
+	}
+	return snap
+}
+
+// Reset clears all counters.
+func (s *RetryDepthStats) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.succeeded = make(map[int]int64)
+	s.failed = make(map[int]int64)
+}
+
+// MethodStats counts terminal payments and approvals per payment method for the
+// lifetime of the orchestrator.
+type MethodStats struct {
+	mu       sync.Mutex
+	total    map[string]int64
+	approved map[string]int64
+}
+
+// MethodApproval is one payment method's lifetime totals.
+type MethodApproval struct {
+	Total        int64   `json:"total"`
+	Approved     int64   `json:"approved"`
+	ApprovalRate float64 `json:"approval_rate"`
+}
+
+// NewMethodStats creates empty per-method counters.
+func NewMethodStats() *MethodStats {
+	return &MethodStats{
+		total:    make(map[string]int64),
+		approved: make(map[string]int64),
+	}
+}
+
+// Record counts a terminal result under its request's payment method.
+func (s *MethodStats) Record(result model.PaymentResult) {
+	method := result.Request.PaymentMethod
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.total[method]++
+	if result.Status == model.StatusApproved {
+		s.approved[method]++
+	}
+}
+
+// Snapshot returns each method's totals and approval rate.
+func (s *MethodStats) Snapshot() map[string]MethodApproval {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snap := make(map[string]MethodApproval, len(s.total))
+	for method, total := range s.total {
+		approved := s.approved[method]
+		snap[method] = MethodApproval{
+			Total:        total,
+			Approved:     approved,
+			ApprovalRate: float64(approved) / float64(total),
+		}
+	}
+	return snap
+}
+
+// Reset clears all counters.
+func (s *MethodStats) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.total = make(map[string]int64)
+	s.approved = make(map[string]int64)
+}
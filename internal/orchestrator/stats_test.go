@@ -0,0 +1,106 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/health"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/model"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/processor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// approveAtProcessor approves only payments whose customer ID names it, so each
+// payment's depth is controlled by which processor it reaches first.
+type approveAtProcessor struct {
+	name string
+}
+
+func (p approveAtProcessor) Name() string               { return p.name }
+func (p approveAtProcessor) SupportedMethods() []string { return []string{"card"} }
+func (p approveAtProcessor) Process(ctx context.Context, req model.PaymentRequest) model.ProcessorResponse {
+	code := model.SoftDecline
+	if req.CustomerID == p.name {
+		code = model.Approved
+	}
+	return model.ProcessorResponse{ProcessorName: p.name, Code: code, Timestamp: time.Now()}
+}
+
+func TestRetryDepth_Distribution(t *testing.T) {
+	cfg := DefaultConfig()
+	orch := NewWithConfig([]processor.Processor{
+		approveAtProcessor{"ProcA"},
+		approveAtProcessor{"ProcB"},
+		approveAtProcessor{"ProcC"},
+	}, health.NewMonitorWithConfig(50, 10*time.Minute), cfg)
+
+	// Health is reset between payments so routing order stays registration order
+	customers := []string{"ProcA", "ProcA", "ProcB", "ProcC", "nobody", "nobody"}
+	for i, cust := range customers {
+		orch.ProcessPayment(context.Background(), model.PaymentRequest{
+			TransactionID: fmt.Sprintf("tx-depth-%d", i),
+			Amount:        10.0,
+			Currency:      "USD",
+			PaymentMethod: "card",
+			CustomerID:    cust,
+		})
+		orch.HealthMonitor().Reset()
+	}
+	orch.ProcessPayment(context.Background(), model.PaymentRequest{
+		TransactionID: "tx-depth-pix",
+		Amount:        10.0,
+		Currency:      "USD",
+		PaymentMethod: "pix",
+		CustomerID:    "ProcA",
+	})
+
+	snap := orch.RetryDepth()
+	assert.Equal(t, map[int]int64{1: 2, 2: 1, 3: 1}, snap.Succeeded)
+	assert.Equal(t, map[int]int64{0: 1, 3: 2}, snap.Failed, "no-eligible declines count at depth 0")
+
+	orch.Reset()
+	assert.Empty(t, orch.RetryDepth().Succeeded)
+	assert.Empty(t, orch.RetryDepth().Failed)
+}
+
+func TestMethodApprovals(t *testing.T) {
+	proc, err := processor.NewScriptedProcessor(processor.ScriptedConfig{
+		ProcessorName: "ProcA",
+		Methods:       []string{"card", "pix"},
+		Codes:         []model.ResponseCode{model.Approved},
+		ByTransaction: map[string][]model.ResponseCode{
+			"tx-card-3": {model.DeclinedFraud},
+			"tx-pix-1":  {model.DeclinedInsufficientFunds},
+		},
+	})
+	require.NoError(t, err)
+	cfg := DefaultConfig()
+	orch := NewWithConfig([]processor.Processor{proc}, health.NewMonitorWithConfig(50, 10*time.Minute), cfg)
+
+	pay := func(txnID, method string) {
+		orch.ProcessPayment(context.Background(), model.PaymentRequest{
+			TransactionID: txnID,
+			Amount:        10.0,
+			Currency:      "BRL",
+			PaymentMethod: method,
+			CustomerID:    "cust-1",
+		})
+	}
+	for i := 0; i < 4; i++ {
+		pay(fmt.Sprintf("tx-card-%d", i), "card")
+	}
+	pay("tx-pix-0", "pix")
+	pay("tx-pix-1", "pix")
+	pay("tx-oxxo-0", "oxxo") // no processor supports it: declined without attempts
+
+	stats := orch.MethodApprovals()
+	assert.Equal(t, MethodApproval{Total: 4, Approved: 3, ApprovalRate: 0.75}, stats["card"])
+	assert.Equal(t, MethodApproval{Total: 2, Approved: 1, ApprovalRate: 0.5}, stats["pix"])
+	assert.Equal(t, MethodApproval{Total: 1, Approved: 0, ApprovalRate: 0}, stats["oxxo"])
+
+	orch.Reset()
+	assert.Empty(t, orch.MethodApprovals())
+}
@@ -0,0 +1,46 @@
+package orchestrator
+
+import (
+	"slices"
+	"time"
+)
+
+// warmUpWeight returns how far a processor is through its warm-up, from 0 when
+// just added to 1 once WarmUpRamp has passed. Processors without an added time,
+// or with no ramp configured, are fully warm.
+func (o *Orchestrator) warmUpWeight(name string, now time.Time) float64 {
+	addedAt, ok := o.cfg.ProcessorAddedAt[name]
+	if !ok || o.cfg.WarmUpRamp <= 0 {
+		return 1
+	}
+	return min(max(float64(now.Sub(addedAt))/float64(o.cfg.WarmUpRamp), 0), 1)
+}
+
+// applyWarmUp limits a warming-up primary to its ramp weight's share of primary
+// traffic: for the rest of the payments it yields the first slot to the next
+// fully warm candidate with a closed circuit, and serves as the first fallback.
+// Without such a candidate it stays primary.
+func (o *Orchestrator) applyWarmUp(eligible []eligibleProcessor, roll func() float64) []eligibleProcessor {
+	if len(o.cfg.ProcessorAddedAt) == 0 {
+		return eligible
+	}
+	now := time.Now()
+	for i := range eligible {
+		eligible[i].warming = o.warmUpWeight(eligible[i].proc.Name(), now) < 1
+	}
+	if len(eligible) < 2 || !eligible[0].warming || eligible[0].lastResort || eligible[0].penalized {
+		return eligible
+	}
+
+	idx := slices.IndexFunc(eligible[1:], func(ep eligibleProcessor) bool {
+		return !ep.warming && !ep.lastResort && !ep.penalized
+	})
+	if idx < 0 || roll() < o.warmUpWeight(eligible[0].proc.Name(), now) {
+		return eligible
+	}
+	idx++
+	ep := eligible[idx]
+	copy(eligible[1:idx+1], eligible[:idx])
+	eligible[0] = ep
+	return eligible
+}
@@ -0,0 +1,161 @@
+package orchestrator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/health"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/model"
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/processor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWarmUpWeight(t *testing.T) {
+	now := time.Now()
+	cfg := DefaultConfig()
+	cfg.WarmUpRamp = time.Hour
+	cfg.ProcessorAddedAt = map[string]time.Time{
+		"Fresh":   now,
+		"Halfway": now.Add(-30 * time.Minute),
+		"Warm":    now.Add(-2 * time.Hour),
+		"Future":  now.Add(time.Hour),
+	}
+	orch := NewWithConfig(nil, health.NewMonitorWithConfig(50, 10*time.Minute), cfg)
+
+	tests := []struct {
+		name string
+		want float64
+	}{
+		{"Fresh", 0},
+		{"Halfway", 0.5},
+		{"Warm", 1},
+		{"Future", 0},
+		{"Unlisted", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, orch.warmUpWeight(tt.name, now), 0.001)
+		})
+	}
+}
+
+func TestGetEligibleProcessors_WarmUpRampLimitsPrimaryShare(t *testing.T) {
+	tests := []struct {
+		name       string
+		addedAgo   time.Duration
+		minPrimary int
+		maxPrimary int
+	}{
+		{"just added leads almost no payments", 6 * time.Minute, 20, 100},
+		{"after the ramp leads every payment", 2 * time.Hour, 500, 500},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Seed = 42
+			cfg.WarmUpRamp = time.Hour
+			cfg.ProcessorAddedAt = map[string]time.Time{"NewProc": time.Now().Add(-tt.addedAgo)}
+			orch := NewWithConfig([]processor.Processor{
+				newDeterministicProcessor("NewProc", []string{"card"}, model.Approved),
+				newDeterministicProcessor("OldProc", []string{"card"}, model.Approved),
+			}, health.NewMonitorWithConfig(50, 10*time.Minute), cfg)
+
+			primary := 0
+			for i := 0; i < 500; i++ {
+				eligible, _ := orch.getEligibleProcessors(context.Background(), model.PaymentRequest{
+					TransactionID: "tx-warm-up", Amount: 100, Currency: "USD", PaymentMethod: "card",
+				})
+				require.Len(t, eligible, 2, "a warming-up processor stays available as a fallback")
+				if eligible[0].proc.Name() == "NewProc" {
+					primary++
+				}
+			}
+			assert.GreaterOrEqual(t, primary, tt.minPrimary)
+			assert.LessOrEqual(t, primary, tt.maxPrimary)
+		})
+	}
+}
+
+func TestGetEligibleProcessors_WarmUpKeepsPrimaryOverOpenCircuits(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     OpenCircuitPolicy
+		lastResort string
+	}{
+		{"penalized candidate", OpenCircuitPenalize, ""},
+		{"last resort", OpenCircuitSkip, "OldProc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mon := health.NewMonitorWithConfig(10, 10*time.Minute)
+			for i := 0; i < 10; i++ {
+				mon.RecordOutcome("OldProc", model.ProcessorError)
+			}
+			require.Equal(t, health.StatusOpen, mon.GetHealth("OldProc").Status)
+
+			cfg := DefaultConfig()
+			cfg.LastResort = tt.lastResort
+			cfg.OpenCircuitPolicy = tt.policy
+			cfg.Seed = 42
+			cfg.WarmUpRamp = time.Hour
+			// Just added: a weight of 0 would yield every payment
+			cfg.ProcessorAddedAt = map[string]time.Time{"NewProc": time.Now()}
+			orch := NewWithConfig([]processor.Processor{
+				newDeterministicProcessor("NewProc", []string{"card"}, model.Approved),
+				newDeterministicProcessor("OldProc", []string{"card"}, model.Approved),
+			}, mon, cfg)
+
+			eligible, _ := orch.getEligibleProcessors(context.Background(), model.PaymentRequest{
+				TransactionID: "tx-warm-up-open", Amount: 100, Currency: "USD", PaymentMethod: "card",
+			})
+			require.Len(t, eligible, 2)
+			assert.Equal(t, "NewProc", eligible[0].proc.Name(), "an open circuit never takes over from a healthy warming primary")
+		})
+	}
+}
+
+func TestGetEligibleProcessors_WarmUpYieldsToFirstWarmCandidate(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Seed = 42
+	cfg.WarmUpRamp = time.Hour
+	cfg.ProcessorAddedAt = map[string]time.Time{"NewProc": time.Now(), "NewerProc": time.Now()}
+	mon := health.NewMonitorWithConfig(10, 10*time.Minute)
+	for i := 0; i < 10; i++ {
+		code := model.Approved
+		if i < 2 {
+			code = model.ProcessorError
+		}
+		mon.RecordOutcome("OldProc", code)
+	}
+	orch := NewWithConfig([]processor.Processor{
+		newDeterministicProcessor("NewProc", []string{"card"}, model.Approved),
+		newDeterministicProcessor("NewerProc", []string{"card"}, model.Approved),
+		newDeterministicProcessor("OldProc", []string{"card"}, model.Approved),
+	}, mon, cfg)
+
+	eligible, _ := orch.getEligibleProcessors(context.Background(), model.PaymentRequest{
+		TransactionID: "tx-warm-up-yield", Amount: 100, Currency: "USD", PaymentMethod: "card",
+	})
+	require.Len(t, eligible, 3)
+	assert.Equal(t, "OldProc", eligible[0].proc.Name(), "the primary slot goes to a fully warm processor")
+	assert.ElementsMatch(t, []string{"NewProc", "NewerProc"},
+		[]string{eligible[1].proc.Name(), eligible[2].proc.Name()}, "warming processors serve as fallbacks")
+}
+
+func TestProcessPayment_WarmingUpPrimaryRoutingReason(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.WarmUpRamp = time.Hour
+	cfg.ProcessorAddedAt = map[string]time.Time{"NewProc": time.Now().Add(-59 * time.Minute)}
+	cfg.Seed = 1
+	orch := NewWithConfig([]processor.Processor{
+		newDeterministicProcessor("NewProc", []string{"card"}, model.Approved),
+	}, health.NewMonitorWithConfig(50, 10*time.Minute), cfg)
+
+	result := orch.ProcessPayment(context.Background(), model.PaymentRequest{
+		TransactionID: "tx-warm-up-reason", Amount: 100, Currency: "USD", PaymentMethod: "card", CustomerID: "cust-1",
+	})
+	require.Len(t, result.Attempts, 1)
+	assert.Contains(t, result.Attempts[0].RoutingReason, "primary (warming up): 98% of its primary share")
+}
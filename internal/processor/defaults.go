@@ -1,10 +1,17 @@
 package processor
 
-import "time"
+import (
+	"time"
+
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/config"
+)
+
+// volumeTiers are the amount tiers every mock processor applies to its rates.
+var volumeTiers = []FeeTier{{MinAmount: config.FeeTierMinAmount, DiscountBps: config.FeeTierDiscountBps}}
 
 // NewPayFlow creates Processor A: general purpose, 70% approval, 20% soft decline, 10% errors.
 func NewPayFlow() *MockProcessor {
-	return NewMockProcessor(MockConfig{
+	return mustNewMockProcessor(MockConfig{
 		ProcessorName: "PayFlow",
 		Methods:       []string{"card", "pix", "oxxo", "pse"},
 		DefaultOutcomes: OutcomeDistribution{
@@ -15,12 +22,13 @@ func NewPayFlow() *MockProcessor {
 		},
 		MinLatency: 50 * time.Millisecond,
 		MaxLatency: 200 * time.Millisecond,
+		Fees:       FeeTable{DefaultBps: 290, CurrencyBps: map[string]int{"USD": 390}, Tiers: volumeTiers},
 	})
 }
 
 // NewCardMax creates Processor B: strong on cards, 85% approval, 10% soft decline, 5% hard decline.
 func NewCardMax() *MockProcessor {
-	return NewMockProcessor(MockConfig{
+	return mustNewMockProcessor(MockConfig{
 		ProcessorName: "CardMax",
 		Methods:       []string{"card", "oxxo"},
 		DefaultOutcomes: OutcomeDistribution{
@@ -31,12 +39,13 @@ func NewCardMax() *MockProcessor {
 		},
 		MinLatency: 80 * time.Millisecond,
 		MaxLatency: 300 * time.Millisecond,
+		Fees:       FeeTable{DefaultBps: 250, CurrencyBps: map[string]int{"USD": 350}, Tiers: volumeTiers},
 	})
 }
 
 // NewPixPay creates Processor C: LATAM specialist, 90% for PIX, 50% for cards.
 func NewPixPay() *MockProcessor {
-	return NewMockProcessor(MockConfig{
+	return mustNewMockProcessor(MockConfig{
 		ProcessorName: "PixPay",
 		Methods:       []string{"card", "pix"},
 		DefaultOutcomes: OutcomeDistribution{
@@ -58,12 +67,13 @@ func NewPixPay() *MockProcessor {
 		},
 		MinLatency: 30 * time.Millisecond,
 		MaxLatency: 150 * time.Millisecond,
+		Fees:       FeeTable{DefaultBps: 120, CurrencyBps: map[string]int{"USD": 320}, Tiers: volumeTiers},
 	})
 }
 
 // NewGlobalPay creates Processor D: universal fallback, 75% flat approval, never rate limits.
 func NewGlobalPay() *MockProcessor {
-	return NewMockProcessor(MockConfig{
+	return mustNewMockProcessor(MockConfig{
 		ProcessorName: "GlobalPay",
 		Methods:       []string{"card", "pix", "oxxo", "pse"},
 		DefaultOutcomes: OutcomeDistribution{
@@ -74,5 +84,6 @@ func NewGlobalPay() *MockProcessor {
 		},
 		MinLatency: 60 * time.Millisecond,
 		MaxLatency: 250 * time.Millisecond,
+		Fees:       FeeTable{DefaultBps: 350, CurrencyBps: map[string]int{"USD": 300}, Tiers: volumeTiers},
 	})
 }
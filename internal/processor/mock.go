@@ -2,8 +2,11 @@ package processor
 
 import (
 	"context"
+	"fmt"
+	"math"
 	"math/rand"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/model"
@@ -17,6 +20,35 @@ type OutcomeDistribution struct {
 	ErrorRate       float64
 }
 
+// rateSumTolerance absorbs floating-point error when summing configured rates.
+const rateSumTolerance = 1e-9
+
+// Validate checks that every rate is within [0, 1] and that the rates sum to 1.0,
+// so every roll in determineOutcome lands in an explicitly configured bucket.
+func (d OutcomeDistribution) Validate() error {
+	rates := []struct {
+		name string
+		rate float64
+	}{
+		{"approval_rate", d.ApprovalRate},
+		{"soft_decline_rate", d.SoftDeclineRate},
+		{"hard_decline_rate", d.HardDeclineRate},
+		{"error_rate", d.ErrorRate},
+	}
+
+	sum := 0.0
+	for _, r := range rates {
+		if r.rate < 0 || r.rate > 1 {
+			return fmt.Errorf("%s must be between 0 and 1, got %.4f", r.name, r.rate)
+		}
+		sum += r.rate
+	}
+	if math.Abs(sum-1) > rateSumTolerance {
+		return fmt.Errorf("rates must sum to 1.0, got %.4f", sum)
+	}
+	return nil
+}
+
 // MethodOverride allows per-method outcome overrides.
 type MethodOverride struct {
 	Method       string
@@ -25,12 +57,157 @@ type MethodOverride struct {
 
 // MockConfig holds configuration for creating a mock processor.
 type MockConfig struct {
-	ProcessorName    string
-	Methods          []string
-	DefaultOutcomes  OutcomeDistribution
-	MethodOverrides  []MethodOverride
-	MinLatency       time.Duration
-	MaxLatency       time.Duration
+	ProcessorName   string
+	Methods         []string
+	DefaultOutcomes OutcomeDistribution
+	MethodOverrides []MethodOverride
+	MinLatency      time.Duration
+	MaxLatency      time.Duration
+	LatencyModel    LatencyModel
+	Fees            FeeTable
+	// Timeout is the processor's SLA for a single call; zero means no limit.
+	Timeout time.Duration
+	// RateLimit, when set, answers RateLimited once a burst exceeds its threshold.
+	RateLimit *RateLimitSimulation
+	// AmountFalloff, when set, makes payments above an amount decline more often.
+	AmountFalloff *AmountFalloff
+	// ExpectedApprovalRate is the approval rate the processor should sustain, used
+	// to flag drift in its health. Zero means no target.
+	ExpectedApprovalRate float64
+}
+
+// RateLimitSimulation mimics an upstream rate limit: the first Threshold requests
+// in each Window are processed normally and the rest return RateLimited until the
+// window resets.
+type RateLimitSimulation struct {
+	Threshold int
+	Window    time.Duration
+}
+
+// AmountFalloff lowers the approval rate of large payments, as issuers decline
+// high-value card payments more often: above Threshold, approval probability
+// falls linearly by Slope per unit of amount, down to zero, and the lost share
+// becomes soft declines.
+type AmountFalloff struct {
+	Threshold float64
+	Slope     float64
+}
+
+// apply returns dist adjusted for a payment of the given amount.
+func (f AmountFalloff) apply(dist OutcomeDistribution, amount float64) OutcomeDistribution {
+	if amount <= f.Threshold {
+		return dist
+	}
+	drop := min(f.Slope*(amount-f.Threshold), dist.ApprovalRate)
+	dist.ApprovalRate -= drop
+	dist.SoftDeclineRate += drop
+	return dist
+}
+
+// LatencyModel selects how simulated latency is distributed between MinLatency and MaxLatency.
+type LatencyModel int
+
+const (
+	// LatencyUniform spreads latency evenly between min and max.
+	LatencyUniform LatencyModel = iota
+	// LatencyLongTail clusters latency near min with a lognormal-shaped tail toward max,
+	// producing realistic p99s.
+	LatencyLongTail
+)
+
+// Long-tail latency: lognormal with the median at 15% of the latency span.
+const (
+	longTailMedian = 0.15
+	longTailSigma  = 0.8
+)
+
+// SimulationMode overrides the outcome of every MockProcessor sharing a Simulation,
+// used to remove RNG noise when load testing the HTTP layer.
+type SimulationMode int32
+
+const (
+	// ModeNormal uses each processor's configured outcome distribution.
+	ModeNormal SimulationMode = iota
+	// ModeApproveAll makes every mock processor approve.
+	ModeApproveAll
+	// ModeDeclineAll makes every mock processor soft decline, so payments exhaust retries.
+	ModeDeclineAll
+)
+
+// Simulation holds the overrides an operator applies to mock processors. Mocks
+// attached to the same Simulation share them, so each owner of a Simulation, such
+// as an HTTP handler, controls only its own processors. Its zero value is ready to
+// use, in ModeNormal.
+type Simulation struct {
+	mode atomic.Int32
+
+	mu sync.RWMutex
+	// customerOutcomes forces the outcome for specific customer IDs, for
+	// deterministic end-to-end scenarios.
+	customerOutcomes map[string]model.ResponseCode
+}
+
+// SetMode sets the simulation mode.
+func (s *Simulation) SetMode(mode SimulationMode) {
+	s.mode.Store(int32(mode))
+}
+
+// Mode returns the simulation mode.
+func (s *Simulation) Mode() SimulationMode {
+	return SimulationMode(s.mode.Load())
+}
+
+// SetCustomerOutcomes makes the attached processors answer the given customers
+// with the mapped code, replacing any previous overrides. A nil or empty map clears
+// them. Overrides take precedence over the simulation mode, degradation and
+// distributions.
+func (s *Simulation) SetCustomerOutcomes(codes map[string]model.ResponseCode) {
+	copied := make(map[string]model.ResponseCode, len(codes))
+	for id, code := range codes {
+		copied[id] = code
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.customerOutcomes = copied
+}
+
+// CustomerOutcome returns the forced outcome for a customer, if one is configured.
+func (s *Simulation) CustomerOutcome(customerID string) (model.ResponseCode, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	code, ok := s.customerOutcomes[customerID]
+	return code, ok
+}
+
+// Reset restores ModeNormal and clears the customer outcomes.
+func (s *Simulation) Reset() {
+	s.SetMode(ModeNormal)
+	s.SetCustomerOutcomes(nil)
+}
+
+// ParseSimulationMode parses the API name of a simulation mode.
+func ParseSimulationMode(s string) (SimulationMode, error) {
+	switch s {
+	case "normal":
+		return ModeNormal, nil
+	case "approve_all":
+		return ModeApproveAll, nil
+	case "decline_all":
+		return ModeDeclineAll, nil
+	default:
+		return ModeNormal, fmt.Errorf("unknown simulation mode %q", s)
+	}
+}
+
+func (m SimulationMode) String() string {
+	switch m {
+	case ModeApproveAll:
+		return "approve_all"
+	case ModeDeclineAll:
+		return "decline_all"
+	default:
+		return "normal"
+	}
 }
 
 // MockProcessor simulates a payment processor with configurable behavior.
@@ -39,14 +216,71 @@ type MockProcessor struct {
 	rng      *rand.Rand
 	mu       sync.Mutex
 	degraded bool
+	sim      atomic.Pointer[Simulation]
+
+	// Rate limit window state, guarded by mu
+	windowStart time.Time
+	windowCount int
 }
 
-// NewMockProcessor creates a new mock processor from the given config.
-func NewMockProcessor(cfg MockConfig) *MockProcessor {
-	return &MockProcessor{
+// Validate checks the default distribution and every method override.
+func (c MockConfig) Validate() error {
+	if err := c.DefaultOutcomes.Validate(); err != nil {
+		return fmt.Errorf("processor %s: default outcomes: %w", c.ProcessorName, err)
+	}
+	for _, override := range c.MethodOverrides {
+		if err := override.Distribution.Validate(); err != nil {
+			return fmt.Errorf("processor %s: %s override: %w", c.ProcessorName, override.Method, err)
+		}
+	}
+	if c.ExpectedApprovalRate < 0 || c.ExpectedApprovalRate > 1 {
+		return fmt.Errorf("processor %s: expected approval rate must be between 0 and 1, got %v",
+			c.ProcessorName, c.ExpectedApprovalRate)
+	}
+	if rl := c.RateLimit; rl != nil && (rl.Threshold < 1 || rl.Window <= 0) {
+		return fmt.Errorf("processor %s: rate limit needs a positive threshold and window, got %d per %s",
+			c.ProcessorName, rl.Threshold, rl.Window)
+	}
+	if af := c.AmountFalloff; af != nil && (af.Threshold < 0 || af.Slope <= 0) {
+		return fmt.Errorf("processor %s: amount falloff needs a non-negative threshold and a positive slope, got %v and %v",
+			c.ProcessorName, af.Threshold, af.Slope)
+	}
+	return nil
+}
+
+// NewMockProcessor creates a new mock processor from the given config,
+// returning an error if any outcome distribution is invalid.
+func NewMockProcessor(cfg MockConfig) (*MockProcessor, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	p := &MockProcessor{
 		config: cfg,
 		rng:    rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
+	p.sim.Store(&Simulation{})
+	return p, nil
+}
+
+// Simulation returns the simulation overrides the processor follows. Each mock
+// starts with its own until SetSimulation attaches a shared one.
+func (p *MockProcessor) Simulation() *Simulation {
+	return p.sim.Load()
+}
+
+// SetSimulation makes the processor follow sim's overrides.
+func (p *MockProcessor) SetSimulation(sim *Simulation) {
+	p.sim.Store(sim)
+}
+
+// mustNewMockProcessor creates a mock processor from a static config, panicking
+// if the config is invalid. Only used for the built-in default processors.
+func mustNewMockProcessor(cfg MockConfig) *MockProcessor {
+	p, err := NewMockProcessor(cfg)
+	if err != nil {
+		panic(err)
+	}
+	return p
 }
 
 func (p *MockProcessor) Name() string {
@@ -57,6 +291,27 @@ func (p *MockProcessor) SupportedMethods() []string {
 	return p.config.Methods
 }
 
+// FeeBps returns the processor's fee on approved payments in a currency, in basis points.
+func (p *MockProcessor) FeeBps(currency string) int {
+	return p.config.Fees.BpsFor(currency)
+}
+
+// FeeBpsForAmount returns the processor's fee on an approved payment, in basis
+// points, applying its amount tiers.
+func (p *MockProcessor) FeeBpsForAmount(amount float64, currency string) int {
+	return p.config.Fees.BpsForAmount(amount, currency)
+}
+
+// Timeout returns the processor's SLA timeout for a single call.
+func (p *MockProcessor) Timeout() time.Duration {
+	return p.config.Timeout
+}
+
+// ExpectedApprovalRate returns the processor's approval target for drift detection.
+func (p *MockProcessor) ExpectedApprovalRate() float64 {
+	return p.config.ExpectedApprovalRate
+}
+
 // SetDegraded toggles degraded mode (80% error rate) for simulation.
 func (p *MockProcessor) SetDegraded(degraded bool) {
 	p.mu.Lock()
@@ -64,6 +319,16 @@ func (p *MockProcessor) SetDegraded(degraded bool) {
 	p.degraded = degraded
 }
 
+// SwapDegraded sets degraded mode and returns the previous state in one step, so
+// callers can tell whether anything changed.
+func (p *MockProcessor) SwapDegraded(degraded bool) (previous bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	previous = p.degraded
+	p.degraded = degraded
+	return previous
+}
+
 // IsDegraded returns the current degraded state.
 func (p *MockProcessor) IsDegraded() bool {
 	p.mu.Lock()
@@ -71,13 +336,34 @@ func (p *MockProcessor) IsDegraded() bool {
 	return p.degraded
 }
 
+// Reset clears all simulation flags, returning the processor to its default behavior.
+func (p *MockProcessor) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.degraded = false
+	p.windowStart = time.Time{}
+	p.windowCount = 0
+}
+
 func (p *MockProcessor) Process(ctx context.Context, req model.PaymentRequest) model.ProcessorResponse {
 	start := time.Now()
 
 	p.mu.Lock()
 	degraded := p.degraded
+	limited := p.rateLimited(start)
 	p.mu.Unlock()
 
+	// Rate-limited requests are rejected upfront, without processing latency
+	if limited {
+		return model.ProcessorResponse{
+			ProcessorName: p.config.ProcessorName,
+			Code:          model.RateLimited,
+			Message:       responseMessage(model.RateLimited),
+			Timestamp:     time.Now(),
+			Latency:       time.Since(start),
+		}
+	}
+
 	// Simulate latency
 	latency := p.simulateLatency()
 	select {
@@ -93,18 +379,50 @@ func (p *MockProcessor) Process(ctx context.Context, req model.PaymentRequest) m
 	}
 
 	// Determine outcome
-	code := p.determineOutcome(req.PaymentMethod, degraded)
+	code := p.determineOutcome(req.PaymentMethod, req.CustomerID, req.Amount, degraded)
+	message := responseMessage(code)
+	if req.IsVerification() && code == model.Approved {
+		message = "account verified"
+	}
 
 	return model.ProcessorResponse{
 		ProcessorName: p.config.ProcessorName,
 		Code:          code,
-		Message:       responseMessage(code),
+		Message:       message,
 		Timestamp:     time.Now(),
 		Latency:       time.Since(start),
 	}
 }
 
-func (p *MockProcessor) determineOutcome(method string, degraded bool) model.ResponseCode {
+// rateLimited counts a request at now against the current rate limit window and
+// reports whether it exceeds the threshold. The caller must hold p.mu.
+func (p *MockProcessor) rateLimited(now time.Time) bool {
+	rl := p.config.RateLimit
+	if rl == nil {
+		return false
+	}
+	if now.Sub(p.windowStart) >= rl.Window {
+		p.windowStart = now
+		p.windowCount = 0
+	}
+	p.windowCount++
+	return p.windowCount > rl.Threshold
+}
+
+func (p *MockProcessor) determineOutcome(method, customerID string, amount float64, degraded bool) model.ResponseCode {
+	sim := p.Simulation()
+	if code, ok := sim.CustomerOutcome(customerID); ok {
+		return code
+	}
+
+	// The simulation mode takes precedence over degradation and distributions
+	switch sim.Mode() {
+	case ModeApproveAll:
+		return model.Approved
+	case ModeDeclineAll:
+		return model.SoftDecline
+	}
+
 	p.mu.Lock()
 	roll := p.rng.Float64()
 	p.mu.Unlock()
@@ -124,6 +442,9 @@ func (p *MockProcessor) determineOutcome(method string, degraded bool) model.Res
 			break
 		}
 	}
+	if p.config.AmountFalloff != nil {
+		dist = p.config.AmountFalloff.apply(dist, amount)
+	}
 
 	// Roll against cumulative distribution
 	if roll < dist.ApprovalRate {
@@ -137,6 +458,11 @@ func (p *MockProcessor) determineOutcome(method string, degraded bool) model.Res
 	if roll < dist.HardDeclineRate {
 		return model.DeclinedInsufficientFunds
 	}
+	roll -= dist.HardDeclineRate
+	if roll < dist.ErrorRate {
+		return model.ProcessorError
+	}
+	// Only reachable through floating-point rounding: Validate requires the rates to sum to 1.0
 	return model.ProcessorError
 }
 
@@ -148,7 +474,15 @@ func (p *MockProcessor) simulateLatency() time.Duration {
 	if max <= min {
 		return min
 	}
-	return min + time.Duration(p.rng.Int63n(int64(max-min)))
+	span := max - min
+	if p.config.LatencyModel == LatencyLongTail {
+		frac := longTailMedian * math.Exp(longTailSigma*p.rng.NormFloat64())
+		if frac > 1 {
+			frac = 1
+		}
+		return min + time.Duration(frac*float64(span))
+	}
+	return min + time.Duration(p.rng.Int63n(int64(span)))
 }
 
 func responseMessage(code model.ResponseCode) string {
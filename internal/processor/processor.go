@@ -2,6 +2,8 @@ package processor
 
 import (
 	"context"
+	"math"
+	"time"
 
 	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/model"
 )
@@ -25,3 +27,115 @@ func SupportsMethod(p Processor, method string) bool {
 	}
 	return false
 }
+
+// FeeTable holds a processor's fees in basis points: a default rate plus
+// per-currency overrides (e.g. cross-border surcharges), lowered by amount tiers
+// for large payments.
+type FeeTable struct {
+	DefaultBps  int
+	CurrencyBps map[string]int
+	Tiers       []FeeTier
+}
+
+// FeeTier discounts the rate of payments of at least MinAmount by DiscountBps.
+type FeeTier struct {
+	MinAmount   float64
+	DiscountBps int
+}
+
+// BpsFor returns the rate for a currency, falling back to the default for
+// currencies without an override.
+func (t FeeTable) BpsFor(currency string) int {
+	if bps, ok := t.CurrencyBps[currency]; ok {
+		return bps
+	}
+	return t.DefaultBps
+}
+
+// BpsForAmount returns the rate for a payment: the currency's rate less the
+// discount of the highest tier the amount reaches, never below zero.
+func (t FeeTable) BpsForAmount(amount float64, currency string) int {
+	bps := t.BpsFor(currency)
+	var tier *FeeTier
+	for i := range t.Tiers {
+		if amount >= t.Tiers[i].MinAmount && (tier == nil || t.Tiers[i].MinAmount > tier.MinAmount) {
+			tier = &t.Tiers[i]
+		}
+	}
+	if tier != nil {
+		bps -= tier.DiscountBps
+	}
+	return max(bps, 0)
+}
+
+// FeeProvider is implemented by processors that charge a fee on approved payments.
+type FeeProvider interface {
+	// FeeBps returns the fee charged on approved payments in the given currency,
+	// in basis points.
+	FeeBps(currency string) int
+}
+
+// TieredFeeProvider is implemented by processors whose rate also depends on the
+// payment amount.
+type TieredFeeProvider interface {
+	// FeeBpsForAmount returns the fee charged on an approved payment of the given
+	// amount and currency, in basis points.
+	FeeBpsForAmount(amount float64, currency string) int
+}
+
+// FeeBps returns a processor's rate for a currency, or 0 if it doesn't implement FeeProvider.
+func FeeBps(p Processor, currency string) int {
+	fp, ok := p.(FeeProvider)
+	if !ok {
+		return 0
+	}
+	return fp.FeeBps(currency)
+}
+
+// FeeBpsForAmount returns a processor's rate for a payment of the given amount and
+// currency. Processors that don't implement TieredFeeProvider charge their FeeBps.
+func FeeBpsForAmount(p Processor, amount float64, currency string) int {
+	if tp, ok := p.(TieredFeeProvider); ok {
+		return tp.FeeBpsForAmount(amount, currency)
+	}
+	return FeeBps(p, currency)
+}
+
+// Fee returns the fee a processor charges for an approved payment of the given amount
+// and currency, rounded to cents. Processors that don't implement FeeProvider charge nothing.
+func Fee(p Processor, amount float64, currency string) float64 {
+	return math.Round(amount*float64(FeeBpsForAmount(p, amount, currency))/100) / 100
+}
+
+// TimeoutProvider is implemented by processors with their own SLA timeout per call.
+type TimeoutProvider interface {
+	// Timeout returns how long a single Process call may take; zero means no limit.
+	Timeout() time.Duration
+}
+
+// Timeout returns a processor's SLA timeout, or 0 if it doesn't implement TimeoutProvider.
+func Timeout(p Processor) time.Duration {
+	tp, ok := p.(TimeoutProvider)
+	if !ok {
+		return 0
+	}
+	return tp.Timeout()
+}
+
+// ApprovalTargetProvider is implemented by processors that declare the approval
+// rate they are expected to sustain, for drift detection.
+type ApprovalTargetProvider interface {
+	// ExpectedApprovalRate returns the expected share of approvals (0-1); zero
+	// means no target.
+	ExpectedApprovalRate() float64
+}
+
+// ExpectedApprovalRate returns a processor's declared approval target, or 0 if
+// it doesn't implement ApprovalTargetProvider.
+func ExpectedApprovalRate(p Processor) float64 {
+	ap, ok := p.(ApprovalTargetProvider)
+	if !ok {
+		return 0
+	}
+	return ap.ExpectedApprovalRate()
+}
@@ -2,6 +2,7 @@ package processor
 
 import (
 	"context"
+	"sort"
 	"testing"
 	"time"
 
@@ -190,13 +191,14 @@ func TestMockProcessor_DegradedMode(t *testing.T) {
 }
 
 func TestMockProcessor_ContextCancellation(t *testing.T) {
-	p := NewMockProcessor(MockConfig{
+	p, err := NewMockProcessor(MockConfig{
 		ProcessorName:   "SlowProcessor",
 		Methods:         []string{"card"},
 		DefaultOutcomes: OutcomeDistribution{ApprovalRate: 1.0},
 		MinLatency:      5 * time.Second,
 		MaxLatency:      5 * time.Second,
 	})
+	require.NoError(t, err)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
 	defer cancel()
@@ -266,3 +268,471 @@ func TestResponseMessage(t *testing.T) {
 		})
 	}
 }
+
+func TestFee(t *testing.T) {
+	fees := FeeTable{DefaultBps: 290, CurrencyBps: map[string]int{"USD": 390, "BRL": 0}}
+	tests := []struct {
+		name     string
+		amount   float64
+		currency string
+		expected float64
+	}{
+		{"default rate: 2.9% of 100", 100.0, "COP", 2.90},
+		{"rounds to cents", 49.99, "MXN", 1.45},
+		{"currency override", 100.0, "USD", 3.90},
+		{"zero-fee currency", 100.0, "BRL", 0},
+		{"zero amount", 0, "USD", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewMockProcessor(MockConfig{
+				ProcessorName:   "FeeProc",
+				DefaultOutcomes: OutcomeDistribution{ApprovalRate: 1.0},
+				Fees:            fees,
+			})
+			require.NoError(t, err)
+			assert.InDelta(t, tt.expected, Fee(p, tt.amount, tt.currency), 0.0001)
+		})
+	}
+}
+
+func TestFeeTable_BpsFor(t *testing.T) {
+	table := FeeTable{DefaultBps: 250, CurrencyBps: map[string]int{"USD": 350}}
+	assert.Equal(t, 350, table.BpsFor("USD"))
+	assert.Equal(t, 250, table.BpsFor("BRL"), "unknown currencies use the default rate")
+	assert.Equal(t, 0, FeeTable{}.BpsFor("USD"))
+}
+
+func TestFeeTable_BpsForAmount(t *testing.T) {
+	table := FeeTable{
+		DefaultBps:  290,
+		CurrencyBps: map[string]int{"USD": 390},
+		Tiers: []FeeTier{
+			{MinAmount: 10000, DiscountBps: 80},
+			{MinAmount: 1000, DiscountBps: 30},
+		},
+	}
+	tests := []struct {
+		name     string
+		amount   float64
+		currency string
+		want     int
+	}{
+		{"below every tier", 999.99, "BRL", 290},
+		{"first tier boundary is inclusive", 1000, "BRL", 260},
+		{"first tier", 9999.99, "BRL", 260},
+		{"highest tier reached wins", 10000, "BRL", 210},
+		{"tiers discount the currency rate", 5000, "USD", 360},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, table.BpsForAmount(tt.amount, tt.currency))
+		})
+	}
+
+	floor := FeeTable{DefaultBps: 50, Tiers: []FeeTier{{MinAmount: 100, DiscountBps: 80}}}
+	assert.Equal(t, 0, floor.BpsForAmount(500, "BRL"), "never below zero")
+}
+
+func TestFee_AmountTiers(t *testing.T) {
+	p, err := NewMockProcessor(MockConfig{
+		ProcessorName:   "TieredProc",
+		DefaultOutcomes: OutcomeDistribution{ApprovalRate: 1.0},
+		Fees:            FeeTable{DefaultBps: 290, Tiers: []FeeTier{{MinAmount: 1000, DiscountBps: 40}}},
+	})
+	require.NoError(t, err)
+	assert.InDelta(t, 28.97, Fee(p, 999, "BRL"), 0.0001)
+	assert.InDelta(t, 25.00, Fee(p, 1000, "BRL"), 0.0001)
+	assert.Equal(t, 290, FeeBps(p, "BRL"), "the amount-free rate ignores tiers")
+	assert.Equal(t, 250, FeeBpsForAmount(p, 1000, "BRL"))
+}
+
+func TestFee_ProcessorWithoutFees(t *testing.T) {
+	var p Processor = noFeeProcessor{}
+	assert.Zero(t, Fee(p, 100.0, "USD"))
+	assert.Zero(t, FeeBps(p, "USD"))
+}
+
+// noFeeProcessor implements Processor but not FeeProvider.
+type noFeeProcessor struct{}
+
+func (noFeeProcessor) Name() string               { return "NoFee" }
+func (noFeeProcessor) SupportedMethods() []string { return []string{"card"} }
+func (noFeeProcessor) Process(ctx context.Context, req model.PaymentRequest) model.ProcessorResponse {
+	return model.ProcessorResponse{ProcessorName: "NoFee", Code: model.Approved}
+}
+
+func TestOutcomeDistribution_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		dist    OutcomeDistribution
+		wantErr string
+	}{
+		{"valid full distribution", OutcomeDistribution{0.70, 0.20, 0.00, 0.10}, ""},
+		{"valid single bucket", OutcomeDistribution{ApprovalRate: 1.0}, ""},
+		{"sum over one", OutcomeDistribution{0.80, 0.20, 0.10, 0.00}, "sum to 1.0"},
+		{"sum under one", OutcomeDistribution{ApprovalRate: 0.5}, "sum to 1.0"},
+		{"negative rate", OutcomeDistribution{ApprovalRate: 1.1, SoftDeclineRate: -0.1}, "approval_rate must be between 0 and 1"},
+		{"negative error rate", OutcomeDistribution{ApprovalRate: 0.9, SoftDeclineRate: 0.3, ErrorRate: -0.2}, "error_rate must be between 0 and 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.dist.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestNewMockProcessor_RejectsInvalidConfig(t *testing.T) {
+	_, err := NewMockProcessor(MockConfig{
+		ProcessorName:   "Broken",
+		DefaultOutcomes: OutcomeDistribution{ApprovalRate: 0.9, SoftDeclineRate: 0.3},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Broken")
+
+	_, err = NewMockProcessor(MockConfig{
+		ProcessorName:   "BrokenOverride",
+		DefaultOutcomes: OutcomeDistribution{ApprovalRate: 1.0},
+		MethodOverrides: []MethodOverride{
+			{Method: "pix", Distribution: OutcomeDistribution{ApprovalRate: -0.5}},
+		},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "pix override")
+}
+
+func TestDefaultProcessors_HaveValidConfigs(t *testing.T) {
+	for _, factory := range []func() *MockProcessor{NewPayFlow, NewCardMax, NewPixPay, NewGlobalPay} {
+		p := factory()
+		assert.NoError(t, p.config.Validate(), p.Name())
+	}
+}
+
+func TestDefaultProcessors_ObservedRatesMatchConfig(t *testing.T) {
+	processors := []struct {
+		name    string
+		factory func() *MockProcessor
+	}{
+		{"PayFlow", NewPayFlow},
+		{"CardMax", NewCardMax},
+		{"PixPay", NewPixPay},
+		{"GlobalPay", NewGlobalPay},
+	}
+	for _, tt := range processors {
+		t.Run(tt.name, func(t *testing.T) {
+			p := tt.factory()
+			dist := p.config.DefaultOutcomes
+
+			// Roll the outcome directly to avoid simulated latency
+			counts := map[model.ResponseCode]int{}
+			total := 20000
+			for i := 0; i < total; i++ {
+				counts[p.determineOutcome("card", "", 100, false)]++
+			}
+
+			rate := func(code model.ResponseCode) float64 { return float64(counts[code]) / float64(total) }
+			assert.InDelta(t, dist.ApprovalRate, rate(model.Approved), 0.02)
+			assert.InDelta(t, dist.SoftDeclineRate, rate(model.SoftDecline), 0.02)
+			assert.InDelta(t, dist.HardDeclineRate, rate(model.DeclinedInsufficientFunds), 0.02)
+			assert.InDelta(t, dist.ErrorRate, rate(model.ProcessorError), 0.02,
+				"%s error rate should match configured ErrorRate", tt.name)
+		})
+	}
+}
+
+func TestMockProcessor_LongTailLatency(t *testing.T) {
+	newProc := func(lm LatencyModel) *MockProcessor {
+		p, err := NewMockProcessor(MockConfig{
+			ProcessorName:   "LatencyProc",
+			DefaultOutcomes: OutcomeDistribution{ApprovalRate: 1.0},
+			MinLatency:      10 * time.Millisecond,
+			MaxLatency:      1000 * time.Millisecond,
+			LatencyModel:    lm,
+		})
+		require.NoError(t, err)
+		return p
+	}
+
+	// Sample latencies directly to avoid sleeping
+	sample := func(p *MockProcessor) (p50, p99 time.Duration) {
+		samples := make([]time.Duration, 5000)
+		for i := range samples {
+			samples[i] = p.simulateLatency()
+			require.GreaterOrEqual(t, samples[i], 10*time.Millisecond)
+			require.LessOrEqual(t, samples[i], 1000*time.Millisecond)
+		}
+		sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+		return samples[len(samples)/2], samples[len(samples)*99/100]
+	}
+
+	uniformP50, uniformP99 := sample(newProc(LatencyUniform))
+	tailP50, tailP99 := sample(newProc(LatencyLongTail))
+
+	uniformRatio := float64(uniformP99) / float64(uniformP50)
+	tailRatio := float64(tailP99) / float64(tailP50)
+	assert.Greater(t, tailRatio, 2*uniformRatio,
+		"long tail p99/p50 (%.1f) should be much heavier than uniform (%.1f)", tailRatio, uniformRatio)
+	assert.Less(t, tailP50, uniformP50, "long tail should cluster near the minimum")
+}
+
+func TestSimulationMode_OverridesOutcomes(t *testing.T) {
+	p := NewPixPay()
+
+	tests := []struct {
+		name     string
+		mode     SimulationMode
+		degraded bool
+		want     model.ResponseCode
+	}{
+		{"approve_all", ModeApproveAll, false, model.Approved},
+		{"approve_all overrides degradation", ModeApproveAll, true, model.Approved},
+		{"decline_all", ModeDeclineAll, false, model.SoftDecline},
+		{"decline_all overrides degradation", ModeDeclineAll, true, model.SoftDecline},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p.Simulation().SetMode(tt.mode)
+			for i := 0; i < 100; i++ {
+				assert.Equal(t, tt.want, p.determineOutcome("pix", "", 100, tt.degraded))
+			}
+		})
+	}
+}
+
+func TestSimulation_SharedOnlyByAttachedProcessors(t *testing.T) {
+	shared := &Simulation{}
+	a, b, other := NewPixPay(), NewCardMax(), NewPayFlow()
+	a.SetSimulation(shared)
+	b.SetSimulation(shared)
+
+	shared.SetMode(ModeDeclineAll)
+	assert.Equal(t, model.SoftDecline, a.determineOutcome("card", "", 100, false))
+	assert.Equal(t, model.SoftDecline, b.determineOutcome("card", "", 100, false))
+	assert.Equal(t, ModeNormal, other.Simulation().Mode(), "a processor's own simulation is unaffected")
+}
+
+func TestParseSimulationMode(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    SimulationMode
+		wantErr bool
+	}{
+		{"normal", ModeNormal, false},
+		{"approve_all", ModeApproveAll, false},
+		{"decline_all", ModeDeclineAll, false},
+		{"", ModeNormal, true},
+		{"chaos", ModeNormal, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseSimulationMode(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.input, got.String())
+		})
+	}
+}
+
+func TestMockProcessor_ZeroAmountVerification(t *testing.T) {
+	p, err := NewMockProcessor(MockConfig{
+		ProcessorName:   "VerifyPay",
+		Methods:         []string{"card"},
+		DefaultOutcomes: OutcomeDistribution{ApprovalRate: 1.0},
+	})
+	require.NoError(t, err)
+
+	resp := p.Process(context.Background(), model.PaymentRequest{
+		TransactionID:   "tx-verify",
+		Amount:          0,
+		Currency:        "USD",
+		PaymentMethod:   "card",
+		CustomerID:      "cust-1",
+		AllowZeroAmount: true,
+	})
+
+	assert.Equal(t, model.Approved, resp.Code)
+	assert.Equal(t, "account verified", resp.Message)
+}
+
+func TestMockProcessor_RateLimitSimulation(t *testing.T) {
+	p, err := NewMockProcessor(MockConfig{
+		ProcessorName:   "LimitPay",
+		Methods:         []string{"card"},
+		DefaultOutcomes: OutcomeDistribution{ApprovalRate: 1.0},
+		RateLimit:       &RateLimitSimulation{Threshold: 3, Window: 100 * time.Millisecond},
+	})
+	require.NoError(t, err)
+	req := model.PaymentRequest{TransactionID: "tx-burst", Amount: 10, Currency: "USD", PaymentMethod: "card", CustomerID: "c1"}
+
+	// A fast burst exceeds the threshold within one window
+	var codes []model.ResponseCode
+	for i := 0; i < 5; i++ {
+		codes = append(codes, p.Process(context.Background(), req).Code)
+	}
+	assert.Equal(t, []model.ResponseCode{
+		model.Approved, model.Approved, model.Approved, model.RateLimited, model.RateLimited,
+	}, codes)
+
+	// Waiting out the window recovers
+	time.Sleep(120 * time.Millisecond)
+	assert.Equal(t, model.Approved, p.Process(context.Background(), req).Code)
+
+	// Requests spaced beyond the window are never limited
+	for i := 0; i < 4; i++ {
+		time.Sleep(120 * time.Millisecond)
+		assert.Equal(t, model.Approved, p.Process(context.Background(), req).Code, "request %d", i)
+	}
+
+	// Reset starts a fresh window
+	for i := 0; i < 3; i++ {
+		p.Process(context.Background(), req)
+	}
+	p.Reset()
+	assert.Equal(t, model.Approved, p.Process(context.Background(), req).Code)
+}
+
+func TestMockProcessor_AmountFalloff(t *testing.T) {
+	p, err := NewMockProcessor(MockConfig{
+		ProcessorName:   "FalloffPay",
+		Methods:         []string{"card"},
+		DefaultOutcomes: OutcomeDistribution{ApprovalRate: 0.9, SoftDeclineRate: 0.1},
+		AmountFalloff:   &AmountFalloff{Threshold: 100, Slope: 0.0005},
+	})
+	require.NoError(t, err)
+
+	approvalRate := func(amount float64) float64 {
+		approved := 0
+		total := 5000
+		for i := 0; i < total; i++ {
+			if p.Process(context.Background(), model.PaymentRequest{PaymentMethod: "card", Amount: amount}).Code == model.Approved {
+				approved++
+			}
+		}
+		return float64(approved) / float64(total)
+	}
+
+	small, large := approvalRate(10), approvalRate(1000)
+	assert.InDelta(t, 0.9, small, 0.03, "amounts under the threshold keep the configured rate")
+	assert.InDelta(t, 0.45, large, 0.03)
+	assert.Greater(t, small-large, 0.3)
+}
+
+func TestAmountFalloff_Apply(t *testing.T) {
+	dist := OutcomeDistribution{ApprovalRate: 0.8, SoftDeclineRate: 0.1, ErrorRate: 0.1}
+	falloff := AmountFalloff{Threshold: 100, Slope: 0.001}
+	tests := []struct {
+		name         string
+		amount       float64
+		wantApproval float64
+		wantSoft     float64
+	}{
+		{"at the threshold", 100, 0.8, 0.1},
+		{"linear above it", 400, 0.5, 0.4},
+		{"never below zero", 5000, 0, 0.9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := falloff.apply(dist, tt.amount)
+			assert.InDelta(t, tt.wantApproval, got.ApprovalRate, 1e-9)
+			assert.InDelta(t, tt.wantSoft, got.SoftDeclineRate, 1e-9)
+			assert.Equal(t, dist.ErrorRate, got.ErrorRate)
+			assert.NoError(t, got.Validate())
+		})
+	}
+
+	_, err := NewMockProcessor(MockConfig{
+		ProcessorName:   "FalloffPay",
+		DefaultOutcomes: OutcomeDistribution{ApprovalRate: 1.0},
+		AmountFalloff:   &AmountFalloff{Threshold: 100},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "amount falloff")
+}
+
+func TestNewMockProcessor_RejectsInvalidRateLimit(t *testing.T) {
+	for _, rl := range []RateLimitSimulation{{Threshold: 0, Window: time.Second}, {Threshold: 5}} {
+		_, err := NewMockProcessor(MockConfig{
+			ProcessorName:   "LimitPay",
+			DefaultOutcomes: OutcomeDistribution{ApprovalRate: 1.0},
+			RateLimit:       &rl,
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "rate limit")
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	p, err := NewMockProcessor(MockConfig{
+		ProcessorName:   "SlaPay",
+		DefaultOutcomes: OutcomeDistribution{ApprovalRate: 1.0},
+		Timeout:         250 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 250*time.Millisecond, Timeout(p))
+
+	scripted, err := NewScriptedProcessor(ScriptedConfig{ProcessorName: "NoSla", Codes: []model.ResponseCode{model.Approved}})
+	require.NoError(t, err)
+	assert.Zero(t, Timeout(scripted), "processors without TimeoutProvider have no limit")
+}
+
+func TestExpectedApprovalRate(t *testing.T) {
+	p, err := NewMockProcessor(MockConfig{
+		ProcessorName:        "TargetPay",
+		DefaultOutcomes:      OutcomeDistribution{ApprovalRate: 1.0},
+		ExpectedApprovalRate: 0.85,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 0.85, ExpectedApprovalRate(p))
+
+	scripted, err := NewScriptedProcessor(ScriptedConfig{ProcessorName: "NoTarget", Codes: []model.ResponseCode{model.Approved}})
+	require.NoError(t, err)
+	assert.Zero(t, ExpectedApprovalRate(scripted))
+
+	_, err = NewMockProcessor(MockConfig{
+		ProcessorName:        "BadTarget",
+		DefaultOutcomes:      OutcomeDistribution{ApprovalRate: 1.0},
+		ExpectedApprovalRate: 1.5,
+	})
+	assert.ErrorContains(t, err, "expected approval rate")
+}
+
+func TestCustomerOutcomes_OverrideEveryProcessor(t *testing.T) {
+	var sim Simulation
+	sim.SetCustomerOutcomes(map[string]model.ResponseCode{
+		"cust-fraud": model.DeclinedFraud,
+		"cust-slow":  model.Timeout,
+	})
+	// Overrides beat the simulation mode too
+	sim.SetMode(ModeApproveAll)
+
+	for _, p := range []*MockProcessor{NewPayFlow(), NewCardMax(), NewPixPay(), NewGlobalPay()} {
+		p.SetSimulation(&sim)
+		p.SetDegraded(true)
+		for i := 0; i < 2; i++ {
+			req := model.PaymentRequest{TransactionID: "tx-flagged", Amount: 10, Currency: "BRL", PaymentMethod: "card"}
+			req.CustomerID = "cust-fraud"
+			assert.Equal(t, model.DeclinedFraud, p.Process(context.Background(), req).Code, p.Name())
+			req.CustomerID = "cust-slow"
+			assert.Equal(t, model.Timeout, p.Process(context.Background(), req).Code, p.Name())
+			req.CustomerID = "cust-regular"
+			assert.Equal(t, model.Approved, p.Process(context.Background(), req).Code, p.Name())
+		}
+	}
+
+	sim.Reset()
+	_, ok := sim.CustomerOutcome("cust-fraud")
+	assert.False(t, ok)
+	assert.Equal(t, ModeNormal, sim.Mode())
+}
@@ -0,0 +1,108 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/model"
+)
+
+// RetryingConfig configures a RetryingProcessor.
+type RetryingConfig struct {
+	// Retries is how many times a transient failure (processor error, timeout,
+	// rate limit) is retried before its response is returned.
+	Retries int
+	// Backoff is waited before the first retry and doubles for each one after.
+	Backoff time.Duration
+}
+
+// RetryingProcessor wraps a Processor and retries its transient failures itself,
+// so the orchestrator sees a single attempt, e.g. for a flaky gateway. It never
+// waits past ctx's deadline: when the next backoff would outlast it, the last
+// response is returned instead. Fees and approval targets of the wrapped
+// processor pass through; its timeout bounds each try rather than the whole call.
+type RetryingProcessor struct {
+	inner Processor
+	cfg   RetryingConfig
+}
+
+// NewRetryingProcessor wraps p with internal retries. Negative retries count as none.
+func NewRetryingProcessor(p Processor, cfg RetryingConfig) *RetryingProcessor {
+	cfg.Retries = max(cfg.Retries, 0)
+	return &RetryingProcessor{inner: p, cfg: cfg}
+}
+
+func (p *RetryingProcessor) Name() string {
+	return p.inner.Name()
+}
+
+func (p *RetryingProcessor) SupportedMethods() []string {
+	return p.inner.SupportedMethods()
+}
+
+// FeeBps returns the wrapped processor's fee.
+func (p *RetryingProcessor) FeeBps(currency string) int {
+	return FeeBps(p.inner, currency)
+}
+
+// FeeBpsForAmount returns the wrapped processor's fee for a payment amount.
+func (p *RetryingProcessor) FeeBpsForAmount(amount float64, currency string) int {
+	return FeeBpsForAmount(p.inner, amount, currency)
+}
+
+// ExpectedApprovalRate returns the wrapped processor's approval target.
+func (p *RetryingProcessor) ExpectedApprovalRate() float64 {
+	return ExpectedApprovalRate(p.inner)
+}
+
+// Process calls the wrapped processor, retrying transient failures with backoff.
+// The response is the last try's, with the latency of every try and wait combined.
+func (p *RetryingProcessor) Process(ctx context.Context, req model.PaymentRequest) model.ProcessorResponse {
+	start := time.Now()
+	backoff := p.cfg.Backoff
+	resp := p.try(ctx, req)
+	retries := 0
+	for resp.Code.IsTransient() && retries < p.cfg.Retries && p.wait(ctx, backoff) {
+		retries++
+		backoff *= 2
+		resp = p.try(ctx, req)
+	}
+	if retries > 0 {
+		resp.Message = fmt.Sprintf("%s (after %d internal retries)", resp.Message, retries)
+	}
+	resp.Latency = time.Since(start)
+	return resp
+}
+
+// try makes one call, bounded by the wrapped processor's own timeout.
+func (p *RetryingProcessor) try(ctx context.Context, req model.PaymentRequest) model.ProcessorResponse {
+	if timeout := Timeout(p.inner); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return p.inner.Process(ctx, req)
+}
+
+// wait sleeps for backoff, reporting false without waiting when that would outlast
+// ctx's deadline, or when ctx is done first.
+func (p *RetryingProcessor) wait(ctx context.Context, backoff time.Duration) bool {
+	if deadline, ok := ctx.Deadline(); ok && time.Now().Add(backoff).After(deadline) {
+		return false
+	}
+	if ctx.Err() != nil {
+		return false
+	}
+	if backoff <= 0 {
+		return true
+	}
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,65 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryingProcessor(t *testing.T) {
+	tests := []struct {
+		name      string
+		codes     []model.ResponseCode
+		retries   int
+		wantCode  model.ResponseCode
+		wantCalls int
+	}{
+		{"transient failures retried until approved", []model.ResponseCode{model.ProcessorError, model.Timeout, model.Approved}, 2, model.Approved, 3},
+		{"retries exhausted return the last failure", []model.ResponseCode{model.ProcessorError, model.RateLimited, model.Approved}, 1, model.RateLimited, 2},
+		{"declines are not retried", []model.ResponseCode{model.SoftDecline, model.Approved}, 2, model.SoftDecline, 1},
+		{"zero retries calls once", []model.ResponseCode{model.ProcessorError, model.Approved}, 0, model.ProcessorError, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner, err := NewScriptedProcessor(ScriptedConfig{ProcessorName: "Flaky", Methods: []string{"card"}, Codes: tt.codes})
+			require.NoError(t, err)
+			p := NewRetryingProcessor(inner, RetryingConfig{Retries: tt.retries, Backoff: time.Millisecond})
+
+			resp := p.Process(context.Background(), model.PaymentRequest{TransactionID: "tx-retry"})
+			assert.Equal(t, tt.wantCode, resp.Code)
+			assert.Equal(t, "Flaky", resp.ProcessorName)
+			assert.Equal(t, tt.wantCalls, inner.CallCount())
+			assert.Equal(t, "Flaky", p.Name())
+			assert.True(t, SupportsMethod(p, "card"))
+		})
+	}
+}
+
+func TestRetryingProcessor_RespectsDeadline(t *testing.T) {
+	inner, err := NewScriptedProcessor(ScriptedConfig{
+		ProcessorName: "Flaky",
+		Codes:         []model.ResponseCode{model.ProcessorError, model.ProcessorError, model.Approved},
+	})
+	require.NoError(t, err)
+	p := NewRetryingProcessor(inner, RetryingConfig{Retries: 2, Backoff: 50 * time.Millisecond})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	resp := p.Process(ctx, model.PaymentRequest{TransactionID: "tx-deadline"})
+
+	assert.Equal(t, model.ProcessorError, resp.Code)
+	assert.Equal(t, 1, inner.CallCount(), "a backoff outlasting the deadline is not waited")
+	assert.Less(t, time.Since(start), 20*time.Millisecond)
+}
+
+func TestRetryingProcessor_PassesThroughFees(t *testing.T) {
+	p := NewRetryingProcessor(NewCardMax(), RetryingConfig{Retries: 1})
+	assert.Equal(t, FeeBps(NewCardMax(), "USD"), FeeBps(p, "USD"))
+	assert.Equal(t, FeeBpsForAmount(NewCardMax(), 10000, "USD"), FeeBpsForAmount(p, 10000, "USD"))
+	assert.Equal(t, ExpectedApprovalRate(NewCardMax()), ExpectedApprovalRate(p))
+}
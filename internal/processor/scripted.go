@@ -0,0 +1,130 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/model"
+)
+
+// ScriptedConfig defines the responses a ScriptedProcessor returns.
+type ScriptedConfig struct {
+	ProcessorName string
+	Methods       []string
+	// Codes are returned in order on successive calls, cycling when exhausted.
+	Codes []model.ResponseCode
+	// ByTransaction scripts responses for specific transaction IDs, taking
+	// precedence over Codes. Each transaction's sequence cycles independently.
+	ByTransaction map[string][]model.ResponseCode
+	// Latency is waited before each response; a cancelled context returns Timeout.
+	Latency time.Duration
+}
+
+// ScriptedProcessor is a fully deterministic Processor for tests: it returns
+// the configured response codes in order instead of sampling a distribution.
+// It is safe for concurrent use.
+type ScriptedProcessor struct {
+	config ScriptedConfig
+
+	mu     sync.Mutex
+	next   int
+	byTxn  map[string]int
+	calls  int
+	called []string
+}
+
+// NewScriptedProcessor creates a scripted processor, returning an error if the
+// config has no name or no scripted codes.
+func NewScriptedProcessor(cfg ScriptedConfig) (*ScriptedProcessor, error) {
+	if cfg.ProcessorName == "" {
+		return nil, errors.New("scripted processor: name is required")
+	}
+	if len(cfg.Codes) == 0 && len(cfg.ByTransaction) == 0 {
+		return nil, errors.New("scripted processor " + cfg.ProcessorName + ": no codes scripted")
+	}
+	return &ScriptedProcessor{config: cfg, byTxn: make(map[string]int)}, nil
+}
+
+func (p *ScriptedProcessor) Name() string {
+	return p.config.ProcessorName
+}
+
+func (p *ScriptedProcessor) SupportedMethods() []string {
+	return p.config.Methods
+}
+
+// Process returns the next scripted code for the request's transaction, or the
+// next code from Codes. A transaction with no script and an empty Codes list
+// gets ProcessorError.
+func (p *ScriptedProcessor) Process(ctx context.Context, req model.PaymentRequest) model.ProcessorResponse {
+	start := time.Now()
+	code, message := p.nextCode(req.TransactionID)
+
+	if p.config.Latency > 0 {
+		select {
+		case <-time.After(p.config.Latency):
+		case <-ctx.Done():
+			return model.ProcessorResponse{
+				ProcessorName: p.config.ProcessorName,
+				Code:          model.Timeout,
+				Message:       "context cancelled",
+				Timestamp:     time.Now(),
+				Latency:       time.Since(start),
+			}
+		}
+	}
+
+	return model.ProcessorResponse{
+		ProcessorName: p.config.ProcessorName,
+		Code:          code,
+		Message:       message,
+		Timestamp:     time.Now(),
+		Latency:       time.Since(start),
+	}
+}
+
+func (p *ScriptedProcessor) nextCode(txnID string) (model.ResponseCode, string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls++
+	p.called = append(p.called, txnID)
+
+	if codes, ok := p.config.ByTransaction[txnID]; ok && len(codes) > 0 {
+		idx := p.byTxn[txnID]
+		p.byTxn[txnID] = idx + 1
+		code := codes[idx%len(codes)]
+		return code, responseMessage(code)
+	}
+	if len(p.config.Codes) == 0 {
+		return model.ProcessorError, "no scripted response for transaction " + txnID
+	}
+	code := p.config.Codes[p.next%len(p.config.Codes)]
+	p.next++
+	return code, responseMessage(code)
+}
+
+// CallCount returns how many times Process has been called.
+func (p *ScriptedProcessor) CallCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.calls
+}
+
+// Calls returns the transaction IDs passed to Process, in call order.
+func (p *ScriptedProcessor) Calls() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.called...)
+}
+
+// Reset rewinds every script to its first code and clears the call history.
+func (p *ScriptedProcessor) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.next = 0
+	p.calls = 0
+	p.called = nil
+	p.byTxn = make(map[string]int)
+}
@@ -0,0 +1,133 @@
+package processor
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewScriptedProcessor_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  ScriptedConfig
+	}{
+		{"missing name", ScriptedConfig{Codes: []model.ResponseCode{model.Approved}}},
+		{"no codes", ScriptedConfig{ProcessorName: "Fake"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewScriptedProcessor(tt.cfg)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestScriptedProcessor_SequenceCycles(t *testing.T) {
+	p, err := NewScriptedProcessor(ScriptedConfig{
+		ProcessorName: "Fake",
+		Methods:       []string{"card"},
+		Codes:         []model.ResponseCode{model.Timeout, model.SoftDecline, model.Approved},
+	})
+	require.NoError(t, err)
+
+	var got []model.ResponseCode
+	for i := 0; i < 5; i++ {
+		resp := p.Process(context.Background(), model.PaymentRequest{TransactionID: "tx"})
+		assert.Equal(t, "Fake", resp.ProcessorName)
+		got = append(got, resp.Code)
+	}
+	assert.Equal(t, []model.ResponseCode{
+		model.Timeout, model.SoftDecline, model.Approved, model.Timeout, model.SoftDecline,
+	}, got)
+	assert.Equal(t, 5, p.CallCount())
+	assert.True(t, SupportsMethod(p, "card"))
+
+	p.Reset()
+	assert.Equal(t, 0, p.CallCount())
+	assert.Equal(t, model.Timeout, p.Process(context.Background(), model.PaymentRequest{}).Code)
+}
+
+func TestScriptedProcessor_ByTransaction(t *testing.T) {
+	p, err := NewScriptedProcessor(ScriptedConfig{
+		ProcessorName: "Fake",
+		Codes:         []model.ResponseCode{model.Approved},
+		ByTransaction: map[string][]model.ResponseCode{
+			"tx-fraud": {model.DeclinedFraud},
+			"tx-flaky": {model.ProcessorError, model.Approved},
+		},
+	})
+	require.NoError(t, err)
+
+	process := func(txnID string) model.ResponseCode {
+		return p.Process(context.Background(), model.PaymentRequest{TransactionID: txnID}).Code
+	}
+	assert.Equal(t, model.DeclinedFraud, process("tx-fraud"))
+	assert.Equal(t, model.ProcessorError, process("tx-flaky"))
+	assert.Equal(t, model.Approved, process("tx-other"), "unscripted transactions use Codes")
+	assert.Equal(t, model.Approved, process("tx-flaky"), "each transaction advances its own script")
+	assert.Equal(t, model.DeclinedFraud, process("tx-fraud"))
+	assert.Equal(t, []string{"tx-fraud", "tx-flaky", "tx-other", "tx-flaky", "tx-fraud"}, p.Calls())
+}
+
+func TestScriptedProcessor_UnscriptedTransactionWithoutDefault(t *testing.T) {
+	p, err := NewScriptedProcessor(ScriptedConfig{
+		ProcessorName: "Fake",
+		ByTransaction: map[string][]model.ResponseCode{"tx-1": {model.Approved}},
+	})
+	require.NoError(t, err)
+
+	resp := p.Process(context.Background(), model.PaymentRequest{TransactionID: "tx-2"})
+	assert.Equal(t, model.ProcessorError, resp.Code)
+	assert.Contains(t, resp.Message, "tx-2")
+}
+
+func TestScriptedProcessor_Latency(t *testing.T) {
+	p, err := NewScriptedProcessor(ScriptedConfig{
+		ProcessorName: "Fake",
+		Codes:         []model.ResponseCode{model.Approved},
+		Latency:       20 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	resp := p.Process(context.Background(), model.PaymentRequest{})
+	assert.Equal(t, model.Approved, resp.Code)
+	assert.GreaterOrEqual(t, resp.Latency, 20*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	resp = p.Process(ctx, model.PaymentRequest{})
+	assert.Equal(t, model.Timeout, resp.Code)
+}
+
+func TestScriptedProcessor_ConcurrentUse(t *testing.T) {
+	p, err := NewScriptedProcessor(ScriptedConfig{
+		ProcessorName: "Fake",
+		Codes:         []model.ResponseCode{model.Approved, model.SoftDecline},
+	})
+	require.NoError(t, err)
+
+	const calls = 200
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	counts := map[model.ResponseCode]int{}
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			code := p.Process(context.Background(), model.PaymentRequest{}).Code
+			mu.Lock()
+			counts[code]++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, calls, p.CallCount())
+	assert.Equal(t, calls/2, counts[model.Approved], "every scripted code is handed out exactly once per cycle")
+	assert.Equal(t, calls/2, counts[model.SoftDecline])
+}
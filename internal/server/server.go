@@ -0,0 +1,90 @@
+// Package server provides NewServer and reads its connection timeouts from the environment.
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/marlonbarreto-git/nimbus-payment-orchestrator/internal/config"
+)
+
+// Environment variables overriding the default timeouts. Values use Go duration
+// syntax, e.g. "5s" or "2m".
+const (
+	EnvReadHeaderTimeout = "SERVER_READ_HEADER_TIMEOUT"
+	EnvReadTimeout       = "SERVER_READ_TIMEOUT"
+	EnvWriteTimeout      = "SERVER_WRITE_TIMEOUT"
+	EnvIdleTimeout       = "SERVER_IDLE_TIMEOUT"
+)
+
+// Config holds the HTTP server address and connection timeouts.
+type Config struct {
+	Addr              string
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+}
+
+// DefaultConfig returns the server settings defined in the config package.
+func DefaultConfig() Config {
+	return Config{
+		Addr:              config.ServerPort,
+		ReadHeaderTimeout: time.Duration(config.ReadHeaderTimeoutSeconds) * time.Second,
+		ReadTimeout:       time.Duration(config.ReadTimeoutSeconds) * time.Second,
+		WriteTimeout:      time.Duration(config.WriteTimeoutSeconds) * time.Second,
+		IdleTimeout:       time.Duration(config.IdleTimeoutSeconds) * time.Second,
+	}
+}
+
+// ConfigFromEnv returns DefaultConfig with PORT and the SERVER_*_TIMEOUT
+// environment variables applied. Timeouts must be positive durations.
+func ConfigFromEnv() (Config, error) {
+	return configFromLookup(os.LookupEnv)
+}
+
+func configFromLookup(lookup func(string) (string, bool)) (Config, error) {
+	cfg := DefaultConfig()
+	if port, ok := lookup("PORT"); ok && port != "" {
+		cfg.Addr = ":" + port
+	}
+
+	timeouts := []struct {
+		env string
+		dst *time.Duration
+	}{
+		{EnvReadHeaderTimeout, &cfg.ReadHeaderTimeout},
+		{EnvReadTimeout, &cfg.ReadTimeout},
+		{EnvWriteTimeout, &cfg.WriteTimeout},
+		{EnvIdleTimeout, &cfg.IdleTimeout},
+	}
+	for _, t := range timeouts {
+		raw, ok := lookup(t.env)
+		if !ok || raw == "" {
+			continue
+		}
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return Config{}, fmt.Errorf("parse %s: %w", t.env, err)
+		}
+		if d <= 0 {
+			return Config{}, fmt.Errorf("%s must be positive, got %s", t.env, raw)
+		}
+		*t.dst = d
+	}
+	return cfg, nil
+}
+
+// NewServer creates an http.Server serving handler with the configured timeouts.
+func NewServer(handler http.Handler, cfg Config) *http.Server {
+	return &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewServer_AppliesTimeouts(t *testing.T) {
+	mux := http.NewServeMux()
+	cfg := Config{
+		Addr:              ":9090",
+		ReadHeaderTimeout: 2 * time.Second,
+		ReadTimeout:       3 * time.Second,
+		WriteTimeout:      4 * time.Second,
+		IdleTimeout:       5 * time.Second,
+	}
+
+	srv := NewServer(mux, cfg)
+
+	assert.Equal(t, ":9090", srv.Addr)
+	assert.Equal(t, mux, srv.Handler)
+	assert.Equal(t, 2*time.Second, srv.ReadHeaderTimeout)
+	assert.Equal(t, 3*time.Second, srv.ReadTimeout)
+	assert.Equal(t, 4*time.Second, srv.WriteTimeout)
+	assert.Equal(t, 5*time.Second, srv.IdleTimeout)
+}
+
+func TestDefaultConfig_SetsAllTimeouts(t *testing.T) {
+	cfg := DefaultConfig()
+	assert.Positive(t, cfg.ReadHeaderTimeout)
+	assert.Positive(t, cfg.ReadTimeout)
+	assert.Positive(t, cfg.WriteTimeout)
+	assert.Positive(t, cfg.IdleTimeout)
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		check   func(t *testing.T, cfg Config)
+		wantErr bool
+	}{
+		{
+			name: "defaults when unset",
+			env:  map[string]string{},
+			check: func(t *testing.T, cfg Config) {
+				assert.Equal(t, DefaultConfig(), cfg)
+			},
+		},
+		{
+			name: "overrides port and timeouts",
+			env: map[string]string{
+				"PORT":               "9000",
+				EnvReadHeaderTimeout: "1s",
+				EnvWriteTimeout:      "2m",
+			},
+			check: func(t *testing.T, cfg Config) {
+				assert.Equal(t, ":9000", cfg.Addr)
+				assert.Equal(t, time.Second, cfg.ReadHeaderTimeout)
+				assert.Equal(t, 2*time.Minute, cfg.WriteTimeout)
+				assert.Equal(t, DefaultConfig().IdleTimeout, cfg.IdleTimeout)
+			},
+		},
+		{
+			name:    "invalid duration",
+			env:     map[string]string{EnvReadTimeout: "soon"},
+			wantErr: true,
+		},
+		{
+			name:    "non-positive duration",
+			env:     map[string]string{EnvIdleTimeout: "0s"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lookup := func(key string) (string, bool) {
+				v, ok := tt.env[key]
+				return v, ok
+			}
+			cfg, err := configFromLookup(lookup)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			tt.check(t, cfg)
+		})
+	}
+}
@@ -0,0 +1,21 @@
+// Package trace carries a per-request trace (correlation) ID through context so it
+// can be logged and forwarded to upstream processors.
+package trace
+
+import "context"
+
+// HeaderName is the HTTP header used to receive and echo trace IDs.
+const HeaderName = "X-Trace-ID"
+
+type contextKey struct{}
+
+// WithTraceID returns a copy of ctx carrying the given trace ID.
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, traceID)
+}
+
+// TraceIDFromContext returns the trace ID carried by ctx, or "" if none is set.
+func TraceIDFromContext(ctx context.Context) string {
+	traceID, _ := ctx.Value(contextKey{}).(string)
+	return traceID
+}
@@ -0,0 +1,25 @@
+package trace
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTraceIDFromContext(t *testing.T) {
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		expected string
+	}{
+		{"no trace ID", context.Background(), ""},
+		{"with trace ID", WithTraceID(context.Background(), "trace-123"), "trace-123"},
+		{"overridden trace ID", WithTraceID(WithTraceID(context.Background(), "old"), "new"), "new"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, TraceIDFromContext(tt.ctx))
+		})
+	}
+}